@@ -2,7 +2,10 @@ package ast
 
 import (
 	"bytes"
+	"strings"
+	"time"
 
+	"github.com/hellracer2007/webCalc/calculator/constants"
 	"github.com/hellracer2007/webCalc/calculator/token"
 )
 
@@ -114,10 +117,44 @@ func (fl *FloatLiteral) TokenLiteral() string {return fl.Token.Literal}
 func (fl *FloatLiteral) String() string {return fl.Token.Literal}
 
 var Euler = &FloatLiteral{
-	Token: token.Token{Type: token.INT, Literal: "2.7182818284"},
-	Value: 2.7182818284,
+	Token: token.Token{Type: token.EULER, Literal: constants.E.Name},
+	Value: constants.E.Value,
 }
 
+// DateLiteral is an ISO 8601 date such as 2026-10-18 or
+// 2026-10-18T14:30, taken as UTC. Invalid marks a day the calendar does
+// not have, such as 2026-02-30.
+type DateLiteral struct {
+	Token   token.Token
+	Value   time.Time
+	Invalid bool
+}
+func (dl *DateLiteral) expressionNode()	{}
+func (dl *DateLiteral) TokenLiteral() string {return dl.Token.Literal}
+func (dl *DateLiteral) String() string {return dl.Token.Literal}
+
+// DurationLiteral is an ISO 8601 duration such as P1Y2M10DT2H30M. Years
+// and months are counted in calendar months and weeks in days, since
+// their length depends on the date they are added to.
+type DurationLiteral struct {
+	Token	token.Token
+	Months	int64
+	Days	int64
+	Seconds	float64
+}
+func (dl *DurationLiteral) expressionNode()	{}
+func (dl *DurationLiteral) TokenLiteral() string {return dl.Token.Literal}
+func (dl *DurationLiteral) String() string {return dl.Token.Literal}
+
+type Identifier struct {
+	Token token.Token
+	Value string
+}
+
+func (i *Identifier) expressionNode()	{}
+func (i *Identifier) TokenLiteral() string {return i.Token.Literal}
+func (i *Identifier) String() string {return i.Value}
+
 type Procedure struct {
 	Token token.Token
 	Func	string
@@ -128,8 +165,13 @@ func (pr *Procedure) expressionNode()	{}
 func (pr *Procedure) TokenLiteral() string {return pr.Token.Literal}
 func (pr *Procedure) String() string {
 	var out bytes.Buffer
+	body := pr.Body.String()
 	out.WriteString(pr.Token.Literal)
-	out.WriteString(pr.Body.String())
+	// infix and prefix expressions bring their own parentheses
+	if !strings.HasPrefix(body, "(") {
+		body = "(" + body + ")"
+	}
+	out.WriteString(body)
 	return out.String()
 }
 
@@ -144,3 +186,115 @@ func (pof *PostfixExpression) TokenLiteral() string {return pof.Token.Literal}
 func (pof *PostfixExpression) String() string {
 	return pof.Token.Literal+pof.Left.String()
 }
+
+type UnitLiteral struct {
+	Token token.Token
+	Value string
+}
+
+func (ul *UnitLiteral) expressionNode()	{}
+func (ul *UnitLiteral) TokenLiteral() string {return ul.Token.Literal}
+func (ul *UnitLiteral) String() string {return ul.Value}
+
+type ConversionExpression struct {
+	Token	token.Token // the to or in token
+	Left	Expression
+	Unit	*UnitLiteral
+}
+
+func (ce *ConversionExpression) expressionNode()	{}
+func (ce *ConversionExpression) TokenLiteral() string {return ce.Token.Literal}
+func (ce *ConversionExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	if ce.Left != nil {
+		out.WriteString(ce.Left.String())
+	}
+	out.WriteString(" " + ce.Token.Literal + " ")
+	if ce.Unit != nil {
+		out.WriteString(ce.Unit.String())
+	}
+	out.WriteString(")")
+	return out.String()
+}
+
+type VectorLiteral struct {
+	Token		token.Token // the [ token
+	Elements	[]Expression
+}
+
+func (vl *VectorLiteral) expressionNode()	{}
+func (vl *VectorLiteral) TokenLiteral() string {return vl.Token.Literal}
+func (vl *VectorLiteral) String() string {
+	var out bytes.Buffer
+	elements := []string{}
+	for _, el := range vl.Elements {
+		elements = append(elements, el.String())
+	}
+	out.WriteString("[")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("]")
+	return out.String()
+}
+
+// ListLiteral is a list of values in braces, {1, 2, 3}.
+type ListLiteral struct {
+	Token		token.Token // the { token
+	Elements	[]Expression
+}
+
+func (ll *ListLiteral) expressionNode()	{}
+func (ll *ListLiteral) TokenLiteral() string {return ll.Token.Literal}
+func (ll *ListLiteral) String() string {
+	elements := []string{}
+	for _, el := range ll.Elements {
+		elements = append(elements, el.String())
+	}
+	return "{" + strings.Join(elements, ", ") + "}"
+}
+
+// CallExpression is a named function applied to a list of arguments,
+// e.g. dot([1, 2], [3, 4]).
+type CallExpression struct {
+	Token		token.Token // the function name
+	Func		string
+	Arguments	[]Expression
+}
+
+func (ce *CallExpression) expressionNode()	{}
+func (ce *CallExpression) TokenLiteral() string {return ce.Token.Literal}
+func (ce *CallExpression) String() string {
+	var out bytes.Buffer
+	args := []string{}
+	for _, a := range ce.Arguments {
+		args = append(args, a.String())
+	}
+	out.WriteString(ce.Func)
+	out.WriteString("(")
+	out.WriteString(strings.Join(args, ", "))
+	out.WriteString(")")
+	return out.String()
+}
+
+// Equation is left = right, as used by solve.
+type Equation struct {
+	Token	token.Token // the = token
+	Left	Expression
+	Right	Expression
+}
+
+func (eq *Equation) expressionNode()	{}
+func (eq *Equation) TokenLiteral() string {return eq.Token.Literal}
+func (eq *Equation) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	if eq.Left != nil {
+		out.WriteString(eq.Left.String())
+	}
+	out.WriteString(" = ")
+	if eq.Right != nil {
+		out.WriteString(eq.Right.String())
+	}
+	out.WriteString(")")
+	return out.String()
+}
@@ -0,0 +1,43 @@
+package ast
+
+// Inspect traverses the tree rooted at node in depth first order, calling
+// f for every node. Children are skipped when f returns false.
+func Inspect(node Node, f func(Node) bool) {
+	if node == nil || !f(node) {
+		return
+	}
+	switch n := node.(type) {
+	case *Program:
+		for _, s := range n.Statements {
+			Inspect(s, f)
+		}
+	case *ExpressionStatement:
+		Inspect(n.Expression, f)
+	case *PrefixExpression:
+		Inspect(n.Right, f)
+	case *InfixExpression:
+		Inspect(n.Left, f)
+		Inspect(n.Right, f)
+	case *PostfixExpression:
+		Inspect(n.Left, f)
+	case *Procedure:
+		Inspect(n.Body, f)
+	case *ConversionExpression:
+		Inspect(n.Left, f)
+	case *VectorLiteral:
+		for _, el := range n.Elements {
+			Inspect(el, f)
+		}
+	case *ListLiteral:
+		for _, el := range n.Elements {
+			Inspect(el, f)
+		}
+	case *CallExpression:
+		for _, a := range n.Arguments {
+			Inspect(a, f)
+		}
+	case *Equation:
+		Inspect(n.Left, f)
+		Inspect(n.Right, f)
+	}
+}
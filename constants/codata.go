@@ -0,0 +1,57 @@
+package constants
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//go:embed codata.txt
+var codata string
+
+var physical []*Constant
+
+// Physical returns the physical constants in the order of the CODATA file.
+func Physical() []*Constant {
+	return physical
+}
+
+func parseCodata(data string) ([]*Constant, error) {
+	var result []*Constant
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) < 6 {
+			return nil, fmt.Errorf("codata.txt:%d: expected 6 columns, got %d", line, len(fields))
+		}
+		value, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("codata.txt:%d: bad value %q", line, fields[2])
+		}
+		uncertainty, err := strconv.ParseFloat(fields[3], 64)
+		if err != nil {
+			return nil, fmt.Errorf("codata.txt:%d: bad uncertainty %q", line, fields[3])
+		}
+		c := &Constant{
+			Name:        fields[0],
+			Value:       value,
+			Digits:      fields[2],
+			Uncertainty: uncertainty,
+			Unit:        fields[4],
+			Description: strings.Join(fields[5:], " "),
+		}
+		if fields[1] != "-" {
+			c.Symbol = fields[1]
+		}
+		result = append(result, c)
+	}
+	return result, scanner.Err()
+}
@@ -0,0 +1,115 @@
+package constants
+
+import (
+	"math"
+	"math/big"
+)
+
+type Constant struct {
+	Name   string
+	Symbol string
+	Value  float64
+	// Digits is the decimal expansion used when more precision than a
+	// float64 is asked for.
+	Digits string
+
+	// Physical constants also carry their SI unit, standard uncertainty
+	// (0 when exact) and a short description.
+	Unit        string
+	Uncertainty float64
+	Description string
+}
+
+// Float returns the constant as a big.Float with prec bits of mantissa.
+// NaN has no big.Float representation, so nil is returned for it.
+func (c *Constant) Float(prec uint) *big.Float {
+	if math.IsNaN(c.Value) {
+		return nil
+	}
+	if math.IsInf(c.Value, 0) {
+		return new(big.Float).SetPrec(prec).SetInf(c.Value < 0)
+	}
+	f, _, err := big.ParseFloat(c.Digits, 10, prec, big.ToNearestEven)
+	if err != nil {
+		return new(big.Float).SetPrec(prec).SetFloat64(c.Value)
+	}
+	return f
+}
+
+var (
+	E = &Constant{
+		Name:   "e",
+		Value:  math.E,
+		Digits: "2.71828182845904523536028747135266249775724709369995957496696763",
+	}
+	Pi = &Constant{
+		Name:   "pi",
+		Symbol: "π",
+		Value:  math.Pi,
+		Digits: "3.14159265358979323846264338327950288419716939937510582097494459",
+	}
+	Tau = &Constant{
+		Name:   "tau",
+		Symbol: "τ",
+		Value:  2 * math.Pi,
+		Digits: "6.28318530717958647692528676655900576839433879875021164194988918",
+	}
+	Phi = &Constant{
+		Name:   "phi",
+		Symbol: "φ",
+		Value:  math.Phi,
+		Digits: "1.61803398874989484820458683436563811772030917980576286213544862",
+	}
+	Gamma = &Constant{
+		Name:   "gamma",
+		Symbol: "γ",
+		Value:  0.57721566490153286060651209008240243104215933593992,
+		Digits: "0.57721566490153286060651209008240243104215933593992359880576723",
+	}
+	Sqrt2 = &Constant{
+		Name:   "sqrt2",
+		Value:  math.Sqrt2,
+		Digits: "1.41421356237309504880168872420969807856967187537694807317667974",
+	}
+	Inf = &Constant{
+		Name:   "inf",
+		Symbol: "∞",
+		Value:  math.Inf(1),
+		Digits: "+Inf",
+	}
+	NaN = &Constant{
+		Name:   "nan",
+		Symbol: "NaN",
+		Value:  math.NaN(),
+		Digits: "NaN",
+	}
+)
+
+var table = map[string]*Constant{}
+
+func init() {
+	for _, c := range []*Constant{E, Pi, Tau, Phi, Gamma, Sqrt2, Inf, NaN} {
+		register(c)
+	}
+	var err error
+	physical, err = parseCodata(codata)
+	if err != nil {
+		panic(err)
+	}
+	for _, c := range physical {
+		register(c)
+	}
+}
+
+func register(c *Constant) {
+	table[c.Name] = c
+	if c.Symbol != "" {
+		table[c.Symbol] = c
+	}
+}
+
+// Lookup finds a constant either by its name or by its symbol.
+func Lookup(name string) (*Constant, bool) {
+	c, ok := table[name]
+	return c, ok
+}
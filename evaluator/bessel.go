@@ -0,0 +1,468 @@
+package evaluator
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/hellracer2007/webCalc/calculator/object"
+)
+
+const (
+	// maxBesselOrder bounds the order n of the Bessel functions.
+	maxBesselOrder = 1000
+	// maxPreciseBessel bounds |x| of J, Y and I in high precision. Their
+	// series lose about |x|/ln 2 bits to cancellation, which are made up
+	// with guard bits.
+	maxPreciseBessel = 1000
+	// maxPreciseAiry does the same for the Airy functions, which lose
+	// about 4/3 |x|^(3/2) / ln 2 bits.
+	maxPreciseAiry = 100
+	// airyAsymptoticZeta is the ζ = 2/3 |x|^(3/2) from which Ai and Bi of
+	// negative x are found by their asymptotic expansion, whose terms
+	// then fall below the rounding error, rather than by their series.
+	airyAsymptoticZeta = 40
+
+	airyAi0      = 0.355028053887817239260 // Ai(0)
+	airyAiPrime0 = 0.258819403792806798405 // -Ai'(0)
+)
+
+func init() {
+	builtins["besselj"] = numericBuiltin("besselj", 2, func(xs []float64) object.Object {
+		n, err := besselOrder("besselj", xs[0])
+		if err != nil {
+			return err
+		}
+		return finite("besselj", math.Jn(n, xs[1]))
+	})
+	builtins["bessely"] = numericBuiltin("bessely", 2, func(xs []float64) object.Object {
+		n, err := besselOrder("bessely", xs[0])
+		if err != nil {
+			return err
+		}
+		if xs[1] <= 0 {
+			return newError("bessely: x must be positive, got %v", xs[1])
+		}
+		return finite("bessely", math.Yn(n, xs[1]))
+	})
+	builtins["besseli"] = numericBuiltin("besseli", 2, func(xs []float64) object.Object {
+		n, err := besselOrder("besseli", xs[0])
+		if err != nil {
+			return err
+		}
+		return finite("besseli", besselI(n, xs[1]))
+	})
+	builtins["besselk"] = numericBuiltin("besselk", 2, func(xs []float64) object.Object {
+		n, err := besselOrder("besselk", xs[0])
+		if err != nil {
+			return err
+		}
+		if xs[1] <= 0 {
+			return newError("besselk: x must be positive, got %v", xs[1])
+		}
+		return finite("besselk", besselK(float64(n), xs[1]))
+	})
+	builtins["airyai"] = numericBuiltin("airyai", 1, func(xs []float64) object.Object {
+		return finite("airyai", airyAi(xs[0]))
+	})
+	builtins["airybi"] = numericBuiltin("airybi", 1, func(xs []float64) object.Object {
+		return finite("airybi", airyBi(xs[0]))
+	})
+
+	preciseBuiltins["besselj"] = preciseBuiltin("besselj", 2, func(prec uint, xs []*big.Float) (*big.Float, *object.Error) {
+		n, err := preciseBesselArgs("besselj", xs, maxPreciseBessel)
+		if err != nil {
+			return nil, err
+		}
+		j := besselSeries(abs(n), xs[1], -1, prec)
+		if n < 0 && n%2 != 0 {
+			j.Neg(j)
+		}
+		return j, nil
+	})
+	preciseBuiltins["bessely"] = preciseBuiltin("bessely", 2, func(prec uint, xs []*big.Float) (*big.Float, *object.Error) {
+		n, err := preciseBesselArgs("bessely", xs, maxPreciseBessel)
+		if err != nil {
+			return nil, err
+		}
+		if xs[1].Sign() <= 0 {
+			return nil, newError("bessely: x must be positive, got %s", xs[1].Text('g', 10))
+		}
+		return bigBesselY(n, xs[1], prec), nil
+	})
+	preciseBuiltins["besseli"] = preciseBuiltin("besseli", 2, func(prec uint, xs []*big.Float) (*big.Float, *object.Error) {
+		n, err := preciseBesselArgs("besseli", xs, maxPreciseBessel)
+		if err != nil {
+			return nil, err
+		}
+		return besselSeries(abs(n), xs[1], 1, prec), nil
+	})
+	preciseBuiltins["besselk"] = preciseBuiltin("besselk", 2, func(prec uint, xs []*big.Float) (*big.Float, *object.Error) {
+		n, err := preciseBesselArgs("besselk", xs, math.MaxFloat64)
+		if err != nil {
+			return nil, err
+		}
+		if xs[1].Sign() <= 0 {
+			return nil, newError("besselk: x must be positive, got %s", xs[1].Text('g', 10))
+		}
+		return bigBesselK(bigInt(int64(n), prec), xs[1], prec), nil
+	})
+	preciseBuiltins["airyai"] = preciseBuiltin("airyai", 1, func(prec uint, xs []*big.Float) (*big.Float, *object.Error) {
+		if err := preciseArgument("airyai", xs[0], maxPreciseAiry); err != nil {
+			return nil, err
+		}
+		ai, _ := bigAiry(xs[0], prec)
+		return ai, nil
+	})
+	preciseBuiltins["airybi"] = preciseBuiltin("airybi", 1, func(prec uint, xs []*big.Float) (*big.Float, *object.Error) {
+		if err := preciseArgument("airybi", xs[0], maxPreciseAiry); err != nil {
+			return nil, err
+		}
+		_, bi := bigAiry(xs[0], prec)
+		return bi, nil
+	})
+}
+
+// besselOrder checks the order of a Bessel function, a whole number.
+func besselOrder(name string, n float64) (int, *object.Error) {
+	if n != math.Trunc(n) || math.Abs(n) > maxBesselOrder {
+		return 0, orderError(name, maxBesselOrder, fmt.Sprint(n))
+	}
+	return int(n), nil
+}
+
+func orderError(name string, max int, n string) *object.Error {
+	return newError("%s: the order must be a whole number between -%d and %d, got %s", name, max, max, n)
+}
+
+// preciseBesselArgs checks the order and the argument of a Bessel function
+// in high precision.
+func preciseBesselArgs(name string, xs []*big.Float, maxArgument float64) (int, *object.Error) {
+	if !xs[0].IsInt() {
+		return 0, orderError(name, maxBesselOrder, xs[0].Text('g', 10))
+	}
+	n, _ := xs[0].Float64()
+	if err := preciseArgument(name, xs[1], maxArgument); err != nil {
+		return 0, err
+	}
+	return besselOrder(name, n)
+}
+
+// preciseArgument checks that |x| is at most max, beyond which the series
+// of a function take too many guard bits.
+func preciseArgument(name string, x *big.Float, max float64) *object.Error {
+	if f, _ := x.Float64(); math.Abs(f) > max {
+		return newError("%s: |x| must be at most %v in high precision", name, max)
+	}
+	return nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// besselI is I_n(x) by its power series, whose terms are all positive.
+func besselI(n int, x float64) float64 {
+	n = abs(n)
+	sign := 1.0
+	if x < 0 && n%2 != 0 {
+		sign = -1
+	}
+	x = math.Abs(x)
+	if x == 0 {
+		if n == 0 {
+			return 1
+		}
+		return 0
+	}
+	lg, _ := math.Lgamma(float64(n + 1))
+	term := math.Exp(float64(n)*math.Log(x/2) - lg)
+	sum := term
+	q := x * x / 4
+	for k := 1; term > sum*1e-17; k++ {
+		term *= q / float64(k*(k+n))
+		sum += term
+	}
+	return sign * sum
+}
+
+// besselK is K_ν(x) = ∫ exp(-x cosh t) cosh(νt) dt over t ≥ 0 by the
+// trapezoidal rule, which converges exponentially for this integrand. The
+// step shrinks like 1/√x with the width of the peak at t = 0.
+func besselK(nu, x float64) float64 {
+	nu = math.Abs(nu)
+	h := math.Min(0.05, 0.5/math.Sqrt(x))
+	sum := math.Exp(-x) / 2
+	for k := 1; ; k++ {
+		t := float64(k) * h
+		a := -x * math.Cosh(t)
+		f := (math.Exp(a+nu*t) + math.Exp(a-nu*t)) / 2
+		sum += f
+		// past the maximum of the integrand at sinh t = ν/x
+		if x*math.Sinh(t) > nu && f <= sum*1e-18 {
+			break
+		}
+	}
+	return h * sum
+}
+
+// airyAi is Ai(x), for x > 0 from Ai(x) = √(x/3) K_1/3(ζ) / π with
+// ζ = 2/3 x^(3/2).
+func airyAi(x float64) float64 {
+	switch {
+	case x > 0:
+		zeta := 2.0 / 3 * x * math.Sqrt(x)
+		return math.Sqrt(x/3) / math.Pi * besselK(1.0/3, zeta)
+	case x == 0:
+		return airyAi0
+	}
+	ai, _ := airyNegative(x)
+	return ai
+}
+
+// airyBi is Bi(x), for x ≥ 0 by its series, whose terms are all positive.
+func airyBi(x float64) float64 {
+	if x < 0 {
+		_, bi := airyNegative(x)
+		return bi
+	}
+	x3 := x * x * x
+	a, b := 1.0, x
+	f, g := a, b
+	for k := 0.0; a+b > (f+g)*1e-17; k++ {
+		a *= x3 / ((3*k + 2) * (3*k + 3))
+		b *= x3 / ((3*k + 3) * (3*k + 4))
+		f += a
+		g += b
+	}
+	return math.Sqrt(3) * (airyAi0*f + airyAiPrime0*g)
+}
+
+// airyNegative returns Ai(x) and Bi(x) for x < 0. The series cancels, so
+// it is summed in big floats with guard bits while that is cheap, and the
+// asymptotic expansion of DLMF 9.7.9 and 9.7.11 takes over beyond.
+func airyNegative(x float64) (float64, float64) {
+	t := -x
+	zeta := 2.0 / 3 * t * math.Sqrt(t)
+	if zeta <= airyAsymptoticZeta {
+		a, b := bigAiry(new(big.Float).SetFloat64(x), 53)
+		ai, _ := a.Float64()
+		bi, _ := b.Float64()
+		return ai, bi
+	}
+	// p = Σ (-1)^k u_2k ζ^-2k and q = Σ (-1)^k u_2k+1 ζ^-(2k+1)
+	var p, q float64
+	u, power := 1.0, 1.0
+	for k := 0; k < 60; k++ {
+		term := u / power
+		switch k % 4 {
+		case 0:
+			p += term
+		case 1:
+			q += term
+		case 2:
+			p -= term
+		case 3:
+			q -= term
+		}
+		if term < 1e-17 {
+			break
+		}
+		kk := float64(k + 1)
+		u *= (6*kk - 5) * (6*kk - 3) * (6*kk - 1) / ((2*kk - 1) * 216 * kk)
+		power *= zeta
+	}
+	s, c := math.Sincos(zeta - math.Pi/4)
+	scale := 1 / (math.Sqrt(math.Pi) * math.Sqrt(math.Sqrt(t)))
+	return scale * (c*p + s*q), scale * (c*q - s*p)
+}
+
+var (
+	// Ai(0) = 1 / (3^(2/3) Γ(2/3))
+	bigAiryAi0 = &bigConstant{compute: func(prec uint) *big.Float {
+		return airyConstant(2, prec)
+	}}
+	// -Ai'(0) = 1 / (3^(1/3) Γ(1/3))
+	bigAiryAiPrime0 = &bigConstant{compute: func(prec uint) *big.Float {
+		return airyConstant(1, prec)
+	}}
+)
+
+// airyConstant is 1 / (3^(k/3) Γ(k/3)).
+func airyConstant(k int64, prec uint) *big.Float {
+	third := newBig(prec).Quo(bigInt(k, prec), bigInt(3, prec))
+	d := bigPow(bigInt(3, prec), third, prec)
+	d.Mul(d, bigExp(bigLnGamma(third, prec), prec))
+	return d.Quo(bigInt(1, prec), d)
+}
+
+// bigAiry returns Ai(x) and Bi(x) from the Maclaurin series
+// Ai = c1 f - c2 g and Bi = √3 (c1 f + c2 g) with c1 = Ai(0), c2 = -Ai'(0),
+// f = Σ 3^k (1/3)_k x^3k / (3k)! and g = Σ 3^k (2/3)_k x^(3k+1) / (3k+1)!.
+// The terms grow like e^ζ while Ai falls like e^-ζ, so the sums carry
+// 2ζ/ln 2 guard bits.
+func bigAiry(x *big.Float, prec uint) (*big.Float, *big.Float) {
+	xf, _ := x.Float64()
+	zeta := 2.0 / 3 * math.Pow(math.Abs(xf), 1.5)
+	work := prec + guardBits + uint(2*zeta/math.Ln2)
+	x3 := newBig(work).Mul(x, x)
+	x3.Mul(x3, x)
+	a, b := bigInt(1, work), newBig(work).Set(x)
+	f, g := newBig(work).Set(a), newBig(work).Set(b)
+	peak := max(magnitude(a), magnitude(b))
+	for k := int64(0); ; k++ {
+		a.Mul(a, x3)
+		a.Quo(a, bigInt((3*k+2)*(3*k+3), work))
+		b.Mul(b, x3)
+		b.Quo(b, bigInt((3*k+3)*(3*k+4), work))
+		f.Add(f, a)
+		g.Add(g, b)
+		peak = max(peak, magnitude(a), magnitude(b))
+		small := func(term *big.Float) bool { return term.Sign() == 0 || magnitude(term) < peak-int(work) }
+		if float64(3*k+2)*float64(3*k+3) > math.Abs(xf*xf*xf) && small(a) && small(b) {
+			break
+		}
+	}
+	f.Mul(f, bigAiryAi0.get(work))
+	g.Mul(g, bigAiryAiPrime0.get(work))
+	ai := newBig(prec).Sub(f, g)
+	bi := newBig(work).Add(f, g)
+	bi.Mul(bi, newBig(work).Sqrt(bigInt(3, work)))
+	return ai, newBig(prec).Set(bi)
+}
+
+// besselSeries is Σ (s x²/4)^k (x/2)^n / (k! (n+k)!) for n ≥ 0, J_n(x) for
+// s = -1 and I_n(x) for s = 1. The alternating series of J carries |x|/ln 2
+// guard bits for its cancellation.
+func besselSeries(n int, x *big.Float, s int, prec uint) *big.Float {
+	xf, _ := x.Float64()
+	work := prec + guardBits + uint(bitLength(int64(n)))
+	if s < 0 {
+		work += uint(math.Abs(xf) / math.Ln2)
+	}
+	half := newBig(work).Quo(x, bigInt(2, work))
+	q := newBig(work).Mul(half, half)
+	if s < 0 {
+		q.Neg(q)
+	}
+	term := bigPowInt(half, int64(n), work)
+	term.Quo(term, newBig(work).SetInt(new(big.Int).MulRange(1, int64(n))))
+	sum := newBig(work).Set(term)
+	peak := magnitude(term)
+	for k := int64(1); term.Sign() != 0; k++ {
+		term.Mul(term, q)
+		term.Quo(term, bigInt(k*(k+int64(n)), work))
+		sum.Add(sum, term)
+		peak = max(peak, magnitude(term))
+		if float64(k*(k+int64(n))) > xf*xf/4 && magnitude(term) < peak-int(work) {
+			break
+		}
+	}
+	return newBig(prec).Set(sum)
+}
+
+// bigBesselY is Y_n(x) for x > 0 by DLMF 10.8.1, with ψ(k+1) = H_k - γ:
+// π Y_n = 2 (ln(x/2) + γ) J_n - Σ_{k<n} (n-k-1)!/k! (x/2)^(2k-n)
+// - Σ (H_k + H_(n+k)) (-x²/4)^k (x/2)^n / (k! (n+k)!).
+func bigBesselY(n int, x *big.Float, prec uint) *big.Float {
+	negate := n < 0 && n%2 != 0
+	n = abs(n)
+	xf, _ := x.Float64()
+	work := prec + guardBits + uint(bitLength(int64(n))) + uint(xf/math.Ln2)
+	half := newBig(work).Quo(x, bigInt(2, work))
+	q := newBig(work).Mul(half, half)
+
+	// the finite sum
+	finite := newBig(work)
+	if n > 0 {
+		term := bigPowInt(half, -int64(n), work)
+		term.Mul(term, newBig(work).SetInt(new(big.Int).MulRange(1, int64(n-1))))
+		for k := int64(0); k < int64(n); k++ {
+			finite.Add(finite, term)
+			if k+1 < int64(n) {
+				term.Mul(term, q)
+				term.Quo(term, bigInt((k+1)*(int64(n)-k-1), work))
+			}
+		}
+	}
+
+	// J_n and the sum with the harmonic numbers
+	q.Neg(q)
+	term := bigPowInt(half, int64(n), work)
+	term.Quo(term, newBig(work).SetInt(new(big.Int).MulRange(1, int64(n))))
+	hk := newBig(work)
+	hnk := newBig(work)
+	for i := int64(1); i <= int64(n); i++ {
+		hnk.Add(hnk, newBig(work).Quo(bigInt(1, work), bigInt(i, work)))
+	}
+	j := newBig(work).Set(term)
+	harmonic := newBig(work).Mul(term, hnk)
+	peak := magnitude(harmonic)
+	for k := int64(1); ; k++ {
+		term.Mul(term, q)
+		term.Quo(term, bigInt(k*(k+int64(n)), work))
+		hk.Add(hk, newBig(work).Quo(bigInt(1, work), bigInt(k, work)))
+		hnk.Add(hnk, newBig(work).Quo(bigInt(1, work), bigInt(k+int64(n), work)))
+		j.Add(j, term)
+		t := newBig(work).Add(hk, hnk)
+		t.Mul(t, term)
+		harmonic.Add(harmonic, t)
+		peak = max(peak, magnitude(t))
+		if float64(k*(k+int64(n))) > xf*xf/4 && magnitude(term) < peak-int(work) && magnitude(t) < peak-int(work) {
+			break
+		}
+	}
+
+	y := bigLog(half, work)
+	y.Add(y, bigEuler(work))
+	y.Mul(y, j)
+	y.SetMantExp(y, 1)
+	y.Sub(y, finite)
+	y.Sub(y, harmonic)
+	y.Quo(y, bigPi(work))
+	if negate {
+		y.Neg(y)
+	}
+	return newBig(prec).Set(y)
+}
+
+// bigBesselK is K_ν(x) by the trapezoidal rule like besselK, with the step
+// chosen for the precision.
+func bigBesselK(nu, x *big.Float, prec uint) *big.Float {
+	work := prec + guardBits
+	nu = newBig(work).Abs(nu)
+	xf, _ := x.Float64()
+	nuf, _ := nu.Float64()
+	// the integrand is analytic in a strip of half width d, where it grows
+	// by at most e^penalty; the error of the rule is about
+	// e^(penalty - 2πd/h)
+	d := math.Min(1.2, 4/math.Sqrt(xf))
+	penalty := xf * (1 - math.Cos(d))
+	hf := 2 * math.Pi * d / (float64(work)*math.Ln2 + penalty + 5)
+	h := newBig(work).SetFloat64(hf)
+	eh := bigExp(h, work)
+	enh := bigExp(newBig(work).Mul(nu, h), work)
+	et, ent := bigInt(1, work), bigInt(1, work)
+	one := bigInt(1, work)
+	sum := bigExp(newBig(work).Neg(x), work)
+	sum.SetMantExp(sum, -1)
+	for k := 1; ; k++ {
+		et.Mul(et, eh)
+		ent.Mul(ent, enh)
+		cosh := newBig(work).Quo(one, et)
+		cosh.Add(cosh, et)
+		cosh.SetMantExp(cosh, -1)
+		f := newBig(work).Quo(one, ent)
+		f.Add(f, ent)
+		f.SetMantExp(f, -1)
+		f.Mul(f, bigExp(cosh.Mul(cosh, x).Neg(cosh), work))
+		sum.Add(sum, f)
+		if xf*math.Sinh(float64(k)*hf) > nuf && negligible(f, sum, work) {
+			break
+		}
+	}
+	return newBig(prec).Mul(sum, h)
+}
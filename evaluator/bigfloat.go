@@ -0,0 +1,354 @@
+package evaluator
+
+import (
+	"math"
+	"math/big"
+	"sync"
+)
+
+// The functions of this file compute to prec bits with guard bits of their
+// own and round the result to prec bits. Their arguments are finite, and
+// positive where a logarithm is taken.
+
+// guardBits are the bits carried beyond the requested precision to absorb
+// rounding errors.
+const guardBits = 32
+
+func newBig(prec uint) *big.Float { return new(big.Float).SetPrec(prec) }
+
+func bigInt(n int64, prec uint) *big.Float { return newBig(prec).SetInt64(n) }
+
+// negligible reports whether adding term to sum changes it by less than
+// the last of prec bits.
+func negligible(term, sum *big.Float, prec uint) bool {
+	if term.Sign() == 0 {
+		return true
+	}
+	if sum.Sign() == 0 {
+		return false
+	}
+	return term.MantExp(nil) < sum.MantExp(nil)-int(prec)-1
+}
+
+// magnitude is the binary exponent of x, 0 for 0.
+func magnitude(x *big.Float) int {
+	if x.Sign() == 0 {
+		return 0
+	}
+	return x.MantExp(nil)
+}
+
+// bigConstant caches a constant to the largest precision asked for.
+type bigConstant struct {
+	mu      sync.Mutex
+	value   *big.Float
+	compute func(prec uint) *big.Float
+}
+
+func (c *bigConstant) get(prec uint) *big.Float {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.value == nil || c.value.Prec() < prec {
+		c.value = newBig(prec).Set(c.compute(prec + guardBits))
+	}
+	return newBig(prec).Set(c.value)
+}
+
+var (
+	bigPiConstant = &bigConstant{compute: func(prec uint) *big.Float {
+		// Machin's formula π = 16 arctan(1/5) - 4 arctan(1/239)
+		a := atanInverse(5, prec)
+		b := atanInverse(239, prec)
+		a.Mul(a, bigInt(16, prec))
+		b.Mul(b, bigInt(4, prec))
+		return a.Sub(a, b)
+	}}
+	bigLn2Constant = &bigConstant{compute: func(prec uint) *big.Float {
+		// ln 2 = 2 artanh(1/3)
+		x := newBig(prec).Quo(bigInt(1, prec), bigInt(3, prec))
+		return atanhSeries(x, prec).Mul(atanhSeries(x, prec), bigInt(2, prec))
+	}}
+	bigEulerConstant = &bigConstant{compute: eulerGamma}
+)
+
+func bigPi(prec uint) *big.Float  { return bigPiConstant.get(prec) }
+func bigLn2(prec uint) *big.Float { return bigLn2Constant.get(prec) }
+
+// bigEuler is the Euler-Mascheroni constant γ.
+func bigEuler(prec uint) *big.Float { return bigEulerConstant.get(prec) }
+
+// atanInverse is arctan(1/n) by its Taylor series.
+func atanInverse(n int64, prec uint) *big.Float {
+	power := newBig(prec).Quo(bigInt(1, prec), bigInt(n, prec))
+	n2 := bigInt(n*n, prec)
+	sum := newBig(prec).Set(power)
+	term := newBig(prec)
+	for k := int64(1); ; k++ {
+		power.Quo(power, n2)
+		term.Quo(power, bigInt(2*k+1, prec))
+		if negligible(term, sum, prec) {
+			return sum
+		}
+		if k%2 == 1 {
+			sum.Sub(sum, term)
+		} else {
+			sum.Add(sum, term)
+		}
+	}
+}
+
+// atanhSeries is artanh x = x + x³/3 + x⁵/5 + ... for small |x|.
+func atanhSeries(x *big.Float, prec uint) *big.Float {
+	power := newBig(prec).Set(x)
+	x2 := newBig(prec).Mul(x, x)
+	sum := newBig(prec).Set(x)
+	term := newBig(prec)
+	for k := int64(1); ; k++ {
+		power.Mul(power, x2)
+		term.Quo(power, bigInt(2*k+1, prec))
+		if negligible(term, sum, prec) {
+			return sum
+		}
+		sum.Add(sum, term)
+	}
+}
+
+// bigExp is e^x. It reduces x to r = x - k ln 2, halves r a few times so
+// that the Taylor series converges fast and squares the sum back.
+func bigExp(x *big.Float, prec uint) *big.Float {
+	if x.Sign() == 0 {
+		return bigInt(1, prec)
+	}
+	xf, _ := x.Float64()
+	k := math.Round(xf / math.Ln2)
+	switch {
+	case k > 1<<30:
+		return newBig(prec).SetInf(false)
+	case k < -(1 << 30):
+		return newBig(prec)
+	}
+	halvings := int(math.Sqrt(float64(prec)) / 2)
+	work := prec + guardBits + uint(halvings) + uint(bitLength(int64(k)))
+	r := newBig(work).Mul(bigLn2(work), bigInt(int64(k), work))
+	r.Sub(newBig(work).Set(x), r)
+	r.SetMantExp(r, -halvings)
+	sum := bigInt(1, work)
+	term := bigInt(1, work)
+	for i := int64(1); ; i++ {
+		term.Mul(term, r)
+		term.Quo(term, bigInt(i, work))
+		if negligible(term, sum, work) {
+			break
+		}
+		sum.Add(sum, term)
+	}
+	for i := 0; i < halvings; i++ {
+		sum.Mul(sum, sum)
+	}
+	return newBig(prec).SetMantExp(sum, int(k))
+}
+
+// bigLog is ln x for x > 0. With x = m 2^e and 1/√2 ≤ m < √2 it is
+// e ln 2 + 2 artanh((m - 1)/(m + 1)), free of cancellation near 1.
+func bigLog(x *big.Float, prec uint) *big.Float {
+	work := prec + guardBits
+	m := newBig(work)
+	e := x.MantExp(m)
+	if m.Cmp(big.NewFloat(math.Sqrt2/2)) < 0 {
+		m.SetMantExp(m, 1)
+		e--
+	}
+	one := bigInt(1, work)
+	t := newBig(work).Quo(newBig(work).Sub(m, one), newBig(work).Add(m, one))
+	sum := atanhSeries(t, work)
+	sum.SetMantExp(sum, 1)
+	if e != 0 {
+		sum.Add(sum, newBig(work).Mul(bigLn2(work), bigInt(int64(e), work)))
+	}
+	return newBig(prec).Set(sum)
+}
+
+// bigPowInt is x^n by repeated squaring.
+func bigPowInt(x *big.Float, n int64, prec uint) *big.Float {
+	work := prec + guardBits + uint(bitLength(n))
+	result := bigInt(1, work)
+	base := newBig(work).Set(x)
+	for m := n; m != 0; m /= 2 {
+		if m%2 != 0 {
+			result.Mul(result, base)
+		}
+		base.Mul(base, base)
+	}
+	if n < 0 {
+		result.Quo(bigInt(1, work), result)
+	}
+	return newBig(prec).Set(result)
+}
+
+// bigPow is x^y for x > 0.
+func bigPow(x, y *big.Float, prec uint) *big.Float {
+	if y.IsInt() && y.MantExp(nil) < 31 {
+		n, _ := y.Int64()
+		return bigPowInt(x, n, prec)
+	}
+	// the error of the logarithm grows with the size of the exponent
+	work := prec + guardBits + uint(max(magnitude(y)+bitLength(int64(magnitude(x))), 0))
+	l := bigLog(x, work)
+	return bigExp(l.Mul(l, y), prec)
+}
+
+// bigSinPi is sin(πt). The argument is reduced exactly to |t| ≤ 1, so that
+// the zeros at the integers come out exact.
+func bigSinPi(t *big.Float, prec uint) *big.Float {
+	work := prec + guardBits
+	r := newBig(t.MinPrec() + 2).Set(t)
+	// sin(π(t - 2k)) = sin(πt)
+	half := newBig(r.Prec()).Quo(r, bigInt(2, r.Prec()))
+	k, _ := half.Int(nil)
+	r.Sub(r, newBig(r.Prec()+uint(k.BitLen())+1).SetInt(k.Lsh(k, 1)))
+	sign := 1
+	if r.Sign() < 0 {
+		r.Neg(r)
+		sign = -1
+	}
+	// sin(πt) = sin(π(1 - t))
+	if r.Cmp(big.NewFloat(0.5)) > 0 {
+		r.Sub(bigInt(1, r.Prec()), r)
+	}
+	x := newBig(work).Mul(bigPi(work), r)
+	x2 := newBig(work).Mul(x, x)
+	sum := newBig(work).Set(x)
+	term := newBig(work).Set(x)
+	for k := int64(1); ; k++ {
+		term.Mul(term, x2)
+		term.Quo(term, bigInt((2*k)*(2*k+1), work))
+		term.Neg(term)
+		if negligible(term, sum, work) {
+			break
+		}
+		sum.Add(sum, term)
+	}
+	if sign < 0 {
+		sum.Neg(sum)
+	}
+	return newBig(prec).Set(sum)
+}
+
+// bigLnGamma is ln Γ(z) for z > 0 by the Stirling series, after shifting
+// z up to where the series reaches the precision:
+// ln Γ(z) = ln Γ(z + n) - ln(z (z + 1) ... (z + n - 1)).
+func bigLnGamma(z *big.Float, prec uint) *big.Float {
+	zf, _ := z.Float64()
+	least := 1.5 * float64(prec+guardBits) * math.Ln2 / (2 * math.Pi)
+	// the terms of the series are as large as w ln w
+	work := prec + guardBits + 2*uint(bitLength(int64(math.Min(zf+least, 1<<50))))
+	w := newBig(work).Set(z)
+	product := bigInt(1, work)
+	for shift := 0; zf+float64(shift) < least; shift++ {
+		product.Mul(product, w)
+		w.Add(w, bigInt(1, work))
+	}
+	// (w - 1/2) ln w - w + ln(2π)/2
+	half := big.NewFloat(0.5)
+	sum := newBig(work).Sub(w, half)
+	sum.Mul(sum, bigLog(w, work))
+	sum.Sub(sum, w)
+	twoPi := bigPi(work)
+	twoPi.SetMantExp(twoPi, 1)
+	sum.Add(sum, newBig(work).Mul(half, bigLog(twoPi, work)))
+	// + Σ B_2k / (2k (2k - 1) w^(2k-1))
+	w2 := newBig(work).Mul(w, w)
+	power := newBig(work).Set(w)
+	term := newBig(work)
+	for k := 1; ; k++ {
+		b := newBig(work).SetRat(bernoulli(2 * k))
+		term.Quo(b, bigInt(int64(2*k*(2*k-1)), work))
+		term.Quo(term, power)
+		if negligible(term, sum, work) {
+			break
+		}
+		sum.Add(sum, term)
+		power.Mul(power, w2)
+	}
+	if product.Cmp(bigInt(1, work)) != 0 {
+		sum.Sub(sum, bigLog(product, work))
+	}
+	return newBig(prec).Set(sum)
+}
+
+// eulerGamma computes γ by the algorithm of Brent and McMillan: with
+// B = Σ (n^k/k!)² and A = Σ (n^k/k!)² (H_k - ln n), γ = A/B with an
+// error below e^(-4n).
+func eulerGamma(prec uint) *big.Float {
+	n := int64(float64(prec)*math.Ln2/4) + 2
+	work := prec + guardBits
+	n2 := bigInt(n*n, work)
+	a := bigLog(bigInt(n, work), work)
+	a.Neg(a)
+	b := bigInt(1, work)
+	u := newBig(work).Set(a)
+	v := newBig(work).Set(b)
+	for k := int64(1); ; k++ {
+		kk := bigInt(k, work)
+		b.Mul(b, n2)
+		b.Quo(b, kk)
+		b.Quo(b, kk)
+		a.Mul(a, n2)
+		a.Quo(a, kk)
+		a.Add(a, b)
+		a.Quo(a, kk)
+		if negligible(a, u, work) && negligible(b, v, work) {
+			break
+		}
+		u.Add(u, a)
+		v.Add(v, b)
+	}
+	return u.Quo(u, v)
+}
+
+// bernoulliNumbers caches the Bernoulli numbers B_0, B_1 = -1/2, B_2, ...
+var bernoulliNumbers struct {
+	sync.Mutex
+	b []*big.Rat
+}
+
+// bernoulli is the Bernoulli number B_n with B_1 = -1/2, found by the
+// recurrence Σ_{k<=n} C(n+1, k) B_k = 0.
+func bernoulli(n int) *big.Rat {
+	bernoulliNumbers.Lock()
+	defer bernoulliNumbers.Unlock()
+	for m := len(bernoulliNumbers.b); m <= n; m++ {
+		if m == 0 {
+			bernoulliNumbers.b = append(bernoulliNumbers.b, big.NewRat(1, 1))
+			continue
+		}
+		sum := new(big.Rat)
+		binomial := big.NewInt(1)
+		for k := 0; k < m; k++ {
+			// C(m+1, k) from C(m+1, k-1)
+			if k > 0 {
+				binomial.Mul(binomial, big.NewInt(int64(m+2-k)))
+				binomial.Quo(binomial, big.NewInt(int64(k)))
+			}
+			if k > 1 && k%2 == 1 {
+				continue
+			}
+			sum.Add(sum, new(big.Rat).Mul(new(big.Rat).SetInt(binomial), bernoulliNumbers.b[k]))
+		}
+		sum.Quo(sum, big.NewRat(int64(-(m+1)), 1))
+		bernoulliNumbers.b = append(bernoulliNumbers.b, sum)
+	}
+	return new(big.Rat).Set(bernoulliNumbers.b[n])
+}
+
+// bitLength is the number of bits of |n|.
+func bitLength(n int64) int {
+	if n < 0 {
+		n = -n
+	}
+	bits := 0
+	for ; n > 0; n >>= 1 {
+		bits++
+	}
+	return bits
+}
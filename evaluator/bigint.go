@@ -0,0 +1,93 @@
+package evaluator
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/hellracer2007/webCalc/calculator/object"
+)
+
+// maxPowerBits bounds the size of exact integer powers, larger ones are
+// computed in floating point.
+const maxPowerBits = 1 << 20
+
+// newInteger returns n as an Integer when it fits, as a BigInt otherwise.
+func newInteger(n *big.Int) object.Object {
+	if n.IsInt64() {
+		return &object.Integer{Value: n.Int64()}
+	}
+	return &object.BigInt{Value: n}
+}
+
+func toBigInt(obj object.Object) (*big.Int, bool) {
+	switch v := obj.(type) {
+	case *object.Integer:
+		return big.NewInt(v.Value), true
+	case *object.BigInt:
+		return v.Value, true
+	}
+	return nil, false
+}
+
+func isInteger(obj object.Object) bool {
+	_, ok := toBigInt(obj)
+	return ok
+}
+
+func bigToFloat(n *big.Int) float64 {
+	f, _ := new(big.Float).SetInt(n).Float64()
+	return f
+}
+
+func evalBigIntInfixExpression(operator string, left, right object.Object) object.Object {
+	l, _ := toBigInt(left)
+	r, _ := toBigInt(right)
+	switch operator {
+	case "+":
+		return newInteger(new(big.Int).Add(l, r))
+	case "-":
+		return newInteger(new(big.Int).Sub(l, r))
+	case "*":
+		return newInteger(new(big.Int).Mul(l, r))
+	case "/":
+		if r.Sign() == 0 {
+			return newError("division by zero")
+		}
+		q, m := new(big.Int).QuoRem(l, r, new(big.Int))
+		if m.Sign() == 0 {
+			return newInteger(q)
+		}
+		f, _ := new(big.Rat).SetFrac(l, r).Float64()
+		return &object.Float{Value: f}
+	case "^":
+		return integerPower(l, r)
+	case "E":
+		scale := integerPower(big.NewInt(10), r)
+		if s, ok := toBigInt(scale); ok {
+			return newInteger(new(big.Int).Mul(l, s))
+		}
+		return &object.Float{Value: bigToFloat(l) * math.Pow(10, bigToFloat(r))}
+	}
+	return newError("unsupported operands for %s: %s and %s", operator, left.Type(), right.Type())
+}
+
+// integerPower computes l^r, exactly for a non-negative exponent unless
+// the result would be enormous.
+func integerPower(l, r *big.Int) object.Object {
+	if r.Sign() >= 0 && l.CmpAbs(big.NewInt(1)) <= 0 {
+		// 0, 1 and -1 stay small whatever the exponent
+		switch {
+		case r.Sign() == 0:
+			return &object.Integer{Value: 1}
+		case l.Sign() >= 0:
+			return newInteger(new(big.Int).Set(l))
+		case r.Bit(0) == 0:
+			return &object.Integer{Value: 1}
+		}
+		return &object.Integer{Value: -1}
+	}
+	if r.Sign() >= 0 && r.IsInt64() && r.Int64() <= maxPowerBits/int64(l.BitLen()) {
+		return newInteger(new(big.Int).Exp(l, r, nil))
+	}
+	return normalizeNumber(&object.Float{Value: math.Pow(bigToFloat(l), bigToFloat(r))})
+}
@@ -0,0 +1,159 @@
+package evaluator
+
+import (
+	"math"
+
+	"github.com/hellracer2007/webCalc/calculator/object"
+)
+
+var builtins = map[string]*object.Builtin{
+	"transpose": matrixBuiltin("transpose", func(m *object.Matrix) object.Object { return transpose(m) }),
+	"det":       matrixBuiltin("det", determinant),
+	"inv":       matrixBuiltin("inv", inverse),
+	"rank":      matrixBuiltin("rank", rank),
+	"lu":        matrixBuiltin("lu", lu),
+	"qr":        matrixBuiltin("qr", qr),
+	"chol":      matrixBuiltin("chol", cholesky),
+	"svd":       matrixBuiltin("svd", svd),
+	"eig":       matrixBuiltin("eig", eig),
+	"eigvals":   matrixBuiltin("eigvals", eigvals),
+	"linsolve": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("linsolve: wrong number of arguments. got=%d, want=2", len(args))
+			}
+			a, err := toMatrix("linsolve", args[0])
+			if err != nil {
+				return err
+			}
+			b, ok := args[1].(*object.Vector)
+			if !ok {
+				return newError("linsolve: right hand side must be a vector, got %s", args[1].Type())
+			}
+			return linsolve(a, b)
+		},
+	},
+	"dot": {
+		Fn: func(args ...object.Object) object.Object {
+			a, b, err := twoVectors("dot", args)
+			if err != nil {
+				return err
+			}
+			return dot(a, b)
+		},
+	},
+	"cross": {
+		Fn: func(args ...object.Object) object.Object {
+			a, b, err := twoVectors("cross", args)
+			if err != nil {
+				return err
+			}
+			return cross(a, b)
+		},
+	},
+	"norm": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("norm: wrong number of arguments. got=%d, want=1 or 2", len(args))
+			}
+			v, ok := args[0].(*object.Vector)
+			if !ok {
+				return newError("norm: argument must be a vector, got %s", args[0].Type())
+			}
+			p := 2.0
+			if len(args) == 2 {
+				f, ok := toFloat(args[1])
+				if !ok || f < 1 {
+					return newError("norm: order must be a number >= 1")
+				}
+				p = f
+			}
+			xs, err := floats("norm", v)
+			if err != nil {
+				return err
+			}
+			return normalizeNumber(&object.Float{Value: pNorm(xs, p)})
+		},
+	},
+}
+
+func matrixBuiltin(name string, fn func(*object.Matrix) object.Object) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("%s: wrong number of arguments. got=%d, want=1", name, len(args))
+			}
+			m, err := toMatrix(name, args[0])
+			if err != nil {
+				return err
+			}
+			return fn(m)
+		},
+	}
+}
+
+func twoVectors(name string, args []object.Object) (*object.Vector, *object.Vector, *object.Error) {
+	if len(args) != 2 {
+		return nil, nil, newError("%s: wrong number of arguments. got=%d, want=2", name, len(args))
+	}
+	a, ok := args[0].(*object.Vector)
+	if !ok {
+		return nil, nil, newError("%s: arguments must be vectors, got %s", name, args[0].Type())
+	}
+	b, ok := args[1].(*object.Vector)
+	if !ok {
+		return nil, nil, newError("%s: arguments must be vectors, got %s", name, args[1].Type())
+	}
+	return a, b, nil
+}
+
+func toFloat(obj object.Object) (float64, bool) {
+	switch v := obj.(type) {
+	case *object.Integer:
+		return float64(v.Value), true
+	case *object.Float:
+		return v.Value, true
+	case *object.BigInt:
+		return bigToFloat(v.Value), true
+	case *object.Decimal:
+		return decimalToFloat(v), true
+	case *object.BigFloat:
+		return bigFloatToFloat(v), true
+	}
+	return 0, false
+}
+
+// floats returns the elements of a vector of plain numbers.
+func floats(name string, v *object.Vector) ([]float64, *object.Error) {
+	xs := make([]float64, len(v.Elements))
+	for i, el := range v.Elements {
+		f, ok := toFloat(el)
+		if !ok {
+			return nil, newError("%s: expected numbers, got %s", name, el.Inspect())
+		}
+		xs[i] = f
+	}
+	return xs, nil
+}
+
+func pNorm(xs []float64, p float64) float64 {
+	if math.IsInf(p, 1) {
+		max := 0.0
+		for _, x := range xs {
+			max = math.Max(max, math.Abs(x))
+		}
+		return max
+	}
+	if p == 2 {
+		sum := 0.0
+		for _, x := range xs {
+			sum = math.Hypot(sum, x)
+		}
+		return sum
+	}
+	sum := 0.0
+	for _, x := range xs {
+		sum += math.Pow(math.Abs(x), p)
+	}
+	return math.Pow(sum, 1/p)
+}
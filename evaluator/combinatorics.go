@@ -0,0 +1,254 @@
+package evaluator
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/hellracer2007/webCalc/calculator/object"
+)
+
+const (
+	// maxFactorial bounds n! and the other products of up to n factors,
+	// 100000! has nearly half a million digits.
+	maxFactorial = 100000
+	// maxStirling bounds n for the Stirling numbers, which fill an n by k
+	// table of big integers.
+	maxStirling = 1000
+)
+
+func init() {
+	builtins["nCr"] = bigIntBuiltin("nCr", func(xs []*big.Int) object.Object {
+		if len(xs) != 2 {
+			return newError("nCr: wrong number of arguments. got=%d, want=2", len(xs))
+		}
+		return binomial(xs[0], xs[1])
+	})
+	builtins["binomial"] = builtins["nCr"]
+	builtins["nPr"] = bigIntBuiltin("nPr", func(xs []*big.Int) object.Object {
+		if len(xs) != 2 {
+			return newError("nPr: wrong number of arguments. got=%d, want=2", len(xs))
+		}
+		n, k := xs[0], xs[1]
+		switch {
+		case n.Sign() < 0:
+			return newError("nPr: n must not be negative")
+		case k.Sign() < 0 || k.Cmp(n) > 0:
+			return &object.Integer{Value: 0}
+		case k.Cmp(big.NewInt(maxFactorial)) > 0:
+			return newError("nPr: %s factors are too many", k)
+		}
+		if n.IsInt64() {
+			return newInteger(new(big.Int).MulRange(n.Int64()-k.Int64()+1, n.Int64()))
+		}
+		p := big.NewInt(1)
+		for f := new(big.Int).Sub(n, k); f.Cmp(n) < 0; {
+			f.Add(f, big.NewInt(1))
+			p.Mul(p, f)
+		}
+		return newInteger(p)
+	})
+	builtins["multinomial"] = &object.Builtin{Fn: multinomial}
+	builtins["stirling1"] = integerBuiltin("stirling1", 2, func(xs []int64) object.Object { return stirling("stirling1", xs[0], xs[1], true) })
+	builtins["stirling2"] = integerBuiltin("stirling2", 2, func(xs []int64) object.Object { return stirling("stirling2", xs[0], xs[1], false) })
+	builtins["catalan"] = integerBuiltin("catalan", 1, func(xs []int64) object.Object {
+		n := xs[0]
+		if n < 0 || n > maxFactorial {
+			return newError("catalan: n must be between 0 and %d", maxFactorial)
+		}
+		c := new(big.Int).Binomial(2*n, n)
+		return newInteger(c.Quo(c, big.NewInt(n+1)))
+	})
+}
+
+// integerBuiltin wraps a function of n machine-sized integers.
+func integerBuiltin(name string, n int, fn func([]int64) object.Object) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != n {
+				return newError("%s: wrong number of arguments. got=%d, want=%d", name, len(args), n)
+			}
+			xs, err := integers(name, args)
+			if err != nil {
+				return err
+			}
+			return fn(xs)
+		},
+	}
+}
+
+// integers reads args as integers, accepting floats without a fraction.
+func integers(name string, args []object.Object) ([]int64, *object.Error) {
+	xs := make([]int64, len(args))
+	for i, arg := range args {
+		if v, ok := arg.(*object.Integer); ok {
+			xs[i] = v.Value
+			continue
+		}
+		if arg.Type() == object.BIGINT_OBJ {
+			return nil, newError("%s: %s is too large", name, arg.Inspect())
+		}
+		f, ok := arg.(*object.Float)
+		if !ok || f.Value != math.Trunc(f.Value) || math.Abs(f.Value) > 1<<53 {
+			return nil, newError("%s: expected integers, got %s", name, arg.Inspect())
+		}
+		xs[i] = int64(f.Value)
+	}
+	return xs, nil
+}
+
+// evalFactorial computes n! exactly for integers and Γ(x + 1) for other
+// numbers.
+func evalFactorial(left object.Object) object.Object {
+	switch v := left.(type) {
+	case *object.Integer:
+		return factorial(v.Value)
+	case *object.Float:
+		if v.Value == math.Trunc(v.Value) && math.Abs(v.Value) <= maxFactorial {
+			return factorial(int64(v.Value))
+		}
+		return number(math.Gamma(v.Value + 1))
+	case *object.BigInt:
+		return newError("factorial: %s is too large", v.Inspect())
+	case *object.Interval:
+		// a whole number read in interval mode
+		if v.Lo == v.Hi && v.Lo == math.Trunc(v.Lo) && math.Abs(v.Lo) <= maxFactorial {
+			n := factorial(int64(v.Lo))
+			if isError(n) {
+				return n
+			}
+			return ratInterval(toRat(n))
+		}
+	}
+	return newError("factorial: not defined for %s", left.Type())
+}
+
+func factorial(n int64) object.Object {
+	switch {
+	case n < 0:
+		return newError("factorial: not defined for negative integers, got %d", n)
+	case n > maxFactorial:
+		return newError("factorial: %d is too large", n)
+	}
+	return newInteger(new(big.Int).MulRange(1, n))
+}
+
+// binomial is n choose k, extended to negative n by
+// C(n, k) = (-1)^k C(k - n - 1, k).
+func binomial(n, k *big.Int) object.Object {
+	if k.Sign() < 0 {
+		return &object.Integer{Value: 0}
+	}
+	if n.Sign() < 0 {
+		m := new(big.Int).Sub(k, n)
+		r := binomial(m.Sub(m, big.NewInt(1)), k)
+		c, ok := toBigInt(r)
+		if !ok {
+			return r
+		}
+		if k.Bit(0) == 1 {
+			c = new(big.Int).Neg(c)
+		}
+		return newInteger(c)
+	}
+	if k.Cmp(n) > 0 {
+		return &object.Integer{Value: 0}
+	}
+	j := new(big.Int).Sub(n, k)
+	if j.Cmp(k) > 0 {
+		j.Set(k)
+	}
+	if j.Cmp(big.NewInt(maxFactorial)) > 0 {
+		return newError("binomial: %s choose %s is too large", n, k)
+	}
+	if n.IsInt64() {
+		return newInteger(new(big.Int).Binomial(n.Int64(), j.Int64()))
+	}
+	// C(n - j + i, i) for i up to j, each step stays whole
+	c := big.NewInt(1)
+	f := new(big.Int).Sub(n, j)
+	for i := int64(1); i <= j.Int64(); i++ {
+		c.Mul(c, f.Add(f, big.NewInt(1)))
+		c.Quo(c, big.NewInt(i))
+	}
+	return newInteger(c)
+}
+
+// evalChoose implements the binomial notation n choose k.
+func evalChoose(left, right object.Object) object.Object {
+	n, ok := bigInteger(left)
+	if !ok {
+		return newError("choose: expected integers, got %s", left.Inspect())
+	}
+	k, ok := bigInteger(right)
+	if !ok {
+		return newError("choose: expected integers, got %s", right.Inspect())
+	}
+	return binomial(n, k)
+}
+
+// multinomial is (k1 + k2 + ...)! / (k1! k2! ...), computed as a product
+// of binomial coefficients. The ks may also be given as a list.
+func multinomial(args ...object.Object) object.Object {
+	if len(args) == 1 {
+		switch v := args[0].(type) {
+		case *object.List:
+			args = v.Elements
+		case *object.Vector:
+			args = v.Elements
+		}
+	}
+	ks, err := integers("multinomial", args)
+	if err != nil {
+		return err
+	}
+	result := big.NewInt(1)
+	n := int64(0)
+	for _, k := range ks {
+		if k < 0 {
+			return newError("multinomial: the arguments must not be negative, got %d", k)
+		}
+		n += k
+		if n > maxFactorial {
+			return newError("multinomial: the sum of the arguments is too large")
+		}
+		result.Mul(result, new(big.Int).Binomial(n, k))
+	}
+	return newInteger(result)
+}
+
+// stirling returns the Stirling numbers of the first kind, signed, or of
+// the second kind by their recurrences
+//
+//	s(n+1, k) = s(n, k-1) - n s(n, k)
+//	S(n+1, k) = S(n, k-1) + k S(n, k)
+func stirling(name string, n, k int64, first bool) object.Object {
+	switch {
+	case n < 0 || k < 0:
+		return newError("%s: the arguments must not be negative", name)
+	case n > maxStirling:
+		return newError("%s: n must be at most %d", name, maxStirling)
+	case k > n:
+		return &object.Integer{Value: 0}
+	}
+	row := make([]*big.Int, k+1)
+	for j := range row {
+		row[j] = new(big.Int)
+	}
+	row[0].SetInt64(1)
+	for m := int64(0); m < n; m++ {
+		// update in place from the right so row[j-1] is still the old value
+		for j := min(m+1, k); j >= 0; j-- {
+			factor := big.NewInt(m)
+			if first {
+				factor.Neg(factor)
+			} else {
+				factor.SetInt64(j)
+			}
+			row[j].Mul(row[j], factor)
+			if j > 0 {
+				row[j].Add(row[j], row[j-1])
+			}
+		}
+	}
+	return newInteger(row[k])
+}
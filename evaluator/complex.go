@@ -0,0 +1,36 @@
+package evaluator
+
+import (
+	"math/cmplx"
+
+	"github.com/hellracer2007/webCalc/calculator/object"
+)
+
+// newComplex falls back to a plain number when there is no imaginary part.
+func newComplex(c complex128) object.Object {
+	if imag(c) == 0 {
+		return normalizeNumber(&object.Float{Value: real(c)})
+	}
+	return &object.Complex{Value: c}
+}
+
+func evalInfixComplexExpression(operator string, left, right object.Object) object.Object {
+	l := left.(*object.Complex).Value
+	r := right.(*object.Complex).Value
+	switch operator {
+	case "+":
+		return newComplex(l + r)
+	case "-":
+		return newComplex(l - r)
+	case "*":
+		return newComplex(l * r)
+	case "/":
+		if r == 0 {
+			return newError("division by zero")
+		}
+		return newComplex(l / r)
+	case "^":
+		return newComplex(cmplx.Pow(l, r))
+	}
+	return newError("operator %s is not supported for complex numbers", operator)
+}
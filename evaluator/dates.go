@@ -0,0 +1,232 @@
+package evaluator
+
+import (
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/hellracer2007/webCalc/calculator/object"
+	"github.com/hellracer2007/webCalc/calculator/units"
+)
+
+const secondsPerDay = 86400
+
+// calendarUnits are the units of time that count calendar months rather
+// than a fixed number of seconds when added to a date, 2026-01-31 + 1 month
+// is 2026-02-28.
+var calendarUnits = map[string]int64{
+	"month":  1,
+	"months": 1,
+	"yr":     12,
+	"year":   12,
+	"years":  12,
+}
+
+func init() {
+	builtins["days_between"] = &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("days_between: wrong number of arguments. got=%d, want=2", len(args))
+			}
+			from, ok := args[0].(*object.Date)
+			if !ok {
+				return newError("days_between: expected dates, got %s", args[0].Inspect())
+			}
+			to, ok := args[1].(*object.Date)
+			if !ok {
+				return newError("days_between: expected dates, got %s", args[1].Inspect())
+			}
+			return &object.Integer{Value: dayNumber(to.Value) - dayNumber(from.Value)}
+		},
+	}
+}
+
+// dayNumber counts the days from 1970-01-01 to the day of t.
+func dayNumber(t time.Time) int64 {
+	days := t.Unix() / secondsPerDay
+	if t.Unix()%secondsPerDay < 0 {
+		days--
+	}
+	return days
+}
+
+// isDate reports whether obj is a date or a duration.
+func isDate(obj object.Object) bool {
+	return obj.Type() == object.DATE_OBJ || obj.Type() == object.DURATION_OBJ
+}
+
+// evalDateInfixExpression implements the arithmetic of dates and
+// durations: a date plus or minus a duration is a date, the difference of
+// two dates is a number of days, and durations add and scale like
+// numbers. A quantity of time such as 90 days is read as a duration.
+func evalDateInfixExpression(operator string, left, right object.Object) object.Object {
+	if l, ok := left.(*object.Date); ok {
+		if r, ok := right.(*object.Date); ok && operator == "-" {
+			seconds := float64(l.Value.Unix()-r.Value.Unix()) + float64(l.Value.Nanosecond()-r.Value.Nanosecond())/1e9
+			day, _ := units.Parse("day")
+			return &object.Quantity{Value: seconds / secondsPerDay, Unit: day}
+		}
+		d, err := toDuration(right)
+		if err != nil {
+			return err
+		}
+		switch operator {
+		case "+":
+			return addDuration(l, d)
+		case "-":
+			return addDuration(l, negateDuration(d))
+		}
+		return newError("operator %s is not supported for dates", operator)
+	}
+	if r, ok := right.(*object.Date); ok {
+		d, err := toDuration(left)
+		if err != nil {
+			return err
+		}
+		if operator != "+" {
+			return newError("unsupported operands for %s: %s and %s", operator, left.Type(), right.Type())
+		}
+		return addDuration(r, d)
+	}
+
+	switch operator {
+	case "+", "-":
+		l, err := toDuration(left)
+		if err != nil {
+			return err
+		}
+		r, err := toDuration(right)
+		if err != nil {
+			return err
+		}
+		if operator == "-" {
+			r = negateDuration(r)
+		}
+		return newDuration(l.Months+r.Months, l.Days+r.Days, l.Seconds+r.Seconds)
+	case "*":
+		if d, ok := left.(*object.Duration); ok {
+			return scaleDuration(d, right)
+		}
+		return scaleDuration(right.(*object.Duration), left)
+	case "/":
+		l, ok := left.(*object.Duration)
+		if !ok {
+			break
+		}
+		if r, ok := right.(*object.Duration); ok {
+			if l.Months != 0 || r.Months != 0 {
+				return newError("durations in months have no fixed ratio")
+			}
+			return number(durationSeconds(l) / durationSeconds(r))
+		}
+		f, ok := toFloat(right)
+		if !ok {
+			break
+		}
+		if f == 0 {
+			return newError("division by zero")
+		}
+		return scaleDuration(l, &object.Float{Value: 1 / f})
+	}
+	return newError("unsupported operands for %s: %s and %s", operator, left.Type(), right.Type())
+}
+
+// toDuration reads a duration or a quantity of time. Months and years are
+// calendar months and must be whole, other units are converted exactly,
+// to days when they are a whole number of days.
+func toDuration(obj object.Object) (*object.Duration, *object.Error) {
+	switch v := obj.(type) {
+	case *object.Duration:
+		return v, nil
+	case *object.Quantity:
+		if v.Unit.Dim != (units.Dimension{2: 1}) {
+			break
+		}
+		if len(v.Unit.Terms) == 1 && v.Unit.Terms[0].Power == 1 {
+			if months, ok := calendarUnits[v.Unit.Terms[0].Name]; ok {
+				n := v.Value * float64(months)
+				if n != math.Trunc(n) || math.Abs(n) > 1e7 {
+					return nil, newError("only a whole number of months can be added to a date, got %s", v.Inspect())
+				}
+				return &object.Duration{Months: int64(n)}, nil
+			}
+		}
+		value := new(big.Rat).SetFloat64(v.Value)
+		if value == nil {
+			return nil, newError("not a duration: %s", v.Inspect())
+		}
+		seconds := value.Mul(value, v.Unit.Factor)
+		days := new(big.Rat).Quo(seconds, big.NewRat(secondsPerDay, 1))
+		if days.IsInt() && days.Num().IsInt64() {
+			return &object.Duration{Days: days.Num().Int64()}, nil
+		}
+		f, _ := seconds.Float64()
+		return &object.Duration{Seconds: f}, nil
+	}
+	return nil, newError("not a duration: %s, write a unit of time such as 90 days", obj.Inspect())
+}
+
+// newDuration moves whole days out of the seconds, PT36H is P1DT12H.
+func newDuration(months, days int64, seconds float64) *object.Duration {
+	whole := math.Trunc(seconds / secondsPerDay)
+	return &object.Duration{Months: months, Days: days + int64(whole), Seconds: seconds - whole*secondsPerDay}
+}
+
+func negateDuration(d *object.Duration) *object.Duration {
+	return &object.Duration{Months: -d.Months, Days: -d.Days, Seconds: -d.Seconds}
+}
+
+// durationSeconds is the length of a duration without months.
+func durationSeconds(d *object.Duration) float64 {
+	return float64(d.Days)*secondsPerDay + d.Seconds
+}
+
+// scaleDuration multiplies a duration by a number. Whole factors keep the
+// parts apart, other factors need a duration without months.
+func scaleDuration(d *object.Duration, factor object.Object) object.Object {
+	f, ok := toFloat(factor)
+	if !ok {
+		return newError("unsupported operands for *: %s and %s", d.Type(), factor.Type())
+	}
+	if f == math.Trunc(f) && math.Abs(f) <= 1<<31 {
+		n := int64(f)
+		return newDuration(d.Months*n, d.Days*n, d.Seconds*f)
+	}
+	if d.Months != 0 {
+		return newError("a duration in months can only be multiplied by whole numbers")
+	}
+	return &object.Duration{Seconds: durationSeconds(d) * f}
+}
+
+// addDuration adds the months of d first, a day past the end of the month
+// falling back to its last day, then the days and the seconds.
+func addDuration(date *object.Date, d *object.Duration) object.Object {
+	t := date.Value
+	if d.Months != 0 {
+		month := int64(t.Month()-1) + d.Months
+		year := int64(t.Year()) + month/12
+		if month %= 12; month < 0 {
+			month += 12
+			year--
+		}
+		if year < 1 || year > 9999 {
+			return newError("date out of range")
+		}
+		day := min(t.Day(), daysIn(int(year), time.Month(month+1)))
+		t = time.Date(int(year), time.Month(month+1), day, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	}
+	days := math.Floor(d.Seconds / secondsPerDay)
+	if math.Abs(float64(d.Days)+days) > 4e6 {
+		return newError("date out of range")
+	}
+	t = t.AddDate(0, 0, int(d.Days)+int(days))
+	t = t.Add(time.Duration(math.Round((d.Seconds - days*secondsPerDay) * 1e9)))
+	if t.Year() < 1 || t.Year() > 9999 {
+		return newError("date out of range")
+	}
+	return &object.Date{Value: t}
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
@@ -0,0 +1,190 @@
+package evaluator
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/hellracer2007/webCalc/calculator/object"
+)
+
+// maxDecimalScale bounds the digits after the point of a decimal.
+const maxDecimalScale = 30
+
+// moneyScale is the least scale of quotients and inexact powers of
+// decimals, cents.
+const moneyScale = 2
+
+func init() {
+	builtins["round"] = &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("round: wrong number of arguments. got=%d, want=1 or 2", len(args))
+			}
+			scale := int64(0)
+			if len(args) == 2 {
+				xs, err := integers("round", args[1:])
+				if err != nil {
+					return err
+				}
+				scale = xs[0]
+				if scale < 0 || scale > maxDecimalScale {
+					return newError("round: the number of digits must be between 0 and %d", maxDecimalScale)
+				}
+			}
+			if isInteger(args[0]) {
+				return args[0]
+			}
+			f, ok := toFloat(args[0])
+			if !ok {
+				return newError("round: expected a number, got %s", args[0].Inspect())
+			}
+			if !isFinite(f) {
+				return args[0]
+			}
+			d := roundDecimal(args[0], int(scale))
+			if len(args) == 1 {
+				return newInteger(d.Unscaled)
+			}
+			return d
+		},
+	}
+}
+
+// isDecimal reports whether obj is a decimal or an integer, the values
+// decimal arithmetic keeps exact.
+func isDecimal(obj object.Object) bool {
+	return obj.Type() == object.DECIMAL_OBJ || isInteger(obj)
+}
+
+// toRat returns the exact value of a decimal, an integer or a float.
+func toRat(obj object.Object) *big.Rat {
+	switch v := obj.(type) {
+	case *object.Decimal:
+		scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(v.Scale)), nil)
+		return new(big.Rat).SetFrac(v.Unscaled, scale)
+	case *object.Float:
+		// the shortest decimal that reads back as the float, so 2.675
+		// is taken as written rather than as 2.67499999...
+		r, _ := new(big.Rat).SetString(strconv.FormatFloat(v.Value, 'g', -1, 64))
+		return r
+	}
+	n, _ := toBigInt(obj)
+	return new(big.Rat).SetInt(n)
+}
+
+// floatDecimal returns f as the decimal it reads as, 0.1 rather than
+// 0.1000000000000000055..., unless it has too many digits after the point.
+func floatDecimal(f float64) (*object.Decimal, bool) {
+	if !isFinite(f) {
+		return nil, false
+	}
+	s := strconv.FormatFloat(f, 'f', -1, 64)
+	scale := 0
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		scale = len(s) - i - 1
+	}
+	if scale > maxDecimalScale {
+		return nil, false
+	}
+	return roundDecimal(&object.Float{Value: f}, scale), true
+}
+
+func decimalToFloat(d *object.Decimal) float64 {
+	f, _ := toRat(d).Float64()
+	return f
+}
+
+// roundDecimal rounds obj to scale digits after the point, ties going to
+// the even digit as is usual for money: 2.665 becomes 2.66 and 2.675
+// becomes 2.68, so rounding errors do not pile up in one direction.
+func roundDecimal(obj object.Object, scale int) *object.Decimal {
+	return roundRat(toRat(obj), scale)
+}
+
+func roundRat(r *big.Rat, scale int) *object.Decimal {
+	pow := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)
+	num := new(big.Int).Mul(r.Num(), pow)
+	q, m := new(big.Int).QuoRem(num, r.Denom(), new(big.Int))
+	// compare twice the remainder with the denominator
+	switch new(big.Int).Lsh(new(big.Int).Abs(m), 1).Cmp(r.Denom()) {
+	case 1:
+		q.Add(q, big.NewInt(int64(r.Sign())))
+	case 0:
+		if q.Bit(0) == 1 {
+			q.Add(q, big.NewInt(int64(r.Sign())))
+		}
+	}
+	return &object.Decimal{Unscaled: q, Scale: scale}
+}
+
+// money rounds an amount to cents.
+func money(f float64) object.Object {
+	if !isFinite(f) {
+		return &object.Float{Value: f}
+	}
+	return roundDecimal(&object.Float{Value: f}, 2)
+}
+
+// evalDecimalInfixExpression adds, subtracts and multiplies decimals and
+// integers exactly, as are powers to whole exponents while they fit the
+// scale. Quotients and other powers are rounded to the larger scale of
+// the operands, at least cents: 100.00 / 3 is 33.33. Other operators work
+// in floating point.
+func evalDecimalInfixExpression(operator string, left, right object.Object) object.Object {
+	l, r := toRat(left), toRat(right)
+	ls, rs := decimalScale(left), decimalScale(right)
+	scale := max(ls, rs, moneyScale)
+	switch operator {
+	case "+":
+		return roundRat(new(big.Rat).Add(l, r), max(ls, rs))
+	case "-":
+		return roundRat(new(big.Rat).Sub(l, r), max(ls, rs))
+	case "*":
+		if ls+rs <= maxDecimalScale {
+			return roundRat(new(big.Rat).Mul(l, r), ls+rs)
+		}
+	case "/":
+		if r.Sign() == 0 {
+			return newError("division by zero")
+		}
+		return roundRat(new(big.Rat).Quo(l, r), scale)
+	case "^":
+		if !r.IsInt() || r.Num().BitLen() > 31 {
+			break
+		}
+		n := r.Num().Int64()
+		switch {
+		case n >= 0 && n <= maxDecimalScale && ls*int(n) <= maxDecimalScale:
+			return roundRat(ratPower(l, n), ls*int(n))
+		case n < 0 && n >= -maxDecimalScale:
+			if l.Sign() == 0 {
+				return newError("division by zero")
+			}
+			return roundRat(new(big.Rat).Inv(ratPower(l, -n)), scale)
+		}
+	}
+	lf, _ := l.Float64()
+	rf, _ := r.Float64()
+	if operator == "^" {
+		if p := math.Pow(lf, rf); isFinite(p) {
+			return roundRat(new(big.Rat).SetFloat64(p), scale)
+		}
+	}
+	return evalInfixExpression(operator, &object.Float{Value: lf}, &object.Float{Value: rf})
+}
+
+// ratPower is x^n for n ≥ 0.
+func ratPower(x *big.Rat, n int64) *big.Rat {
+	num := new(big.Int).Exp(x.Num(), big.NewInt(n), nil)
+	den := new(big.Int).Exp(x.Denom(), big.NewInt(n), nil)
+	return new(big.Rat).SetFrac(num, den)
+}
+
+func decimalScale(obj object.Object) int {
+	if d, ok := obj.(*object.Decimal); ok {
+		return d.Scale
+	}
+	return 0
+}
@@ -0,0 +1,695 @@
+package evaluator
+
+import (
+	"math"
+	"math/cmplx"
+	"sort"
+
+	"github.com/hellracer2007/webCalc/calculator/object"
+)
+
+// The decompositions work on row slices and are converted back to
+// matrices at the end.
+
+func toRows(m *object.Matrix) [][]float64 {
+	return augment(m, nil)
+}
+
+func fromRows(rows [][]float64) *object.Matrix {
+	cols := 0
+	if len(rows) > 0 {
+		cols = len(rows[0])
+	}
+	m := object.NewMatrix(len(rows), cols)
+	for i, row := range rows {
+		copy(m.Data[i*cols:], row)
+	}
+	return m
+}
+
+func identityRows(n int) [][]float64 {
+	return toRows(identity(n))
+}
+
+// lu factors a square matrix as P A = L U with partial pivoting, L has a
+// unit diagonal.
+func lu(m *object.Matrix) object.Object {
+	if err := requireSquare("lu", m); err != nil {
+		return err
+	}
+	n := m.Rows
+	u := toRows(m)
+	l := identityRows(n)
+	p := identityRows(n)
+	for k := 0; k < n; k++ {
+		pivot := k
+		for i := k + 1; i < n; i++ {
+			if math.Abs(u[i][k]) > math.Abs(u[pivot][k]) {
+				pivot = i
+			}
+		}
+		if pivot != k {
+			u[k], u[pivot] = u[pivot], u[k]
+			p[k], p[pivot] = p[pivot], p[k]
+			for j := 0; j < k; j++ {
+				l[k][j], l[pivot][j] = l[pivot][j], l[k][j]
+			}
+		}
+		if u[k][k] == 0 {
+			continue
+		}
+		for i := k + 1; i < n; i++ {
+			f := u[i][k] / u[k][k]
+			l[i][k] = f
+			for j := k; j < n; j++ {
+				u[i][j] -= f * u[k][j]
+			}
+		}
+	}
+	return &object.Tuple{
+		Names:    []string{"L", "U", "P"},
+		Elements: []object.Object{fromRows(l), fromRows(u), fromRows(p)},
+	}
+}
+
+// qr factors an m x n matrix as A = Q R with Householder reflections.
+func qr(m *object.Matrix) object.Object {
+	rows, cols := m.Rows, m.Cols
+	r := toRows(m)
+	q := identityRows(rows)
+	v := make([]float64, rows)
+	for k := 0; k < cols && k < rows-1; k++ {
+		norm := 0.0
+		for i := k; i < rows; i++ {
+			norm = math.Hypot(norm, r[i][k])
+		}
+		if norm == 0 {
+			continue
+		}
+		alpha := -math.Copysign(norm, r[k][k])
+		vnorm := 0.0
+		for i := k; i < rows; i++ {
+			v[i] = r[i][k]
+			if i == k {
+				v[i] -= alpha
+			}
+			vnorm = math.Hypot(vnorm, v[i])
+		}
+		if vnorm == 0 {
+			continue
+		}
+		for i := k; i < rows; i++ {
+			v[i] /= vnorm
+		}
+		for j := 0; j < cols; j++ {
+			dot := 0.0
+			for i := k; i < rows; i++ {
+				dot += v[i] * r[i][j]
+			}
+			for i := k; i < rows; i++ {
+				r[i][j] -= 2 * v[i] * dot
+			}
+		}
+		for i := 0; i < rows; i++ {
+			dot := 0.0
+			for j := k; j < rows; j++ {
+				dot += q[i][j] * v[j]
+			}
+			for j := k; j < rows; j++ {
+				q[i][j] -= 2 * dot * v[j]
+			}
+		}
+		for i := k + 1; i < rows; i++ {
+			r[i][k] = 0
+		}
+	}
+	return &object.Tuple{
+		Names:    []string{"Q", "R"},
+		Elements: []object.Object{fromRows(q), fromRows(r)},
+	}
+}
+
+func isSymmetric(m *object.Matrix) bool {
+	if m.Rows != m.Cols {
+		return false
+	}
+	for i := 0; i < m.Rows; i++ {
+		for j := 0; j < i; j++ {
+			a, b := m.At(i, j), m.At(j, i)
+			if math.Abs(a-b) > 1e-12*math.Max(1, math.Max(math.Abs(a), math.Abs(b))) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// cholesky factors a symmetric positive definite matrix as A = L Lᵀ.
+func cholesky(m *object.Matrix) object.Object {
+	if err := requireSquare("chol", m); err != nil {
+		return err
+	}
+	if !isSymmetric(m) {
+		return newError("chol: matrix is not symmetric")
+	}
+	n := m.Rows
+	l := make([][]float64, n)
+	for i := range l {
+		l[i] = make([]float64, n)
+	}
+	for i := 0; i < n; i++ {
+		for j := 0; j <= i; j++ {
+			sum := m.At(i, j)
+			for k := 0; k < j; k++ {
+				sum -= l[i][k] * l[j][k]
+			}
+			if i == j {
+				if sum <= 0 {
+					return newError("chol: matrix is not positive definite")
+				}
+				l[i][i] = math.Sqrt(sum)
+			} else {
+				l[i][j] = sum / l[j][j]
+			}
+		}
+	}
+	return fromRows(l)
+}
+
+// svd computes the thin singular value decomposition A = U diag(S) Vᵀ by
+// one-sided Jacobi rotations, which keeps small singular values accurate.
+// S is sorted in decreasing order.
+func svd(m *object.Matrix) object.Object {
+	if m.Rows < m.Cols {
+		t := svd(transpose(m)).(*object.Tuple)
+		return &object.Tuple{
+			Names:    []string{"U", "S", "V"},
+			Elements: []object.Object{t.Elements[2], t.Elements[1], t.Elements[0]},
+		}
+	}
+	u, s, v := jacobiSVD(toRows(m))
+	return &object.Tuple{
+		Names:    []string{"U", "S", "V"},
+		Elements: []object.Object{fromRows(u), newVector(s), fromRows(v)},
+	}
+}
+
+func jacobiSVD(u [][]float64) ([][]float64, []float64, [][]float64) {
+	rows, cols := len(u), len(u[0])
+	v := identityRows(cols)
+	for sweep := 0; sweep < 60; sweep++ {
+		rotated := false
+		for p := 0; p < cols-1; p++ {
+			for q := p + 1; q < cols; q++ {
+				alpha, beta, gamma := 0.0, 0.0, 0.0
+				for i := 0; i < rows; i++ {
+					alpha += u[i][p] * u[i][p]
+					beta += u[i][q] * u[i][q]
+					gamma += u[i][p] * u[i][q]
+				}
+				if gamma == 0 || math.Abs(gamma) <= 1e-15*math.Sqrt(alpha*beta) {
+					continue
+				}
+				rotated = true
+				zeta := (beta - alpha) / (2 * gamma)
+				t := math.Copysign(1, zeta) / (math.Abs(zeta) + math.Sqrt(1+zeta*zeta))
+				c := 1 / math.Sqrt(1+t*t)
+				s := c * t
+				for i := 0; i < rows; i++ {
+					up, uq := u[i][p], u[i][q]
+					u[i][p] = c*up - s*uq
+					u[i][q] = s*up + c*uq
+				}
+				for i := 0; i < cols; i++ {
+					vp, vq := v[i][p], v[i][q]
+					v[i][p] = c*vp - s*vq
+					v[i][q] = s*vp + c*vq
+				}
+			}
+		}
+		if !rotated {
+			break
+		}
+	}
+	sigma := make([]float64, cols)
+	for j := 0; j < cols; j++ {
+		norm := 0.0
+		for i := 0; i < rows; i++ {
+			norm = math.Hypot(norm, u[i][j])
+		}
+		sigma[j] = norm
+		if norm != 0 {
+			for i := 0; i < rows; i++ {
+				u[i][j] /= norm
+			}
+		}
+	}
+	order := make([]int, cols)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return sigma[order[a]] > sigma[order[b]] })
+	return permuteColumns(u, order), permute(sigma, order), permuteColumns(v, order)
+}
+
+func permute(xs []float64, order []int) []float64 {
+	result := make([]float64, len(xs))
+	for i, o := range order {
+		result[i] = xs[o]
+	}
+	return result
+}
+
+func permuteColumns(rows [][]float64, order []int) [][]float64 {
+	result := make([][]float64, len(rows))
+	for i, row := range rows {
+		result[i] = permute(row, order)
+	}
+	return result
+}
+
+// eig returns the eigenvalues and eigenvectors of a square matrix. The
+// eigenvectors are the columns of a matrix, or a vector of complex
+// vectors when some eigenvalues are complex.
+func eig(m *object.Matrix) object.Object {
+	if err := requireSquare("eig", m); err != nil {
+		return err
+	}
+	if isSymmetric(m) {
+		values, vectors := jacobiEigen(toRows(m))
+		return &object.Tuple{
+			Names:    []string{"values", "vectors"},
+			Elements: []object.Object{newVector(values), fromRows(vectors)},
+		}
+	}
+	values, err := eigenvalues(toRows(m))
+	if err != nil {
+		return err
+	}
+	vectors := make([][]complex128, len(values))
+	allReal := true
+	for i, lambda := range values {
+		vectors[i] = inverseIteration(toRows(m), lambda)
+		if imag(lambda) != 0 {
+			allReal = false
+		}
+	}
+	var vecs object.Object
+	if allReal {
+		rows := make([][]float64, m.Rows)
+		for i := range rows {
+			rows[i] = make([]float64, len(values))
+			for j := range values {
+				rows[i][j] = real(vectors[j][i])
+			}
+		}
+		vecs = fromRows(rows)
+	} else {
+		columns := make([]object.Object, len(values))
+		for j, vec := range vectors {
+			columns[j] = newComplexVector(vec)
+		}
+		vecs = &object.Vector{Elements: columns}
+	}
+	return &object.Tuple{
+		Names:    []string{"values", "vectors"},
+		Elements: []object.Object{newComplexVector(values), vecs},
+	}
+}
+
+func eigvals(m *object.Matrix) object.Object {
+	if err := requireSquare("eigvals", m); err != nil {
+		return err
+	}
+	if isSymmetric(m) {
+		values, _ := jacobiEigen(toRows(m))
+		return newVector(values)
+	}
+	values, err := eigenvalues(toRows(m))
+	if err != nil {
+		return err
+	}
+	return newComplexVector(values)
+}
+
+func newComplexVector(cs []complex128) *object.Vector {
+	elements := make([]object.Object, len(cs))
+	for i, c := range cs {
+		elements[i] = newComplex(c)
+	}
+	return &object.Vector{Elements: elements}
+}
+
+// jacobiEigen diagonalises a symmetric matrix with cyclic Jacobi
+// rotations, returning the eigenvalues in decreasing order and the
+// eigenvectors as columns.
+func jacobiEigen(a [][]float64) ([]float64, [][]float64) {
+	n := len(a)
+	v := identityRows(n)
+	for sweep := 0; sweep < 100; sweep++ {
+		off, total := 0.0, 0.0
+		for i := 0; i < n; i++ {
+			for j := 0; j < n; j++ {
+				total += a[i][j] * a[i][j]
+				if i != j {
+					off += a[i][j] * a[i][j]
+				}
+			}
+		}
+		if off <= 1e-30*total {
+			break
+		}
+		for p := 0; p < n-1; p++ {
+			for q := p + 1; q < n; q++ {
+				if a[p][q] == 0 {
+					continue
+				}
+				theta := (a[q][q] - a[p][p]) / (2 * a[p][q])
+				t := math.Copysign(1, theta) / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+				c := 1 / math.Sqrt(t*t+1)
+				s := t * c
+				for k := 0; k < n; k++ {
+					akp, akq := a[k][p], a[k][q]
+					a[k][p] = c*akp - s*akq
+					a[k][q] = s*akp + c*akq
+				}
+				for k := 0; k < n; k++ {
+					apk, aqk := a[p][k], a[q][k]
+					a[p][k] = c*apk - s*aqk
+					a[q][k] = s*apk + c*aqk
+				}
+				for k := 0; k < n; k++ {
+					vkp, vkq := v[k][p], v[k][q]
+					v[k][p] = c*vkp - s*vkq
+					v[k][q] = s*vkp + c*vkq
+				}
+			}
+		}
+	}
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = a[i][i]
+	}
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(x, y int) bool { return values[order[x]] > values[order[y]] })
+	return permute(values, order), permuteColumns(v, order)
+}
+
+// eigenvalues of a general real matrix: reduction to Hessenberg form by
+// elimination followed by the Francis double shift QR algorithm.
+func eigenvalues(a [][]float64) ([]complex128, *object.Error) {
+	hessenberg(a)
+	values, ok := hqr(a)
+	if !ok {
+		return nil, newError("eig: QR iteration did not converge")
+	}
+	sort.SliceStable(values, func(i, j int) bool {
+		if real(values[i]) != real(values[j]) {
+			return real(values[i]) > real(values[j])
+		}
+		return imag(values[i]) > imag(values[j])
+	})
+	return values, nil
+}
+
+func hessenberg(a [][]float64) {
+	n := len(a)
+	for m := 1; m < n-1; m++ {
+		x := 0.0
+		i := m
+		for j := m; j < n; j++ {
+			if math.Abs(a[j][m-1]) > math.Abs(x) {
+				x = a[j][m-1]
+				i = j
+			}
+		}
+		if i != m {
+			a[i], a[m] = a[m], a[i]
+			for j := 0; j < n; j++ {
+				a[j][i], a[j][m] = a[j][m], a[j][i]
+			}
+		}
+		if x == 0 {
+			continue
+		}
+		for i := m + 1; i < n; i++ {
+			y := a[i][m-1]
+			if y == 0 {
+				continue
+			}
+			y /= x
+			a[i][m-1] = 0
+			for j := m; j < n; j++ {
+				a[i][j] -= y * a[m][j]
+			}
+			for j := 0; j < n; j++ {
+				a[j][m] += y * a[j][i]
+			}
+		}
+	}
+}
+
+func hqr(a [][]float64) ([]complex128, bool) {
+	n := len(a)
+	values := make([]complex128, n)
+	anorm := 0.0
+	for i := 0; i < n; i++ {
+		for j := max(i-1, 0); j < n; j++ {
+			anorm += math.Abs(a[i][j])
+		}
+	}
+	t := 0.0
+	for nn := n - 1; nn >= 0; {
+		its := 0
+		for {
+			var l int
+			for l = nn; l >= 1; l-- {
+				s := math.Abs(a[l-1][l-1]) + math.Abs(a[l][l])
+				if s == 0 {
+					s = anorm
+				}
+				if math.Abs(a[l][l-1])+s == s {
+					a[l][l-1] = 0
+					break
+				}
+			}
+			x := a[nn][nn]
+			if l == nn {
+				values[nn] = complex(x+t, 0)
+				nn--
+				break
+			}
+			y := a[nn-1][nn-1]
+			w := a[nn][nn-1] * a[nn-1][nn]
+			if l == nn-1 {
+				p := 0.5 * (y - x)
+				q := p*p + w
+				z := math.Sqrt(math.Abs(q))
+				x += t
+				if q >= 0 {
+					z = p + math.Copysign(z, p)
+					values[nn-1] = complex(x+z, 0)
+					values[nn] = values[nn-1]
+					if z != 0 {
+						values[nn] = complex(x-w/z, 0)
+					}
+				} else {
+					values[nn-1] = complex(x+p, -z)
+					values[nn] = complex(x+p, z)
+				}
+				nn -= 2
+				break
+			}
+			if its == 60 {
+				return nil, false
+			}
+			if its == 10 || its == 20 {
+				t += x
+				for i := 0; i <= nn; i++ {
+					a[i][i] -= x
+				}
+				s := math.Abs(a[nn][nn-1]) + math.Abs(a[nn-1][nn-2])
+				x = 0.75 * s
+				y = x
+				w = -0.4375 * s * s
+			}
+			its++
+			var m int
+			var p, q, r, z float64
+			for m = nn - 2; m >= l; m-- {
+				z = a[m][m]
+				r = x - z
+				s := y - z
+				p = (r*s-w)/a[m+1][m] + a[m][m+1]
+				q = a[m+1][m+1] - z - r - s
+				r = a[m+2][m+1]
+				s = math.Abs(p) + math.Abs(q) + math.Abs(r)
+				p /= s
+				q /= s
+				r /= s
+				if m == l {
+					break
+				}
+				u := math.Abs(a[m][m-1]) * (math.Abs(q) + math.Abs(r))
+				v := math.Abs(p) * (math.Abs(a[m-1][m-1]) + math.Abs(z) + math.Abs(a[m+1][m+1]))
+				if u+v == v {
+					break
+				}
+			}
+			for i := m + 2; i <= nn; i++ {
+				a[i][i-2] = 0
+				if i != m+2 {
+					a[i][i-3] = 0
+				}
+			}
+			for k := m; k <= nn-1; k++ {
+				if k != m {
+					p = a[k][k-1]
+					q = a[k+1][k-1]
+					r = 0
+					if k != nn-1 {
+						r = a[k+2][k-1]
+					}
+					x = math.Abs(p) + math.Abs(q) + math.Abs(r)
+					if x != 0 {
+						p /= x
+						q /= x
+						r /= x
+					}
+				}
+				s := math.Copysign(math.Sqrt(p*p+q*q+r*r), p)
+				if s == 0 {
+					continue
+				}
+				if k == m {
+					if l != m {
+						a[k][k-1] = -a[k][k-1]
+					}
+				} else {
+					a[k][k-1] = -s * x
+				}
+				p += s
+				x = p / s
+				y = q / s
+				z = r / s
+				q /= p
+				r /= p
+				for j := k; j <= nn; j++ {
+					p = a[k][j] + q*a[k+1][j]
+					if k != nn-1 {
+						p += r * a[k+2][j]
+						a[k+2][j] -= p * z
+					}
+					a[k+1][j] -= p * y
+					a[k][j] -= p * x
+				}
+				mmin := nn
+				if k+3 < nn {
+					mmin = k + 3
+				}
+				for i := l; i <= mmin; i++ {
+					p = x*a[i][k] + y*a[i][k+1]
+					if k != nn-1 {
+						p += z * a[i][k+2]
+						a[i][k+2] -= p * r
+					}
+					a[i][k+1] -= p * q
+					a[i][k] -= p
+				}
+			}
+		}
+	}
+	return values, true
+}
+
+// inverseIteration finds the eigenvector of a for a known eigenvalue by
+// solving (A - λI) x = b a few times with a slightly perturbed λ. The
+// result has unit length and its largest component is real and positive.
+func inverseIteration(a [][]float64, lambda complex128) []complex128 {
+	n := len(a)
+	norm := 0.0
+	for _, row := range a {
+		for _, x := range row {
+			norm = math.Max(norm, math.Abs(x))
+		}
+	}
+	shift := lambda + complex(1e-10*math.Max(norm, 1), 0)
+	x := make([]complex128, n)
+	for i := range x {
+		x[i] = complex(1/math.Sqrt(float64(n)), 0)
+	}
+	for it := 0; it < 3; it++ {
+		m := make([][]complex128, n)
+		for i := range m {
+			m[i] = make([]complex128, n+1)
+			for j := 0; j < n; j++ {
+				m[i][j] = complex(a[i][j], 0)
+			}
+			m[i][i] -= shift
+			m[i][n] = x[i]
+		}
+		x = solveComplex(m)
+		length := 0.0
+		for _, c := range x {
+			length = math.Hypot(length, cmplx.Abs(c))
+		}
+		for i := range x {
+			x[i] /= complex(length, 0)
+		}
+	}
+	largest := 0
+	for i := range x {
+		if cmplx.Abs(x[i]) > cmplx.Abs(x[largest]) {
+			largest = i
+		}
+	}
+	phase := complex(cmplx.Abs(x[largest]), 0) / x[largest]
+	for i := range x {
+		x[i] *= phase
+		re, im := real(x[i]), imag(x[i])
+		if math.Abs(re) < 1e-14 {
+			re = 0
+		}
+		if math.Abs(im) < 1e-14 {
+			im = 0
+		}
+		x[i] = complex(re, im)
+	}
+	return x
+}
+
+// solveComplex solves an augmented n x (n+1) system in place, replacing
+// zero pivots with a tiny value as inverse iteration expects.
+func solveComplex(m [][]complex128) []complex128 {
+	n := len(m)
+	for k := 0; k < n; k++ {
+		p := k
+		for i := k + 1; i < n; i++ {
+			if cmplx.Abs(m[i][k]) > cmplx.Abs(m[p][k]) {
+				p = i
+			}
+		}
+		m[k], m[p] = m[p], m[k]
+		if m[k][k] == 0 {
+			m[k][k] = 1e-300
+		}
+		for i := k + 1; i < n; i++ {
+			f := m[i][k] / m[k][k]
+			for j := k; j <= n; j++ {
+				m[i][j] -= f * m[k][j]
+			}
+		}
+	}
+	x := make([]complex128, n)
+	for i := n - 1; i >= 0; i-- {
+		sum := m[i][n]
+		for j := i + 1; j < n; j++ {
+			sum -= m[i][j] * x[j]
+		}
+		x[i] = sum / m[i][i]
+	}
+	return x
+}
@@ -0,0 +1,116 @@
+package evaluator
+
+import (
+	"math"
+
+	"github.com/hellracer2007/webCalc/calculator/ast"
+	"github.com/hellracer2007/webCalc/calculator/object"
+	"github.com/hellracer2007/webCalc/calculator/symbolic"
+)
+
+const (
+	diffMaxOrder = 10
+	diffTableau  = 10
+	diffShrink   = 1.4
+	diffSafe     = 2.0
+	diffRelTol   = 1e-6
+)
+
+// evalDiff implements diff(expr, x, at) and diff(expr, x, at, n), the n-th
+// derivative of expr with respect to x at the point at. Without a point,
+// diff(expr, x) returns the derivative as an expression.
+func evalDiff(node *ast.CallExpression, env *object.Environment) object.Object {
+	if len(node.Arguments) < 2 || len(node.Arguments) > 4 {
+		return newError("diff: wrong number of arguments. got=%d, want=2 to 4", len(node.Arguments))
+	}
+	name, err := variableName("diff", node.Arguments[1])
+	if err != nil {
+		return err
+	}
+	if len(node.Arguments) == 2 {
+		d, err := symbolic.Derivative(node.Arguments[0], name)
+		if err != nil {
+			return newError("diff: %s", err)
+		}
+		return &object.Expression{Node: d}
+	}
+	at, err := evalNumber("diff", node.Arguments[2], env)
+	if err != nil {
+		return err
+	}
+	order := 1.0
+	if len(node.Arguments) == 4 {
+		if order, err = evalNumber("diff", node.Arguments[3], env); err != nil {
+			return err
+		}
+	}
+	if order != math.Trunc(order) || order < 0 || order > diffMaxOrder {
+		return newError("diff: the order must be an integer between 0 and %d, got %v", diffMaxOrder, order)
+	}
+	f := bindReal("diff", node.Arguments[0], name, env)
+	d, err := derivative(f, at, int(order))
+	if err != nil {
+		return err
+	}
+	return normalizeNumber(&object.Float{Value: d})
+}
+
+// derivative estimates the n-th derivative of f at x with Ridders' method:
+// central differences for a shrinking step are extrapolated to a zero step
+// and the estimate with the smallest error is kept.
+func derivative(f realFunc, x float64, n int) (float64, *object.Error) {
+	if n == 0 {
+		return f(x)
+	}
+	// higher orders lose more digits to cancellation, so they start wider
+	h := 0.1 * float64(n)
+	if x != 0 {
+		h *= math.Abs(x)
+	}
+	var a [diffTableau][diffTableau]float64
+	best, bestErr := math.NaN(), math.Inf(1)
+	for i := 0; i < diffTableau; i++ {
+		d, err := centralDifference(f, x, h, n)
+		if err != nil {
+			return 0, err
+		}
+		a[i][0] = d
+		factor := diffShrink * diffShrink
+		for j := 1; j <= i; j++ {
+			a[i][j] = (a[i][j-1]*factor - a[i-1][j-1]) / (factor - 1)
+			factor *= diffShrink * diffShrink
+			e := math.Max(math.Abs(a[i][j]-a[i][j-1]), math.Abs(a[i][j]-a[i-1][j-1]))
+			if e <= bestErr {
+				best, bestErr = a[i][j], e
+			}
+		}
+		if i > 0 && math.Abs(a[i][i]-a[i-1][i-1]) >= diffSafe*bestErr {
+			break
+		}
+		h /= diffShrink
+	}
+	if !isFinite(best) || bestErr > diffRelTol*math.Max(1, math.Abs(best)) {
+		return 0, newError("diff: the derivative does not exist at %v", x)
+	}
+	return best, nil
+}
+
+// centralDifference is the n-th order central difference of f at x with
+// step h, divided by h^n.
+func centralDifference(f realFunc, x, h float64, n int) (float64, *object.Error) {
+	sum := 0.0
+	binomial := 1.0
+	for k := 0; k <= n; k++ {
+		y, err := f(x + (float64(n)/2-float64(k))*h)
+		if err != nil {
+			return 0, err
+		}
+		if k%2 == 1 {
+			sum -= binomial * y
+		} else {
+			sum += binomial * y
+		}
+		binomial = binomial * float64(n-k) / float64(k+1)
+	}
+	return sum / math.Pow(h, float64(n)), nil
+}
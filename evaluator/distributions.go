@@ -0,0 +1,430 @@
+package evaluator
+
+import (
+	"math"
+
+	"github.com/hellracer2007/webCalc/calculator/object"
+)
+
+// distribution describes a probability distribution by its density (the
+// probability mass for discrete ones), its cumulative distribution and
+// the inverse of the latter. Each is registered as a built-in named after
+// the distribution, normpdf, normcdf and norminv for "norm".
+type distribution struct {
+	name string
+	// defaults holds a default for every parameter, NaN when the
+	// parameter is required
+	defaults []float64
+	// check returns a message when the parameters are invalid
+	check func(ps []float64) string
+	pdf   func(x float64, ps []float64) float64
+	cdf   func(x float64, ps []float64) float64
+	inv   func(p float64, ps []float64) float64
+}
+
+var required = math.NaN()
+
+var distributions = []distribution{
+	{
+		name:     "norm",
+		defaults: []float64{0, 1},
+		check:    positive(1, "sigma"),
+		pdf: func(x float64, ps []float64) float64 {
+			z := (x - ps[0]) / ps[1]
+			return math.Exp(-z*z/2) / (ps[1] * math.Sqrt(2*math.Pi))
+		},
+		cdf: func(x float64, ps []float64) float64 {
+			return math.Erfc(-(x-ps[0])/(ps[1]*math.Sqrt2)) / 2
+		},
+		inv: func(p float64, ps []float64) float64 {
+			return ps[0] + ps[1]*normalQuantile(p)
+		},
+	},
+	{
+		name:     "bino",
+		defaults: []float64{required, required},
+		check: func(ps []float64) string {
+			if ps[0] < 0 || ps[0] != math.Trunc(ps[0]) {
+				return "n must be a non-negative integer"
+			}
+			if ps[1] < 0 || ps[1] > 1 {
+				return "p must be between 0 and 1"
+			}
+			return ""
+		},
+		pdf: binomialPMF,
+		cdf: binomialCDF,
+		inv: func(q float64, ps []float64) float64 {
+			n, p := ps[0], ps[1]
+			return discreteInverse(q, ps, binomialCDF, n*p, math.Sqrt(n*p*(1-p)), n)
+		},
+	},
+	{
+		name:     "poiss",
+		defaults: []float64{required},
+		check:    positive(0, "lambda"),
+		pdf: func(k float64, ps []float64) float64 {
+			if k < 0 || k != math.Trunc(k) {
+				return 0
+			}
+			lk, _ := math.Lgamma(k + 1)
+			return math.Exp(k*math.Log(ps[0]) - ps[0] - lk)
+		},
+		cdf: poissonCDF,
+		inv: func(q float64, ps []float64) float64 {
+			return discreteInverse(q, ps, poissonCDF, ps[0], math.Sqrt(ps[0]), math.Inf(1))
+		},
+	},
+	{
+		name:     "t",
+		defaults: []float64{required},
+		check:    positive(0, "nu"),
+		pdf: func(x float64, ps []float64) float64 {
+			nu := ps[0]
+			a, _ := math.Lgamma((nu + 1) / 2)
+			b, _ := math.Lgamma(nu / 2)
+			return math.Exp(a-b-(nu+1)/2*math.Log1p(x*x/nu)) / math.Sqrt(nu*math.Pi)
+		},
+		cdf: studentCDF,
+		inv: func(p float64, ps []float64) float64 {
+			// the upper tail is solved for directly, 1 - cdf would lose
+			// the digits of p close to 1
+			tail := func(x float64, ps []float64) float64 { return -studentTail(x, ps[0]) }
+			if p < 0.5 {
+				return -continuousInverse(-p, ps, tail, 0)
+			}
+			return continuousInverse(p-1, ps, tail, 0)
+		},
+	},
+	{
+		name:     "chi2",
+		defaults: []float64{required},
+		check:    positive(0, "k"),
+		pdf: func(x float64, ps []float64) float64 {
+			k := ps[0]
+			switch {
+			case x < 0:
+				return 0
+			case x == 0:
+				return densityAtZero(k/2, 0.5)
+			}
+			lg, _ := math.Lgamma(k / 2)
+			return math.Exp((k/2-1)*math.Log(x) - x/2 - k/2*math.Ln2 - lg)
+		},
+		cdf: chiSquareCDF,
+		inv: func(p float64, ps []float64) float64 {
+			return continuousInverse(p, ps, chiSquareCDF, 0)
+		},
+	},
+	{
+		name:     "f",
+		defaults: []float64{required, required},
+		check: func(ps []float64) string {
+			if ps[0] <= 0 || ps[1] <= 0 {
+				return "d1 and d2 must be positive"
+			}
+			return ""
+		},
+		pdf: func(x float64, ps []float64) float64 {
+			d1, d2 := ps[0], ps[1]
+			switch {
+			case x < 0:
+				return 0
+			case x == 0:
+				return densityAtZero(d1/2, 1)
+			}
+			return math.Exp((d1*math.Log(d1*x)+d2*math.Log(d2)-(d1+d2)*math.Log(d1*x+d2))/2-lbeta(d1/2, d2/2)) / x
+		},
+		cdf: fisherCDF,
+		inv: func(p float64, ps []float64) float64 {
+			return continuousInverse(p, ps, fisherCDF, 0)
+		},
+	},
+	{
+		name:     "exp",
+		defaults: []float64{1},
+		check:    positive(0, "mu"),
+		pdf: func(x float64, ps []float64) float64 {
+			if x < 0 {
+				return 0
+			}
+			return math.Exp(-x/ps[0]) / ps[0]
+		},
+		cdf: func(x float64, ps []float64) float64 {
+			if x < 0 {
+				return 0
+			}
+			return -math.Expm1(-x / ps[0])
+		},
+		inv: func(p float64, ps []float64) float64 {
+			return -ps[0] * math.Log1p(-p)
+		},
+	},
+	{
+		name:     "unif",
+		defaults: []float64{0, 1},
+		check: func(ps []float64) string {
+			if ps[0] >= ps[1] {
+				return "a must be less than b"
+			}
+			return ""
+		},
+		pdf: func(x float64, ps []float64) float64 {
+			if x < ps[0] || x > ps[1] {
+				return 0
+			}
+			return 1 / (ps[1] - ps[0])
+		},
+		cdf: func(x float64, ps []float64) float64 {
+			return clamp((x-ps[0])/(ps[1]-ps[0]), 0, 1)
+		},
+		inv: func(p float64, ps []float64) float64 {
+			return ps[0] + p*(ps[1]-ps[0])
+		},
+	},
+}
+
+func init() {
+	for _, d := range distributions {
+		d := d
+		builtins[d.name+"pdf"] = distributionBuiltin(d.name+"pdf", d, false, d.pdf)
+		builtins[d.name+"cdf"] = distributionBuiltin(d.name+"cdf", d, false, d.cdf)
+		builtins[d.name+"inv"] = distributionBuiltin(d.name+"inv", d, true, d.inv)
+	}
+}
+
+// distributionBuiltin wraps fn as name(x, params...), filling in the
+// defaults of omitted parameters. For an inverse x is a probability.
+func distributionBuiltin(name string, d distribution, inverse bool, fn func(float64, []float64) float64) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			min := 1
+			for _, def := range d.defaults {
+				if math.IsNaN(def) {
+					min++
+				}
+			}
+			max := len(d.defaults) + 1
+			if len(args) < min || len(args) > max {
+				if min == max {
+					return newError("%s: wrong number of arguments. got=%d, want=%d", name, len(args), min)
+				}
+				return newError("%s: wrong number of arguments. got=%d, want=%d to %d", name, len(args), min, max)
+			}
+			xs, err := sample(name, args)
+			if err != nil {
+				return err
+			}
+			ps := append(xs[1:], d.defaults[len(xs)-1:]...)
+			if msg := d.check(ps); msg != "" {
+				return newError("%s: %s", name, msg)
+			}
+			x := xs[0]
+			if inverse && (x < 0 || x > 1) {
+				return newError("%s: the probability must be between 0 and 1, got %v", name, x)
+			}
+			return number(fn(x, ps))
+		},
+	}
+}
+
+// positive checks that the parameter at index i is positive.
+func positive(i int, name string) func([]float64) string {
+	return func(ps []float64) string {
+		if ps[i] <= 0 {
+			return name + " must be positive"
+		}
+		return ""
+	}
+}
+
+// densityAtZero is the limit at 0 of a density behaving like x^(a-1),
+// scaled by c when a = 1.
+func densityAtZero(a, c float64) float64 {
+	switch {
+	case a < 1:
+		return math.Inf(1)
+	case a == 1:
+		return c
+	}
+	return 0
+}
+
+func binomialPMF(k float64, ps []float64) float64 {
+	n, p := ps[0], ps[1]
+	if k < 0 || k > n || k != math.Trunc(k) {
+		return 0
+	}
+	switch {
+	case p == 0:
+		return boolFloat(k == 0)
+	case p == 1:
+		return boolFloat(k == n)
+	}
+	// the binomial coefficient by multiplication stays exact while it
+	// fits a float, through lgamma it picks up rounding errors
+	c := 1.0
+	m := math.Min(k, n-k)
+	for i := 1.0; i <= m && !math.IsInf(c, 1); i++ {
+		c = c * (n - m + i) / i
+	}
+	logPower := k*math.Log(p) + (n-k)*math.Log1p(-p)
+	if !math.IsInf(c, 1) && logPower > -700 {
+		return c * math.Pow(p, k) * math.Pow(1-p, n-k)
+	}
+	ln, _ := math.Lgamma(n + 1)
+	lk, _ := math.Lgamma(k + 1)
+	lnk, _ := math.Lgamma(n - k + 1)
+	return math.Exp(ln - lk - lnk + logPower)
+}
+
+func boolFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func binomialCDF(k float64, ps []float64) float64 {
+	n, p := ps[0], ps[1]
+	k = math.Floor(k)
+	switch {
+	case k < 0:
+		return 0
+	case k >= n:
+		return 1
+	}
+	return betaI(1-p, n-k, k+1)
+}
+
+func poissonCDF(k float64, ps []float64) float64 {
+	if k < 0 {
+		return 0
+	}
+	return gammaQ(math.Floor(k)+1, ps[0])
+}
+
+func studentCDF(x float64, ps []float64) float64 {
+	tail := studentTail(x, ps[0])
+	if x > 0 {
+		return 1 - tail
+	}
+	return tail
+}
+
+// studentTail is the probability of exceeding |x|.
+func studentTail(x, nu float64) float64 {
+	return betaI(nu/(nu+x*x), nu/2, 0.5) / 2
+}
+
+func chiSquareCDF(x float64, ps []float64) float64 {
+	return gammaP(ps[0]/2, x/2)
+}
+
+func fisherCDF(x float64, ps []float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	d1, d2 := ps[0], ps[1]
+	return betaI(1/(1+d2/(d1*x)), d1/2, d2/2)
+}
+
+// continuousInverse solves cdf(x) = p for x above lo, doubling the upper
+// end of the bracket until it holds the solution. cdf need only be
+// increasing, the probability p is taken as given.
+func continuousInverse(p float64, ps []float64, cdf func(float64, []float64) float64, lo float64) float64 {
+	if p <= cdf(lo, ps) {
+		return lo
+	}
+	if p >= cdf(math.Inf(1), ps) {
+		return math.Inf(1)
+	}
+	f := func(x float64) (float64, *object.Error) { return cdf(x, ps) - p, nil }
+	hi := lo + 1
+	for cdf(hi, ps) < p && !math.IsInf(hi, 1) {
+		lo, hi = hi, 2*hi
+	}
+	flo, _ := f(lo)
+	fhi, _ := f(hi)
+	x, _ := brent(f, lo, hi, flo, fhi)
+	return x
+}
+
+// discreteInverse returns the smallest k with cdf(k) >= p, searching
+// from the normal approximation mean + sd z.
+func discreteInverse(p float64, ps []float64, cdf func(float64, []float64) float64, mean, sd, max float64) float64 {
+	if p == 1 {
+		return max
+	}
+	k := clamp(math.Floor(mean+sd*normalQuantile(p)), 0, max)
+	for k > 0 && cdf(k-1, ps) >= p {
+		k--
+	}
+	for k < max && cdf(k, ps) < p {
+		k++
+	}
+	return k
+}
+
+// normalQuantile is the inverse of the standard normal distribution,
+// Wichura's algorithm AS 241 which is accurate to about 1e-16 also far
+// out in the tails.
+func normalQuantile(p float64) float64 {
+	switch {
+	case p <= 0:
+		return math.Inf(-1)
+	case p >= 1:
+		return math.Inf(1)
+	}
+	q := p - 0.5
+	if math.Abs(q) <= 0.425 {
+		r := 0.180625 - q*q
+		return q * rational(r, []float64{
+			3.387132872796366608, 133.14166789178437745, 1971.5909503065514427, 13731.693765509461125,
+			45921.953931549871457, 67265.770927008700853, 33430.575583588128105, 2509.0809287301226727,
+		}, []float64{
+			1, 42.313330701600911252, 687.1870074920579083, 5394.1960214247511077,
+			21213.794301586595867, 39307.89580009271061, 28729.085735721942674, 5226.495278852545925,
+		})
+	}
+	r := p
+	if q > 0 {
+		r = 1 - p
+	}
+	r = math.Sqrt(-math.Log(r))
+	var x float64
+	if r <= 5 {
+		r -= 1.6
+		x = rational(r, []float64{
+			1.42343711074968357734, 4.6303378461565452959, 5.7694972214606914055, 3.64784832476320460504,
+			1.27045825245236838258, 0.24178072517745061177, 0.0227238449892691845833, 7.7454501427834140764e-4,
+		}, []float64{
+			1, 2.05319162663775882187, 1.6763848301838038494, 0.68976733498510000455,
+			0.14810397642748007459, 0.0151986665636164571966, 5.475938084995344946e-4, 1.05075007164441684324e-9,
+		})
+	} else {
+		r -= 5
+		x = rational(r, []float64{
+			6.6579046435011037772, 5.4637849111641143699, 1.7848265399172913358, 0.29656057182850489123,
+			0.026532189526576123093, 0.0012426609473880784386, 2.71155556874348757815e-5, 2.01033439929228813265e-7,
+		}, []float64{
+			1, 0.59983220655588793769, 0.13692988092273580531, 0.0148753612908506148525,
+			7.868691311456132591e-4, 1.8463183175100546818e-5, 1.4215117583164458887e-7, 2.04426310338993978564e-15,
+		})
+	}
+	if q < 0 {
+		return -x
+	}
+	return x
+}
+
+// rational evaluates the quotient of two polynomials given by their
+// coefficients, lowest power first.
+func rational(x float64, num, den []float64) float64 {
+	n, d := 0.0, 0.0
+	for i := len(num) - 1; i >= 0; i-- {
+		n = n*x + num[i]
+		d = d*x + den[i]
+	}
+	return n / d
+}
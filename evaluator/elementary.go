@@ -0,0 +1,163 @@
+package evaluator
+
+import (
+	"math"
+
+	"github.com/hellracer2007/webCalc/calculator/object"
+)
+
+// elementary is a procedure beyond sin, cos, tan and the logarithms. An
+// argument outside its domain, a pole included, is an error rather than
+// NaN or ±Inf; domain is nil for functions defined everywhere.
+type elementary struct {
+	fn     func(float64) float64
+	domain func(float64) bool
+	// expects names the domain in the error message
+	expects string
+}
+
+// The reciprocal trigonometric functions work in degrees like sin, cos
+// and tan, their inverses return degrees.
+var elementaries = map[string]elementary{
+	"sinh":    {fn: math.Sinh},
+	"cosh":    {fn: math.Cosh},
+	"tanh":    {fn: math.Tanh},
+	"arcsinh": {fn: math.Asinh},
+	"arccosh": {math.Acosh, func(x float64) bool { return x >= 1 }, "x ≥ 1"},
+	"arctanh": {math.Atanh, func(x float64) bool { return x > -1 && x < 1 }, "-1 < x < 1"},
+	"sec": {
+		func(x float64) float64 { return 1 / math.Cos(x*math.Pi/180) },
+		func(x float64) bool { return math.Abs(math.Mod(x, 180)) != 90 },
+		"x ≠ 90 + 180k",
+	},
+	"csc": {
+		func(x float64) float64 { return 1 / math.Sin(x*math.Pi/180) },
+		func(x float64) bool { return math.Mod(x, 180) != 0 },
+		"x ≠ 180k",
+	},
+	"cot": {
+		func(x float64) float64 { return 1 / math.Tan(x*math.Pi/180) },
+		func(x float64) bool { return math.Mod(x, 180) != 0 },
+		"x ≠ 180k",
+	},
+	"arcsec": {
+		func(x float64) float64 { return math.Acos(1/x) * 180 / math.Pi },
+		func(x float64) bool { return math.Abs(x) >= 1 },
+		"|x| ≥ 1",
+	},
+	"arccsc": {
+		func(x float64) float64 { return math.Asin(1/x) * 180 / math.Pi },
+		func(x float64) bool { return math.Abs(x) >= 1 },
+		"|x| ≥ 1",
+	},
+	"arccot": {fn: func(x float64) float64 { return 90 - math.Atan(x)*180/math.Pi }},
+	"exp":    {fn: math.Exp},
+	"expm1":  {fn: math.Expm1},
+	"log2":   {math.Log2, func(x float64) bool { return x > 0 }, "x > 0"},
+	"log1p":  {math.Log1p, func(x float64) bool { return x > -1 }, "x > -1"},
+	"cbrt":   {fn: math.Cbrt},
+	"erf":    {fn: math.Erf},
+	"erfc":   {fn: math.Erfc},
+}
+
+// procedureDomains holds the domains of the logarithms, √ and the inverse
+// sine and cosine, which evalProcedure computes itself.
+var procedureDomains = map[string]elementary{
+	"ln":     {domain: func(x float64) bool { return x > 0 }, expects: "x > 0"},
+	"log":    {domain: func(x float64) bool { return x > 0 }, expects: "x > 0"},
+	"√":      {domain: func(x float64) bool { return x >= 0 }, expects: "x ≥ 0"},
+	"arcsin": {domain: func(x float64) bool { return math.Abs(x) <= 1 }, expects: "|x| ≤ 1"},
+	"arccos": {domain: func(x float64) bool { return math.Abs(x) <= 1 }, expects: "|x| ≤ 1"},
+}
+
+func init() {
+	builtins["logb"] = &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("logb: wrong number of arguments. got=%d, want=2", len(args))
+			}
+			xs, err := sample("logb", args)
+			if err != nil {
+				return err
+			}
+			x, b := xs[0], xs[1]
+			if x <= 0 {
+				return newError("logb: %v is outside the domain, x > 0", x)
+			}
+			if b <= 0 || b == 1 {
+				return newError("logb: the base must be positive and not 1, got %v", b)
+			}
+			r := math.Log(x) / math.Log(b)
+			// exact powers of the base come out whole
+			if n := math.Round(r); math.Pow(b, n) == x {
+				r = n
+			}
+			return number(r)
+		},
+	}
+	builtins["nthroot"] = &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("nthroot: wrong number of arguments. got=%d, want=2", len(args))
+			}
+			x, ok := toFloat(args[0])
+			if !ok {
+				return newError("nthroot: expected a number, got %s", args[0].Inspect())
+			}
+			ns, err := integers("nthroot", args[1:])
+			if err != nil {
+				return err
+			}
+			return nthRoot(x, ns[0])
+		},
+	}
+}
+
+// nthRoot is the real n-th root of x, negative for negative x and odd n.
+func nthRoot(x float64, n int64) object.Object {
+	switch {
+	case n == 0:
+		return newError("nthroot: n must not be 0")
+	case x < 0 && n%2 == 0:
+		return newError("nthroot: %v has no real root of even degree %d", x, n)
+	case n < 0:
+		r := nthRoot(x, -n)
+		if isError(r) {
+			return r
+		}
+		f, _ := toFloat(r)
+		if f == 0 {
+			return newError("division by zero")
+		}
+		return number(1 / f)
+	}
+	r := math.Pow(math.Abs(x), 1/float64(n))
+	if n == 3 {
+		r = math.Cbrt(math.Abs(x))
+	}
+	// perfect powers come out whole
+	if w := math.Round(r); math.Pow(w, float64(n)) == math.Abs(x) {
+		r = w
+	}
+	return number(math.Copysign(r, x))
+}
+
+// evalElementary applies one of the elementaries to a plain number.
+func evalElementary(proc string, body object.Object) object.Object {
+	e, ok := elementaries[proc]
+	if !ok {
+		return newError("unknown procedure: %s", proc)
+	}
+	x, _ := toFloat(body)
+	if e.domain != nil && !e.domain(x) {
+		return newError("%s: %v is outside the domain, %s", proc, x, e.expects)
+	}
+	y := e.fn(x)
+	switch {
+	case math.IsNaN(y):
+		return newError("%s: not defined for %v", proc, x)
+	case math.IsInf(y, 0) && isFinite(x):
+		return newError("%s: %v overflows", proc, x)
+	}
+	return number(y)
+}
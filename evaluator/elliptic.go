@@ -0,0 +1,423 @@
+package evaluator
+
+import (
+	"math/big"
+
+	"github.com/hellracer2007/webCalc/calculator/object"
+)
+
+// The elliptic integrals take the parameter m = k² and, for the incomplete
+// ones, the amplitude φ in degrees like the trigonometric functions:
+// F(φ, m) = ∫ dθ / √(1 - m sin²θ), E(φ, m) = ∫ √(1 - m sin²θ) dθ and
+// Π(n, φ, m) = ∫ dθ / ((1 - n sin²θ) √(1 - m sin²θ)) from 0 to φ, and K, E
+// and Π of m alone integrate to 90°. They are computed from Carlson's
+// symmetric integrals R_F, R_D and R_J, in big floats for floats too.
+
+func init() {
+	ellipk := preciseBuiltin("ellipk", 1, func(prec uint, xs []*big.Float) (*big.Float, *object.Error) {
+		if xs[0].Cmp(big.NewFloat(1)) >= 0 {
+			return nil, newError("ellipk: m must be less than 1, got %s", xs[0].Text('g', 10))
+		}
+		return ellipticF(bigInt(90, prec), xs[0], prec), nil
+	})
+	ellipf := preciseBuiltin("ellipf", 2, func(prec uint, xs []*big.Float) (*big.Float, *object.Error) {
+		phi, m := xs[0], xs[1]
+		if !ellipticDomain(phi, m, prec, false) {
+			return nil, newError("ellipf: needs m sin²φ < 1, and m < 1 for |φ| ≥ 90")
+		}
+		return ellipticF(phi, m, prec), nil
+	})
+	ellipe := func(prec uint, xs []*big.Float) (*big.Float, *object.Error) {
+		switch len(xs) {
+		case 1:
+			if xs[0].Cmp(big.NewFloat(1)) > 0 {
+				return nil, newError("ellipe: m must be at most 1, got %s", xs[0].Text('g', 10))
+			}
+			return ellipticE(bigInt(90, prec), xs[0], prec), nil
+		case 2:
+			phi, m := xs[0], xs[1]
+			if !ellipticDomain(phi, m, prec, true) {
+				return nil, newError("ellipe: needs m sin²φ ≤ 1, and m ≤ 1 for |φ| > 90")
+			}
+			return ellipticE(phi, m, prec), nil
+		}
+		return nil, newError("ellipe: wrong number of arguments. got=%d, want=1 or 2", len(xs))
+	}
+	ellippi := func(prec uint, xs []*big.Float) (*big.Float, *object.Error) {
+		switch len(xs) {
+		case 2:
+			n, m := xs[0], xs[1]
+			if n.Cmp(big.NewFloat(1)) >= 0 || m.Cmp(big.NewFloat(1)) >= 0 {
+				return nil, newError("ellippi: n and m must be less than 1")
+			}
+			return ellipticPi(n, bigInt(90, prec), m, prec), nil
+		case 3:
+			n, phi, m := xs[0], xs[1], xs[2]
+			if !ellipticDomain(phi, m, prec, false) || !ellipticDomain(phi, n, prec, false) {
+				return nil, newError("ellippi: needs n sin²φ < 1 and m sin²φ < 1, and n, m < 1 for |φ| ≥ 90")
+			}
+			return ellipticPi(n, phi, m, prec), nil
+		}
+		return nil, newError("ellippi: wrong number of arguments. got=%d, want=2 or 3", len(xs))
+	}
+	for name, fn := range map[string]preciseFunc{"ellipk": ellipk, "ellipf": ellipf, "ellipe": ellipe, "ellippi": ellippi} {
+		builtins[name] = floatBuiltin(name, fn)
+		preciseBuiltins[name] = fn
+	}
+}
+
+// ellipticAmplitude splits an amplitude φ in degrees into φ = 180j + θ
+// with |θ| ≤ 90 and returns the whole number j, sin θ and cos θ, which is
+// 0 for |θ| = 90.
+func ellipticAmplitude(phi *big.Float, prec uint) (*big.Float, *big.Float, *big.Float) {
+	q := newBig(64).Quo(phi, bigInt(180, 64))
+	q.Add(q, big.NewFloat(0.5*float64(q.Sign())))
+	n, _ := q.Int(nil)
+	j := new(big.Float).SetInt(n)
+	bits := phi.MinPrec() + uint(n.BitLen()) + 16
+	theta := newBig(bits).Sub(phi, newBig(bits).Mul(j, bigInt(180, 8)))
+	// the rounding of the quotient may leave θ just beyond ±90
+	if theta.Cmp(big.NewFloat(90)) > 0 {
+		theta.Sub(theta, big.NewFloat(180))
+		j.Add(j, big.NewFloat(1))
+	} else if theta.Cmp(big.NewFloat(-90)) < 0 {
+		theta.Add(theta, big.NewFloat(180))
+		j.Sub(j, big.NewFloat(1))
+	}
+	work := prec + guardBits
+	t := newBig(work).Quo(theta, bigInt(180, work))
+	s := bigSinPi(t, work)
+	c := bigSinPi(t.Add(t, big.NewFloat(0.5)), work)
+	return j, s, c
+}
+
+// ellipticDomain reports whether an integral of amplitude φ and parameter m
+// is real: m sin²φ < 1, or ≤ 1 when closed is set, and beyond |φ| = 90,
+// where the complete integral enters, m < 1, or ≤ 1 when closed.
+func ellipticDomain(phi, m *big.Float, prec uint, closed bool) bool {
+	j, s, c := ellipticAmplitude(phi, prec)
+	ms := newBig(prec+guardBits).Mul(m, s)
+	ms.Mul(ms, s)
+	one := big.NewFloat(1)
+	complete := j.Sign() != 0 || c.Sign() == 0
+	if closed {
+		return ms.Cmp(one) <= 0 && (!complete || m.Cmp(one) <= 0)
+	}
+	return ms.Cmp(one) < 0 && (!complete || m.Cmp(one) < 0)
+}
+
+// ellipticF is F(φ, m) = s R_F(c², 1 - m s², 1) + 2j K(m).
+func ellipticF(phi, m *big.Float, prec uint) *big.Float {
+	work := prec + guardBits
+	j, s, c := ellipticAmplitude(phi, work)
+	f := newBig(work).Mul(s, carlsonRF(newBig(work).Mul(c, c), ellipticDelta(m, s, work), bigInt(1, work), work))
+	if j.Sign() != 0 {
+		k := carlsonRF(newBig(work), newBig(work).Sub(bigInt(1, work), m), bigInt(1, work), work)
+		f.Add(f, k.Mul(k, newBig(work).Add(j, j)))
+	}
+	return newBig(prec).Set(f)
+}
+
+// ellipticE is E(φ, m) = s R_F(c², Δ, 1) - m s³ R_D(c², Δ, 1) / 3 + 2j E(m)
+// with Δ = 1 - m s², and sin θ + 2j for m = 1.
+func ellipticE(phi, m *big.Float, prec uint) *big.Float {
+	work := prec + guardBits
+	j, s, c := ellipticAmplitude(phi, work)
+	if m.Cmp(big.NewFloat(1)) == 0 {
+		e := newBig(work).Add(j, j)
+		return newBig(prec).Add(e, s)
+	}
+	e := ellipticEPart(s, c, m, work)
+	if j.Sign() != 0 {
+		complete := ellipticEPart(bigInt(1, work), newBig(work), m, work)
+		e.Add(e, complete.Mul(complete, newBig(work).Add(j, j)))
+	}
+	return newBig(prec).Set(e)
+}
+
+func ellipticEPart(s, c, m *big.Float, prec uint) *big.Float {
+	c2 := newBig(prec).Mul(c, c)
+	delta := ellipticDelta(m, s, prec)
+	e := carlsonRF(c2, delta, bigInt(1, prec), prec)
+	e.Mul(e, s)
+	d := carlsonRD(c2, delta, bigInt(1, prec), prec)
+	d.Mul(d, m)
+	d.Mul(d, bigPowInt(s, 3, prec))
+	d.Quo(d, bigInt(3, prec))
+	return e.Sub(e, d)
+}
+
+// ellipticPi is Π(n, φ, m) = s R_F(c², Δ, 1) + n s³ R_J(c², Δ, 1, 1 - n s²) / 3
+// + 2j Π(n, m) with Δ = 1 - m s².
+func ellipticPi(n, phi, m *big.Float, prec uint) *big.Float {
+	work := prec + guardBits
+	j, s, c := ellipticAmplitude(phi, work)
+	p := ellipticPiPart(n, s, c, m, work)
+	if j.Sign() != 0 {
+		complete := ellipticPiPart(n, bigInt(1, work), newBig(work), m, work)
+		p.Add(p, complete.Mul(complete, newBig(work).Add(j, j)))
+	}
+	return newBig(prec).Set(p)
+}
+
+func ellipticPiPart(n, s, c, m *big.Float, prec uint) *big.Float {
+	c2 := newBig(prec).Mul(c, c)
+	delta := ellipticDelta(m, s, prec)
+	p := carlsonRF(c2, delta, bigInt(1, prec), prec)
+	p.Mul(p, s)
+	r := carlsonRJ(c2, delta, bigInt(1, prec), ellipticDelta(n, s, prec), prec)
+	r.Mul(r, n)
+	r.Mul(r, bigPowInt(s, 3, prec))
+	r.Quo(r, bigInt(3, prec))
+	return p.Add(p, r)
+}
+
+// ellipticDelta is 1 - m s².
+func ellipticDelta(m, s *big.Float, prec uint) *big.Float {
+	d := newBig(prec).Mul(m, s)
+	d.Mul(d, s)
+	return d.Sub(bigInt(1, prec), d)
+}
+
+// carlsonTolerance is the relative spread of the arguments at which
+// Carlson's duplication stops: the series then err by about its sixth
+// power.
+func carlsonTolerance(prec uint) *big.Float {
+	t := big.NewFloat(1)
+	return t.SetMantExp(t, -int(prec/6)-1)
+}
+
+// carlsonRF is R_F(x, y, z) = ∫ dt / (2 √((t+x)(t+y)(t+z))) over t ≥ 0 for
+// x, y, z ≥ 0 with at most one of them 0, by Carlson's duplication theorem
+// and the series of Numerical Recipes.
+func carlsonRF(x, y, z *big.Float, prec uint) *big.Float {
+	work := prec + guardBits
+	tol := carlsonTolerance(work)
+	x, y, z = newBig(work).Set(x), newBig(work).Set(y), newBig(work).Set(z)
+	var ave, dx, dy, dz *big.Float
+	for {
+		lambda := carlsonLambda(x, y, z, work)
+		carlsonStep(lambda, work, x, y, z)
+		ave = newBig(work).Add(x, y)
+		ave.Add(ave, z)
+		ave.Quo(ave, bigInt(3, work))
+		dx, dy, dz = deviation(ave, x, work), deviation(ave, y, work), deviation(ave, z, work)
+		if within(tol, dx, dy, dz) {
+			break
+		}
+	}
+	// 1 + (e2/24 - 1/10 - 3 e3/44) e2 + e3/14
+	e2 := newBig(work).Mul(dx, dy)
+	e2.Sub(e2, newBig(work).Mul(dz, dz))
+	e3 := newBig(work).Mul(dx, dy)
+	e3.Mul(e3, dz)
+	r := newBig(work).Quo(e2, bigInt(24, work))
+	r.Sub(r, ratio(1, 10, work))
+	r.Sub(r, newBig(work).Mul(ratio(3, 44, work), e3))
+	r.Mul(r, e2)
+	r.Add(r, bigInt(1, work))
+	r.Add(r, newBig(work).Quo(e3, bigInt(14, work)))
+	r.Quo(r, newBig(work).Sqrt(ave))
+	return newBig(prec).Set(r)
+}
+
+// carlsonRD is R_D(x, y, z) = ∫ 3 dt / (2 √((t+x)(t+y)) (t+z)^(3/2)) for
+// x, y ≥ 0 with at most one of them 0 and z > 0.
+func carlsonRD(x, y, z *big.Float, prec uint) *big.Float {
+	work := prec + guardBits
+	tol := carlsonTolerance(work)
+	x, y, z = newBig(work).Set(x), newBig(work).Set(y), newBig(work).Set(z)
+	sum, fac := newBig(work), bigInt(1, work)
+	var ave, dx, dy, dz *big.Float
+	for {
+		lambda := carlsonLambda(x, y, z, work)
+		t := newBig(work).Add(z, lambda)
+		t.Mul(t, newBig(work).Sqrt(z))
+		sum.Add(sum, t.Quo(fac, t))
+		fac.SetMantExp(fac, -2)
+		carlsonStep(lambda, work, x, y, z)
+		// (x + y + 3z) / 5
+		ave = newBig(work).Add(x, y)
+		ave.Add(ave, newBig(work).Mul(z, bigInt(3, work)))
+		ave.Quo(ave, bigInt(5, work))
+		dx, dy, dz = deviation(ave, x, work), deviation(ave, y, work), deviation(ave, z, work)
+		if within(tol, dx, dy, dz) {
+			break
+		}
+	}
+	ea := newBig(work).Mul(dx, dy)
+	eb := newBig(work).Mul(dz, dz)
+	ec := newBig(work).Sub(ea, eb)
+	ed := newBig(work).Sub(ea, newBig(work).Mul(eb, bigInt(6, work)))
+	ee := newBig(work).Add(ed, ec)
+	ee.Add(ee, ec)
+	c1, c2, c3, c4 := ratio(3, 14, work), ratio(1, 6, work), ratio(9, 22, work), ratio(3, 26, work)
+	c5, c6 := ratio(9, 88, work), ratio(9, 52, work)
+	// 1 + ed (-c1 + c5 ed - c6 dz ee) + dz (c2 ee + dz (-c3 ec + dz c4 ea))
+	a := newBig(work).Mul(c5, ed)
+	a.Sub(a, c1)
+	a.Sub(a, product(work, c6, dz, ee))
+	a.Mul(a, ed)
+	b := product(work, dz, c4, ea)
+	b.Sub(b, newBig(work).Mul(c3, ec))
+	b.Mul(b, dz)
+	b.Add(b, newBig(work).Mul(c2, ee))
+	b.Mul(b, dz)
+	r := bigInt(1, work)
+	r.Add(r, a)
+	r.Add(r, b)
+	r.Mul(r, fac)
+	r.Quo(r, newBig(work).Mul(ave, newBig(work).Sqrt(ave)))
+	r.Add(r, sum.Mul(sum, bigInt(3, work)))
+	return newBig(prec).Set(r)
+}
+
+// carlsonRJ is R_J(x, y, z, p) = ∫ 3 dt / (2 √((t+x)(t+y)(t+z)) (t+p)) for
+// x, y, z ≥ 0 with at most one of them 0 and p > 0.
+func carlsonRJ(x, y, z, p *big.Float, prec uint) *big.Float {
+	work := prec + guardBits
+	tol := carlsonTolerance(work)
+	x, y, z, p = newBig(work).Set(x), newBig(work).Set(y), newBig(work).Set(z), newBig(work).Set(p)
+	sum, fac := newBig(work), bigInt(1, work)
+	var ave, dx, dy, dz, dp *big.Float
+	for {
+		sx, sy, sz := newBig(work).Sqrt(x), newBig(work).Sqrt(y), newBig(work).Sqrt(z)
+		lambda := carlsonLambda(x, y, z, work)
+		// α = (p (√x + √y + √z) + √(xyz))², β = p (p + λ)²
+		alpha := newBig(work).Add(sx, sy)
+		alpha.Add(alpha, sz)
+		alpha.Mul(alpha, p)
+		alpha.Add(alpha, product(work, sx, sy, sz))
+		alpha.Mul(alpha, alpha)
+		beta := newBig(work).Add(p, lambda)
+		beta.Mul(beta, beta)
+		beta.Mul(beta, p)
+		rc := carlsonRC(alpha, beta, work)
+		sum.Add(sum, rc.Mul(rc, fac))
+		fac.SetMantExp(fac, -2)
+		carlsonStep(lambda, work, x, y, z, p)
+		// (x + y + z + 2p) / 5
+		ave = newBig(work).Add(x, y)
+		ave.Add(ave, z)
+		ave.Add(ave, newBig(work).Mul(p, bigInt(2, work)))
+		ave.Quo(ave, bigInt(5, work))
+		dx, dy, dz, dp = deviation(ave, x, work), deviation(ave, y, work), deviation(ave, z, work), deviation(ave, p, work)
+		if within(tol, dx, dy, dz, dp) {
+			break
+		}
+	}
+	ea := newBig(work).Add(dy, dz)
+	ea.Mul(ea, dx)
+	ea.Add(ea, newBig(work).Mul(dy, dz))
+	eb := product(work, dx, dy, dz)
+	ec := newBig(work).Mul(dp, dp)
+	ed := newBig(work).Sub(ea, newBig(work).Mul(ec, bigInt(3, work)))
+	ee := newBig(work).Sub(ea, ec)
+	ee.Mul(ee, dp)
+	ee.SetMantExp(ee, 1)
+	ee.Add(ee, eb)
+	c1, c2, c3, c4 := ratio(3, 14, work), ratio(1, 3, work), ratio(3, 22, work), ratio(3, 26, work)
+	c5, c6, c7, c8 := ratio(9, 88, work), ratio(9, 52, work), ratio(1, 6, work), ratio(3, 11, work)
+	// 1 + ed (-c1 + c5 ed - c6 ee) + eb (c7 + dp (-c8 + dp c4))
+	// + dp ea (c2 - dp c3) - c2 dp ec
+	a := newBig(work).Mul(c5, ed)
+	a.Sub(a, c1)
+	a.Sub(a, newBig(work).Mul(c6, ee))
+	a.Mul(a, ed)
+	b := newBig(work).Mul(dp, c4)
+	b.Sub(b, c8)
+	b.Mul(b, dp)
+	b.Add(b, c7)
+	b.Mul(b, eb)
+	c := newBig(work).Mul(dp, c3)
+	c.Sub(c2, c)
+	c.Mul(c, product(work, dp, ea))
+	r := bigInt(1, work)
+	r.Add(r, a)
+	r.Add(r, b)
+	r.Add(r, c)
+	r.Sub(r, product(work, c2, dp, ec))
+	r.Mul(r, fac)
+	r.Quo(r, newBig(work).Mul(ave, newBig(work).Sqrt(ave)))
+	r.Add(r, sum.Mul(sum, bigInt(3, work)))
+	return newBig(prec).Set(r)
+}
+
+// carlsonRC is R_C(x, y) = R_F(x, y, y) for x ≥ 0 and y > 0.
+func carlsonRC(x, y *big.Float, prec uint) *big.Float {
+	work := prec + guardBits
+	tol := carlsonTolerance(work)
+	x, y = newBig(work).Set(x), newBig(work).Set(y)
+	var ave, s *big.Float
+	for {
+		// λ = 2 √x √y + y
+		lambda := newBig(work).Mul(newBig(work).Sqrt(x), newBig(work).Sqrt(y))
+		lambda.SetMantExp(lambda, 1)
+		lambda.Add(lambda, y)
+		carlsonStep(lambda, work, x, y)
+		ave = newBig(work).Add(x, y)
+		ave.Add(ave, y)
+		ave.Quo(ave, bigInt(3, work))
+		s = newBig(work).Sub(y, ave)
+		s.Quo(s, ave)
+		if within(tol, s) {
+			break
+		}
+	}
+	// 1 + s² (3/10 + s (1/7 + s (3/8 + s 9/22)))
+	r := newBig(work).Mul(s, ratio(9, 22, work))
+	r.Add(r, ratio(3, 8, work))
+	r.Mul(r, s)
+	r.Add(r, ratio(1, 7, work))
+	r.Mul(r, s)
+	r.Add(r, ratio(3, 10, work))
+	r.Mul(r, s)
+	r.Mul(r, s)
+	r.Add(r, bigInt(1, work))
+	r.Quo(r, newBig(work).Sqrt(ave))
+	return newBig(prec).Set(r)
+}
+
+// carlsonLambda is λ = √x (√y + √z) + √y √z.
+func carlsonLambda(x, y, z *big.Float, prec uint) *big.Float {
+	sy, sz := newBig(prec).Sqrt(y), newBig(prec).Sqrt(z)
+	lambda := newBig(prec).Add(sy, sz)
+	lambda.Mul(lambda, newBig(prec).Sqrt(x))
+	return lambda.Add(lambda, sy.Mul(sy, sz))
+}
+
+// carlsonStep replaces each argument v by (v + λ) / 4.
+func carlsonStep(lambda *big.Float, prec uint, vs ...*big.Float) {
+	for _, v := range vs {
+		v.Add(v, lambda)
+		v.SetMantExp(v, -2)
+	}
+}
+
+// deviation is (ave - v) / ave.
+func deviation(ave, v *big.Float, prec uint) *big.Float {
+	d := newBig(prec).Sub(ave, v)
+	return d.Quo(d, ave)
+}
+
+// within reports whether every |d| is at most tol.
+func within(tol *big.Float, ds ...*big.Float) bool {
+	for _, d := range ds {
+		if new(big.Float).Abs(d).Cmp(tol) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func ratio(a, b int64, prec uint) *big.Float {
+	return newBig(prec).Quo(bigInt(a, prec), bigInt(b, prec))
+}
+
+func product(prec uint, xs ...*big.Float) *big.Float {
+	p := bigInt(1, prec)
+	for _, x := range xs {
+		p.Mul(p, x)
+	}
+	return p
+}
@@ -4,60 +4,252 @@ import (
 	//	"math"
 	"fmt"
 	"math"
+	"math/big"
 
 	"github.com/hellracer2007/webCalc/calculator/ast"
+	"github.com/hellracer2007/webCalc/calculator/constants"
 	"github.com/hellracer2007/webCalc/calculator/object"
+	"github.com/hellracer2007/webCalc/calculator/units"
 )
 
-func Eval(node ast.Node) object.Object {	
+func Eval(node ast.Node, env *object.Environment) object.Object {
 	switch node := node.(type) {
 	case *ast.IntegerLiteral:
+		if env.IntervalMode() {
+			x, _ := toInterval(&object.Integer{Value: node.Value})
+			return x
+		}
 		return &object.Integer{Value: node.Value}
 	case *ast.FloatLiteral:
+		if env.IntervalMode() {
+			return literalInterval(node.Token.Literal, node.Value)
+		}
+		if digits := env.Precision(); digits > 0 {
+			return preciseLiteral(node, digits)
+		}
 		return &object.Float{Value: node.Value}
+	case *ast.DateLiteral:
+		if node.Invalid {
+			return newError("invalid date: %s", node.Token.Literal)
+		}
+		return &object.Date{Value: node.Value}
+	case *ast.DurationLiteral:
+		return &object.Duration{Months: node.Months, Days: node.Days, Seconds: node.Seconds}
+	case *ast.Identifier:
+		return evalIdentifier(node, env)
+	case *ast.UnitLiteral:
+		return evalUnitLiteral(node)
+	case *ast.VectorLiteral:
+		elements := evalExpressions(node.Elements, env)
+		if len(elements) == 1 && isError(elements[0]) {
+			return elements[0]
+		}
+		return newVectorOrMatrix(elements)
+	case *ast.ListLiteral:
+		elements := evalExpressions(node.Elements, env)
+		if len(elements) == 1 && isError(elements[0]) {
+			return elements[0]
+		}
+		return &object.List{Elements: elements}
+	case *ast.CallExpression:
+		if special, ok := specialForms[node.Func]; ok {
+			return special(node, env)
+		}
+		args := evalExpressions(node.Arguments, env)
+		if len(args) == 1 && isError(args[0]) {
+			return args[0]
+		}
+		if precise, ok := preciseBuiltins[node.Func]; ok && env.Precision() > 0 && !hasUncertain(args) {
+			return applyPrecise(node.Func, precise, args, env.Precision())
+		}
+		return applyFunction(node.Func, args)
+	case *ast.ConversionExpression:
+		if node.Left == nil || node.Unit == nil {
+			return newError("%s needs a value before it and a unit after it: %s", node.Token.Literal, node.String())
+		}
+		left := Eval(node.Left, env)
+		if isError(left) {
+			return left
+		}
+		return evalConversion(left, node.Unit)
+	case *ast.Equation:
+		return newError("an equation can only be used inside solve or root: %s", node.String())
 	case *ast.Program:
-		return evalProgram(node)
+		return evalProgram(node, env)
 	case *ast.ExpressionStatement :
-		return Eval(node.Expression)
+		if node.Expression == nil {
+			return newError("could not parse the expression starting at %s", node.Token.Literal)
+		}
+		return Eval(node.Expression, env)
 	case *ast.Procedure :
-		body := Eval(node.Body)
+		body := Eval(node.Body, env)
 		return evalProcedure(node.Func ,body)
 	case *ast.PostfixExpression:
-		left := Eval(node.Left)
+		left := Eval(node.Left, env)
+		if isError(left) {
+			return left
+		}
 		return evalPostFixExpression(node.Token.Literal, left)
 	case *ast.PrefixExpression:
-		right := Eval(node.Right)
+		right := Eval(node.Right, env)
+		if isError(right) {
+			return right
+		}
 		return evalPrefixExpression(node.Token.Literal, right)
 	case *ast.InfixExpression:
-		left:= Eval(node.Left)
+		left:= Eval(node.Left, env)
 		if isError(left) {
 			return left
 		}
-		right := Eval(node.Right)
+		right := Eval(node.Right, env)
 		if isError(right) {
 			return right
 		}
 		l, r := normalizeExpr(left, right)
+		if node.Token.Literal == "±" && env.SamplingMode() {
+			return samplePlusMinus(l, r, env)
+		}
+		if (node.Token.Literal == "/" || node.Token.Literal == "^") && env.Precision() > 0 && isInteger(l) && isInteger(r) {
+			return preciseIntegers(node.Token.Literal, l, r, env.Precision())
+		}
 		return evalInfixExpression(node.Token.Literal, l, r)
 	}
 	return nil
 } 
 
-func evalProgram(program *ast.Program) object.Object {
+func evalProgram(program *ast.Program, env *object.Environment) object.Object {
 	var result object.Object
 	for _, statement := range program.Statements {
-		result = Eval(statement)
+		result = Eval(statement, env)
+		// the statements after a parse error are its debris
+		if isError(result) {
+			return result
+		}
 	}
 	return result
 }
 
+func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Object {
+	var result []object.Object
+	for _, e := range exps {
+		evaluated := Eval(e, env)
+		if isError(evaluated) {
+			return []object.Object{evaluated}
+		}
+		result = append(result, evaluated)
+	}
+	return result
+}
+
+func applyFunction(name string, args []object.Object) object.Object {
+	builtin, ok := builtins[name]
+	if !ok {
+		return newError("unknown function: %s", name)
+	}
+	if hasInterval(args) && !intervalAware[name] {
+		return applyIntervals(name, builtin, args)
+	}
+	if hasUncertain(args) {
+		return applyUncertain(name, builtin, args)
+	}
+	return builtin.Fn(args...)
+}
+
+func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
+	if val, ok := env.Get(node.Value); ok {
+		return val
+	}
+	if c, ok := constants.Lookup(node.Value); ok {
+		if env.IntervalMode() && c.Unit == "" {
+			return constantInterval(c.Value)
+		}
+		if digits := env.Precision(); digits > 0 && c.Unit == "" && isFinite(c.Value) {
+			return &object.BigFloat{Value: c.Float(precisionBits(digits)), Digits: digits}
+		}
+		return evalConstant(c)
+	}
+	if u, err := units.Parse(node.Value); err == nil {
+		return &object.Quantity{Value: 1, Unit: u}
+	}
+	return newError("identifier not found: %s", node.Value)
+}
+
+// evalConstant returns physical constants as quantities in their SI unit.
+func evalConstant(c *constants.Constant) object.Object {
+	value := normalizeNumber(&object.Float{Value: c.Value})
+	if c.Unit == "" {
+		return value
+	}
+	u, err := units.Parse(c.Unit)
+	if err != nil {
+		return newError("constant %s: %s", c.Name, err)
+	}
+	return newQuantity(c.Value, u)
+}
+
 func evalPrefixExpression(operator string, right object.Object) object.Object {
 	switch v := right.(type){
+	case *object.Complex :
+		if operator == "-" {
+			return &object.Complex{Value: -v.Value}
+		}
+		return v
+	case *object.Matrix :
+		if operator != "-" {
+			return v
+		}
+		return scaleMatrix(v, -1)
+	case *object.Vector :
+		return mapVector(v, func(el object.Object) object.Object {
+			return evalPrefixExpression(operator, el)
+		})
+	case *object.Quantity :
+		if operator == "-" {
+			return &object.Quantity{Value: -v.Value, Unit: v.Unit}
+		}
+		return v
+	case *object.Duration :
+		if operator == "-" {
+			return negateDuration(v)
+		}
+		return v
+	case *object.Interval :
+		if operator == "-" {
+			return &object.Interval{Lo: -v.Hi, Hi: -v.Lo}
+		}
+		return v
+	case *object.Uncertain :
+		if operator == "-" {
+			return propagate(-v.Value, v, -1, &object.Uncertain{}, 0)
+		}
+		return v
 	case *object.Integer :
-		v.Value = -v.Value
+		if operator == "-" {
+			if v.Value == math.MinInt64 {
+				return newInteger(new(big.Int).Neg(big.NewInt(v.Value)))
+			}
+			return &object.Integer{Value: -v.Value}
+		}
+		return v
+	case *object.BigInt :
+		if operator == "-" {
+			return newInteger(new(big.Int).Neg(v.Value))
+		}
+		return v
+	case *object.Decimal :
+		if operator == "-" {
+			return &object.Decimal{Unscaled: new(big.Int).Neg(v.Unscaled), Scale: v.Scale}
+		}
 		return v
 	case *object.Float :
-		v.Value = -v.Value
+		if operator == "-" {
+			return &object.Float{Value: -v.Value}
+		}
+		return v
+	case *object.BigFloat :
+		if operator == "-" {
+			return &object.BigFloat{Value: new(big.Float).Neg(v.Value), Digits: v.Digits}
+		}
 		return v
 	}
 	return nil
@@ -66,12 +258,36 @@ func evalPrefixExpression(operator string, right object.Object) object.Object {
 func evalInfixExpression(operator string, left, right object.Object) object.Object {
 
 	switch {
+	case operator == "choose":
+		return evalChoose(left, right)
+	case operator == "±":
+		return evalPlusMinus(left, right)
+	case isDate(left) || isDate(right):
+		return evalDateInfixExpression(operator, left, right)
+	case left.Type() == object.MATRIX_OBJ || right.Type() == object.MATRIX_OBJ:
+		return evalMatrixInfixExpression(operator, left, right)
+	case left.Type() == object.VECTOR_OBJ && right.Type() == object.VECTOR_OBJ:
+		return evalVectorInfixExpression(operator, left, right)
+	case left.Type() == object.INTERVAL_OBJ || right.Type() == object.INTERVAL_OBJ:
+		return evalIntervalInfixExpression(operator, left, right)
+	case left.Type() == object.UNCERTAIN_OBJ || right.Type() == object.UNCERTAIN_OBJ:
+		return evalUncertainInfixExpression(operator, left, right)
+	case left.Type() == object.COMPLEX_OBJ && right.Type() == object.COMPLEX_OBJ:
+		return evalInfixComplexExpression(operator, left, right)
+	case left.Type() == object.QUANTITY_OBJ || right.Type() == object.QUANTITY_OBJ:
+		return evalQuantityInfixExpression(operator, left, right)
+	case left.Type() == object.BIGFLOAT_OBJ || right.Type() == object.BIGFLOAT_OBJ:
+		return evalBigFloatInfixExpression(operator, left, right)
 	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
 		return evalInfixIntegerExpression(operator, left, right)
+	case isInteger(left) && isInteger(right):
+		return evalBigIntInfixExpression(operator, left, right)
+	case isDecimal(left) && isDecimal(right):
+		return evalDecimalInfixExpression(operator, left, right)
 	case left.Type() == object.FLOAT_OBJ && right.Type() == object.FLOAT_OBJ:
 		return evalInfixFloatExpression(operator, left, right)
 	}
-	return nil
+	return newError("unsupported operands for %s: %s and %s", operator, left.Type(), right.Type())
 }
 
 func evalPostFixExpression(operator string, left object.Object) object.Object {
@@ -82,15 +298,6 @@ func evalPostFixExpression(operator string, left object.Object) object.Object {
 	return nil
 }
 
-func evalFactorial(left object.Object) object.Object{
-	val :=  left.(*object.Integer).Value 
-	result := val
-	for i := val; i >= 2; i-- {
-		result *= (i-1)
-	}
-	return &object.Integer{Value: result}
-}
-
 func evalInfixIntegerExpression(operator string, left, right object.Object) object.Object {
 	leftVal := left.(*object.Integer).Value
 	rightVal := right.(*object.Integer).Value
@@ -99,11 +306,28 @@ func evalInfixIntegerExpression(operator string, left, right object.Object) obje
 	switch operator {
 	case "+":
 		result.Value = leftVal + rightVal
+		// on overflow the sign comes out wrong
+		if (leftVal >= 0) == (rightVal >= 0) && (result.Value >= 0) != (leftVal >= 0) {
+			return evalBigIntInfixExpression(operator, left, right)
+		}
 	case "-":
 		result.Value = leftVal - rightVal
+		if (leftVal >= 0) != (rightVal >= 0) && (result.Value >= 0) != (leftVal >= 0) {
+			return evalBigIntInfixExpression(operator, left, right)
+		}
 	case "*":
 		result.Value = leftVal * rightVal
+		if leftVal != 0 && (result.Value/leftVal != rightVal || (leftVal == -1 && rightVal == math.MinInt64)) {
+			return evalBigIntInfixExpression(operator, left, right)
+		}
 	case "/":
+		if rightVal == 0 {
+			return newError("division by zero")
+		}
+		// whole numbers often come from normalizeNumber, 1/2 is still 0.5
+		if leftVal%rightVal != 0 {
+			return &object.Float{Value: float64(leftVal) / float64(rightVal)}
+		}
 		result.Value = leftVal / rightVal
 	case "E":
 		res := solveExp(left, right)
@@ -115,14 +339,8 @@ func evalInfixIntegerExpression(operator string, left, right object.Object) obje
 			return &object.Float{Value: float64(sol.Value)}
 		}
 	case "^" :
-		res := &object.Float{Value: math.Pow(float64(leftVal), float64(rightVal))}
-		solution, ok := normalizeNumber(res).(*object.Integer)
-		if ok {
-			return solution
-		}
-		return res
+		return integerPower(big.NewInt(leftVal), big.NewInt(rightVal))
 	case "√":
-		fmt.Println("we mafe it here")
 		res := math.Pow(float64(rightVal), 1.0/float64(leftVal))
 		return normalizeNumber(&object.Float{Value: res})
 	}	
@@ -174,19 +392,63 @@ func solveExp(value, exp object.Object) object.Object {
 		result.Value *= 10
 		right--
 	}
-	fmt.Println(result.Value)
 	return normalizeNumber(result)
 
 }
 
 func normalizeExpr(left, right object.Object) (object.Object, object.Object) {
-	fmt.Println("normalize")
+	// a scalar next to a vector is broadcast to the vector's length
+	if lv, ok := left.(*object.Vector); ok && isScalar(right) {
+		right = broadcast(right, len(lv.Elements))
+	}
+	if rv, ok := right.(*object.Vector); ok && isScalar(left) {
+		left = broadcast(left, len(rv.Elements))
+	}
+	// big floats keep their precision among plain real numbers only
+	if b, ok := left.(*object.BigFloat); ok && !isReal(right) {
+		left = &object.Float{Value: bigFloatToFloat(b)}
+	}
+	if b, ok := right.(*object.BigFloat); ok && !isReal(left) {
+		right = &object.Float{Value: bigFloatToFloat(b)}
+	}
+	// an interval or a big float takes big integers and decimals exactly
+	if left.Type() == object.BIGINT_OBJ && !isInteger(right) && right.Type() != object.INTERVAL_OBJ && right.Type() != object.BIGFLOAT_OBJ {
+		left = &object.Float{Value: bigToFloat(left.(*object.BigInt).Value)}
+	}
+	if right.Type() == object.BIGINT_OBJ && !isInteger(left) && left.Type() != object.INTERVAL_OBJ && left.Type() != object.BIGFLOAT_OBJ {
+		right = &object.Float{Value: bigToFloat(right.(*object.BigInt).Value)}
+	}
+	// a float next to a decimal is taken as written, so money plus 0.1
+	// stays money
+	if f, ok := right.(*object.Float); ok && left.Type() == object.DECIMAL_OBJ {
+		if d, ok := floatDecimal(f.Value); ok {
+			right = d
+		}
+	}
+	if f, ok := left.(*object.Float); ok && right.Type() == object.DECIMAL_OBJ {
+		if d, ok := floatDecimal(f.Value); ok {
+			left = d
+		}
+	}
+	// decimals stay exact among integers and decimals only
+	if left.Type() == object.DECIMAL_OBJ && !isDecimal(right) && right.Type() != object.INTERVAL_OBJ && right.Type() != object.BIGFLOAT_OBJ {
+		left = &object.Float{Value: decimalToFloat(left.(*object.Decimal))}
+	}
+	if right.Type() == object.DECIMAL_OBJ && !isDecimal(left) && left.Type() != object.INTERVAL_OBJ && left.Type() != object.BIGFLOAT_OBJ {
+		right = &object.Float{Value: decimalToFloat(right.(*object.Decimal))}
+	}
 	if left.Type() == object.FLOAT_OBJ && right.Type() == object.INTEGER_OBJ {
 		right = &object.Float{Value: float64(right.(*object.Integer).Value)}
 	}
 	if left.Type() == object.INTEGER_OBJ && right.Type() == object.FLOAT_OBJ {
 		left = &object.Float{Value: float64(left.(*object.Integer).Value)}
 	}
+	if c, ok := toFloat(left); ok && right.Type() == object.COMPLEX_OBJ {
+		left = &object.Complex{Value: complex(c, 0)}
+	}
+	if c, ok := toFloat(right); ok && left.Type() == object.COMPLEX_OBJ {
+		right = &object.Complex{Value: complex(c, 0)}
+	}
 
 	return left, right
 }
@@ -194,6 +456,40 @@ func normalizeExpr(left, right object.Object) (object.Object, object.Object) {
 
 
 func evalProcedure(proc string, body object.Object) object.Object {
+	if isError(body) {
+		return body
+	}
+	if q, ok := body.(*object.Quantity); ok {
+		return newError("%s expects a plain number, got %s", proc, q.Inspect())
+	}
+	if v, ok := body.(*object.Vector); ok {
+		return mapVector(v, func(el object.Object) object.Object {
+			return evalProcedure(proc, el)
+		})
+	}
+	if x, ok := body.(*object.Interval); ok {
+		return evalIntervalProcedure(proc, x)
+	}
+	if u, ok := body.(*object.Uncertain); ok {
+		return evalUncertainProcedure(proc, u)
+	}
+	if _, ok := toFloat(body); !ok {
+		return newError("%s expects a number, got %s", proc, body.Type())
+	}
+	if d, ok := body.(*object.Decimal); ok {
+		body = &object.Float{Value: decimalToFloat(d)}
+	}
+	if b, ok := body.(*object.BigInt); ok {
+		body = &object.Float{Value: bigToFloat(b.Value)}
+	}
+	if b, ok := body.(*object.BigFloat); ok {
+		body = &object.Float{Value: bigFloatToFloat(b)}
+	}
+	if e, ok := procedureDomains[proc]; ok {
+		if x, _ := toFloat(body); !e.domain(x) {
+			return newError("%s: %v is outside the domain, %s", proc, x, e.expects)
+		}
+	}
 	var result object.Object
 	switch {
 	case proc == "sin":
@@ -205,15 +501,17 @@ func evalProcedure(proc string, body object.Object) object.Object {
 	case proc == "log":
 		result = resolveProc(body, math.Log10)
 	case proc == "arcsin":
-		result = resolveDegreesProc(body, math.Asin)
+		result = resolveInverseDegreesProc(body, math.Asin)
 	case proc == "arccos":
-		result = resolveDegreesProc(body, math.Acos)
+		result = resolveInverseDegreesProc(body, math.Acos)
 	case proc == "arctan":
-		result = resolveDegreesProc(body, math.Atan)
+		result = resolveInverseDegreesProc(body, math.Atan)
 	case proc == "ln":
 		result = resolveProc(body, math.Log)
 	case proc == "√":
 		result = resolveProc(body, math.Sqrt)
+	default:
+		result = evalElementary(proc, body)
 	}
 	return result
 }
@@ -230,7 +528,6 @@ func resolveProc(body object.Object, proc func(float64) float64) object.Object {
 		result := proc(valf.Value)
 		res = &object.Float{Value: result}
 	}
-	fmt.Println(res)
 	return normalizeNumber(res)
 }
 
@@ -250,6 +547,13 @@ func resolveDegreesProc(body object.Object, proc func(float64) float64) object.O
 	return normalizeNumber(res)
 }
 
+// resolveInverseDegreesProc applies an inverse trigonometric function and
+// returns the angle in degrees, the unit the other procedures expect.
+func resolveInverseDegreesProc(body object.Object, proc func(float64) float64) object.Object {
+	return resolveProc(body, func(x float64) float64 {
+		return proc(x) * 180 / math.Pi
+	})
+}
 
 func normalizeNumber(number object.Object)object.Object{
 	result, ok := number.(*object.Float)
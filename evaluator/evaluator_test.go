@@ -0,0 +1,1137 @@
+package evaluator
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"strings"
+	"testing"
+
+	"github.com/hellracer2007/webCalc/calculator/ast"
+	"github.com/hellracer2007/webCalc/calculator/lexer"
+	"github.com/hellracer2007/webCalc/calculator/object"
+	"github.com/hellracer2007/webCalc/calculator/parser"
+)
+
+func testEval(input string) object.Object {
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	return Eval(program, object.NewEnvironment())
+}
+
+func testInspect(t *testing.T, input, expected string) {
+	t.Helper()
+	result := testEval(input)
+	if result == nil {
+		t.Fatalf("%s: got nil", input)
+	}
+	if result.Inspect() != expected {
+		t.Errorf("%s: expected %s got %s", input, expected, result.Inspect())
+	}
+}
+
+func TestVectors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[1, 2, 3] + [4, 5, 6]", "[5, 7, 9]"},
+		{"2 * [1, 2.5]", "[2, 5]"},
+		{"dot([1, 2, 3], [4, 5, 6])", "32"},
+		{"cross([1, 0, 0], [0, 1, 0])", "[0, 0, 1]"},
+		{"norm([3, 4])", "5"},
+		{"[1, 2] + [1, 2, 3]", "ERROR: vector length mismatch: 2 + 3"},
+	}
+	for _, tt := range tests {
+		testInspect(t, tt.input, tt.expected)
+	}
+}
+
+func TestMatrices(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[[1, 2], [3, 4]] * [[5, 6], [7, 8]]", "[[19, 22], [43, 50]]"},
+		{"transpose([[1, 2, 3], [4, 5, 6]])", "[[1, 4], [2, 5], [3, 6]]"},
+		{"det([[1, 2], [3, 4]])", "-2"},
+		{"inv([[1, 2], [3, 4]])", "[[-2, 1], [1.5, -0.5]]"},
+		{"rank([[1, 2], [2, 4]])", "1"},
+		{"linsolve([[2, 1], [1, 3]], [3, 5])", "[0.8, 1.4]"},
+		{"[[1, 2], [3, 4]] * [[1, 2, 3]]", "ERROR: dimension mismatch: 2x2 * 1x3"},
+		{"inv([[1, 2], [2, 4]])", "ERROR: inv: matrix is singular"},
+	}
+	for _, tt := range tests {
+		testInspect(t, tt.input, tt.expected)
+	}
+}
+
+func TestDecompositions(t *testing.T) {
+	a := "[[4, 12, -16], [12, 37, -43], [-16, -43, 98]]"
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"chol(" + a + ")", "[[2, 0, 0], [6, 1, 0], [-8, 5, 3]]"},
+		{"lu([[1, 2], [3, 4]])", "(L = [[1, 0], [0.3333333333333333, 1]], U = [[3, 4], [0, 0.6666666666666667]], P = [[0, 1], [1, 0]])"},
+		{"eigvals([[0, 1], [-2, -3]])", "[-1, -2]"},
+		{"eigvals([[0, -1], [1, 0]])", "[1i, -1i]"},
+		{"chol([[1, 2], [2, 1]])", "ERROR: chol: matrix is not positive definite"},
+	}
+	for _, tt := range tests {
+		testInspect(t, tt.input, tt.expected)
+	}
+
+	// the factors have to multiply back to the original matrix
+	for _, input := range []string{
+		"qr([[12, -51, 4], [6, 167, -68], [-4, 24, -41]])",
+		"svd([[3, 2, 2], [2, 3, -2]])",
+		"eig([[2, 1], [1, 2]])",
+	} {
+		tuple, ok := testEval(input).(*object.Tuple)
+		if !ok {
+			t.Fatalf("%s: expected a tuple", input)
+		}
+		var product object.Object
+		switch tuple.Names[0] {
+		case "Q":
+			product = matMul(tuple.Get("Q").(*object.Matrix), tuple.Get("R").(*object.Matrix))
+		case "U":
+			u := tuple.Get("U").(*object.Matrix)
+			s, _ := floats("svd", tuple.Get("S").(*object.Vector))
+			us := object.NewMatrix(u.Rows, u.Cols)
+			for i := 0; i < u.Rows; i++ {
+				for j := 0; j < u.Cols; j++ {
+					us.Set(i, j, u.At(i, j)*s[j])
+				}
+			}
+			product = matMul(us, transpose(tuple.Get("V").(*object.Matrix)))
+		case "values":
+			v := tuple.Get("vectors").(*object.Matrix)
+			d, _ := floats("eig", tuple.Get("values").(*object.Vector))
+			vd := object.NewMatrix(v.Rows, v.Cols)
+			for i := 0; i < v.Rows; i++ {
+				for j := 0; j < v.Cols; j++ {
+					vd.Set(i, j, v.At(i, j)*d[j])
+				}
+			}
+			product = matMul(vd, transpose(v))
+		}
+		original := testEval(input[strings.Index(input, "(")+1 : len(input)-1]).(*object.Matrix)
+		for i, x := range product.(*object.Matrix).Data {
+			if math.Abs(x-original.Data[i]) > 1e-9 {
+				t.Errorf("%s: factors give %s", input, product.Inspect())
+				break
+			}
+		}
+	}
+}
+
+func TestIntegerArithmetic(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"6 / 3", "2"},
+		{"5 / 2", "2.5"},
+		{"-7 / 2", "-3.5"},
+		{"1 / 0", "ERROR: division by zero"},
+		{"+5", "5"},
+		{"+2.5", "2.5"},
+		{"-2.5", "-2.5"},
+		{"3 - -2", "5"},
+	}
+	for _, tt := range tests {
+		testInspect(t, tt.input, tt.expected)
+	}
+}
+
+func TestIntegrate(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"integrate(x^2, x, 0, 1)", 1.0 / 3},
+		{"integrate(1/√x, x, 0, 1)", 2},
+		{"integrate(1/(1+x^2), x, 0, ∞)", math.Pi / 2},
+		{"integrate(e^(-(x^2)), x, -∞, ∞)", math.Sqrt(math.Pi)},
+		{"integrate(x, x, 1, 0)", -0.5},
+	}
+	for _, tt := range tests {
+		tuple, ok := testEval(tt.input).(*object.Tuple)
+		if !ok {
+			t.Fatalf("%s: expected a tuple, got %s", tt.input, testEval(tt.input).Inspect())
+		}
+		value, _ := toFloat(tuple.Get("value"))
+		estimate, _ := toFloat(tuple.Get("error"))
+		if math.Abs(value-tt.expected) > 1e-9 {
+			t.Errorf("%s: expected %v got %v", tt.input, tt.expected, value)
+		}
+		if estimate > 1e-9 {
+			t.Errorf("%s: error estimate too large: %v", tt.input, estimate)
+		}
+	}
+
+	for _, input := range []string{"integrate(1/x, x, 0, 1)", "integrate(1/x, x, 1, ∞)"} {
+		if result := testEval(input); !isError(result) {
+			t.Errorf("%s: expected an error, got %s", input, result.Inspect())
+		}
+	}
+}
+
+func TestSolve(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []float64
+	}{
+		{"solve(x^2 - 2 = 0, x)", []float64{-math.Sqrt2, math.Sqrt2}},
+		{"solve(x^3 - x = 0, x)", []float64{-1, 0, 1}},
+		{"solve(sin(x) = 0.5, x, 0, 360)", []float64{30, 150}},
+		{"solve((x-1)^2*(x-3), x)", []float64{1, 3}},
+		{"solve(ln(x) = 1, x)", []float64{math.E}},
+		{"solve(√(x) = 2, x)", []float64{4}},
+		{"solve([[2,1],[1,3]], [3,5])", []float64{0.8, 1.4}},
+	}
+	for _, tt := range tests {
+		vector, ok := testEval(tt.input).(*object.Vector)
+		if !ok {
+			t.Fatalf("%s: expected a vector, got %s", tt.input, testEval(tt.input).Inspect())
+		}
+		roots, _ := floats("solve", vector)
+		if len(roots) != len(tt.expected) {
+			t.Errorf("%s: expected %v got %v", tt.input, tt.expected, roots)
+			continue
+		}
+		for i, root := range roots {
+			if math.Abs(root-tt.expected[i]) > 1e-9 {
+				t.Errorf("%s: expected %v got %v", tt.input, tt.expected, roots)
+				break
+			}
+		}
+	}
+
+	roots := []struct {
+		input    string
+		expected float64
+	}{
+		{"root(x^2 - 2, 0, 2)", math.Sqrt2},
+		{"root(x^2 - 2, 1)", math.Sqrt2},
+		{"root(cos(t) - t/100, t, 0, 90)", 55.96701234713431},
+	}
+	for _, tt := range roots {
+		result, _ := toFloat(testEval(tt.input))
+		if math.Abs(result-tt.expected) > 1e-9 {
+			t.Errorf("%s: expected %v got %v", tt.input, tt.expected, result)
+		}
+	}
+
+	for _, input := range []string{"x = 2", "root(x^2 - 2, 3, 4)", "solve(x^2 = y, x)"} {
+		if result := testEval(input); !isError(result) {
+			t.Errorf("%s: expected an error, got %s", input, result.Inspect())
+		}
+	}
+
+	errors := []struct {
+		input    string
+		expected string
+	}{
+		{"solve(x^2 + 1 = 0, x)", "ERROR: solve: no real root in [-1000, 1000], the interval searched unless given as solve(equation, x, a, b)"},
+		{"solve(x^2 = 4, x, 5, 10)", "ERROR: solve: no real root in [5, 10]"},
+		{"solve(x = x, x)", "ERROR: solve: every x in [-1000, 1000] is a solution"},
+		{"solve(2*(t + 1) = 2*t + 2, t, 0, 1)", "ERROR: solve: every t in [0, 1] is a solution"},
+		{"root(tan(x), x, 80, 100)", "ERROR: root: f changes sign at a pole near 90.00000000000003, not at a root"},
+		{"1 == 1", "ERROR: could not parse the expression starting at 1"},
+		{"1 =", "ERROR: could not parse the expression starting at 1"},
+	}
+	for _, tt := range errors {
+		testInspect(t, tt.input, tt.expected)
+	}
+	if s := (&ast.Equation{Left: &ast.Identifier{Value: "x"}}).String(); s != "(x = )" {
+		t.Errorf("an equation without a right side prints as %s", s)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"diff(x^2, x, 3)", 6},
+		{"diff(x^3, x, 2, 2)", 12},
+		{"diff(x^5, x, 1, 4)", 120},
+		{"diff(e^x, x, 1, 5)", math.E},
+		{"diff(ln(x), x, 0.001)", 1000},
+		{"diff(sin(x), x, 0)", math.Pi / 180},
+		{"diff(x^2, x, 5, 0)", 25},
+	}
+	for _, tt := range tests {
+		result, ok := toFloat(testEval(tt.input))
+		if !ok {
+			t.Fatalf("%s: expected a number, got %s", tt.input, testEval(tt.input).Inspect())
+		}
+		if math.Abs(result-tt.expected) > 1e-7*math.Max(1, math.Abs(tt.expected)) {
+			t.Errorf("%s: expected %v got %v", tt.input, tt.expected, result)
+		}
+	}
+
+	for _, input := range []string{"diff(1/x, x, 0)", "diff(√x, x, 0)", "diff(x, x, 1, 1.5)", "diff(x^2, 2, 1)"} {
+		if result := testEval(input); !isError(result) {
+			t.Errorf("%s: expected an error, got %s", input, result.Inspect())
+		}
+	}
+}
+
+func TestInverseTrigonometry(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"arcsin(0.5)", 30},
+		{"arccos(0.5)", 60},
+		{"arctan(1)", 45},
+		{"arcsin(-1)", -90},
+		{"sin(arcsin(0.3))", 0.3},
+	}
+	for _, tt := range tests {
+		result, ok := toFloat(testEval(tt.input))
+		if !ok || math.Abs(result-tt.expected) > 1e-12*math.Max(1, math.Abs(tt.expected)) {
+			t.Errorf("%s: expected %v got %s", tt.input, tt.expected, testEval(tt.input).Inspect())
+		}
+	}
+}
+
+func TestSymbolicDiff(t *testing.T) {
+	// the symbolic derivative has to agree with the numeric one
+	for _, input := range []string{
+		"x^3 - 2*x", "sin(x^2)", "cos(x)*tan(x)", "ln(x)/x", "x^x", "√(1 + x^2)",
+		"arcsin(x/2)", "arccos(x/2)", "arctan(x)", "log(x)", "2^x", "e^(-x)",
+	} {
+		d, ok := testEval("diff(" + input + ", x)").(*object.Expression)
+		if !ok {
+			t.Fatalf("%s: expected an expression, got %s", input, testEval("diff("+input+", x)").Inspect())
+		}
+		for _, at := range []float64{0.3, 1.1} {
+			env := object.NewEnvironment()
+			env.Set("x", &object.Float{Value: at})
+			symbolic, _ := toFloat(Eval(d.Node, env))
+			numeric, _ := toFloat(testEval(fmt.Sprintf("diff(%s, x, %v)", input, at)))
+			if math.Abs(symbolic-numeric) > 1e-6*math.Max(1, math.Abs(numeric)) {
+				t.Errorf("d/dx %s at %v: %s gives %v, numerically %v", input, at, d.Inspect(), symbolic, numeric)
+			}
+		}
+	}
+}
+
+func TestSimplify(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"simplify(2*3 + x*1)", "(x + 6)"},
+		{"simplify(√16 * x)", "(4 * x)"},
+		{"simplify(cos(0)*x + ln(1))", "x"},
+		{"simplify(x * π / 180 * 2)", "((π * x) / 90)"},
+		{"simplify(x^2 = 2*x - x)", "((x ^ 2) = x)"},
+		{"simplify(3 + 4)", "7"},
+		{"simplify(diff(x^3, x))", "(3 * (x ^ 2))"},
+		{"simplify(diff(x^3, x) / x)", "(3 * x)"},
+		{"simplify(expand((x+1)^2) - x^2)", "((2 * x) + 1)"},
+		{"simplify(1 + expand((x+1)^100))", "ERROR: expand: ((x + 1) ^ 100) has a power above 64, too large to multiply out"},
+	}
+	for _, tt := range tests {
+		if result := testEval(tt.input).Inspect(); result != tt.expected {
+			t.Errorf("%s: expected %s got %s", tt.input, tt.expected, result)
+		}
+	}
+
+	// folding must not change what repeated evaluation computes
+	for _, input := range []string{"x^2 + 0*x + √16", "2*π*x - x*π", "sin(x) * cos(60)"} {
+		expr := parser.New(lexer.New(input)).ParseProgram().Statements[0].(*ast.ExpressionStatement).Expression
+		optimized := optimize(expr, "x", object.NewEnvironment())
+		for _, at := range []float64{-1.5, 0.5, 2} {
+			env := object.NewEnvironment()
+			env.Set("x", &object.Float{Value: at})
+			want, _ := toFloat(Eval(expr, env))
+			got, _ := toFloat(Eval(optimized, env))
+			if math.Abs(want-got) > 1e-12*math.Max(1, math.Abs(want)) {
+				t.Errorf("%s at %v: %s gives %v, want %v", input, at, optimized.String(), got, want)
+			}
+		}
+	}
+}
+
+func TestRoots(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []complex128
+	}{
+		{"roots(x^3 - 6*x^2 + 11*x - 6)", []complex128{1, 2, 3}},
+		{"roots((x-1)^3)", []complex128{1, 1, 1}},
+		{"roots(x^2 = 2)", []complex128{-math.Sqrt2, math.Sqrt2}},
+		{"roots(x^2 + 1)", []complex128{-1i, 1i}},
+		{"roots(x^4 + 1)", []complex128{
+			complex(-math.Sqrt2/2, -math.Sqrt2/2), complex(-math.Sqrt2/2, math.Sqrt2/2),
+			complex(math.Sqrt2/2, -math.Sqrt2/2), complex(math.Sqrt2/2, math.Sqrt2/2),
+		}},
+	}
+	for _, tt := range tests {
+		vector, ok := testEval(tt.input).(*object.Vector)
+		if !ok || len(vector.Elements) != len(tt.expected) {
+			t.Fatalf("%s: expected %d roots, got %s", tt.input, len(tt.expected), testEval(tt.input).Inspect())
+		}
+		for i, el := range vector.Elements {
+			var z complex128
+			switch el := el.(type) {
+			case *object.Complex:
+				z = el.Value
+			default:
+				f, _ := toFloat(el)
+				z = complex(f, 0)
+			}
+			if cmplx.Abs(z-tt.expected[i]) > 1e-12 {
+				t.Errorf("%s: expected %v got %s", tt.input, tt.expected, vector.Inspect())
+				break
+			}
+		}
+	}
+
+	for _, input := range []string{"roots(sin(x))", "roots(0)", "factor(x^2 - y^2)", "polydiv(x, 0)"} {
+		if result := testEval(input); !isError(result) {
+			t.Errorf("%s: expected an error, got %s", input, result.Inspect())
+		}
+	}
+	testInspect(t, "expand((x+1)^100)", "ERROR: expand: ((x + 1) ^ 100) has a power above 64, too large to multiply out")
+}
+
+func TestSum(t *testing.T) {
+	exact := []struct {
+		input    string
+		expected string
+	}{
+		{"sum(k^2, k, 1, 100)", "338350"},
+		{"Σ(k, k, 1, 10)", "55"},
+		{"∏(k, k, 1, 5)", "120"},
+		{"prod(k, k, 1, 30)", "265252859812191058636308480000000"},
+		{"sum(2^k, k, 0, 70)", "2361183241434822606847"},
+		{"sum(k, k, 1, 0)", "0"},
+		{"prod(k, k, 1, 0)", "1"},
+		{"sum([k, k^2], k, 1, 3)", "[6, 14]"},
+		{"2^100", "1267650600228229401496703205376"},
+		{"9223372036854775807 + 1", "9223372036854775808"},
+		{"2^100 / 2^98", "4"},
+	}
+	for _, tt := range exact {
+		if result := testEval(tt.input).Inspect(); result != tt.expected {
+			t.Errorf("%s: expected %s got %s", tt.input, tt.expected, result)
+		}
+	}
+
+	series := []struct {
+		input    string
+		expected float64
+	}{
+		{"sum(1/k^2, k, 1, ∞)", math.Pi * math.Pi / 6},
+		{"sum((-1)^(k+1)/k, k, 1, ∞)", math.Ln2},
+		{"sum(0.5^k, k, 0, ∞)", 2},
+		{"prod(1 - 1/k^2, k, 2, ∞)", 0.5},
+	}
+	for _, tt := range series {
+		result, ok := toFloat(testEval(tt.input))
+		if !ok || math.Abs(result-tt.expected) > 1e-8 {
+			t.Errorf("%s: expected %v got %s", tt.input, tt.expected, testEval(tt.input).Inspect())
+		}
+	}
+
+	for _, input := range []string{"sum(1/k, k, 1, ∞)", "sum((-1)^k, k, 0, ∞)", "sum(k, k, 1.5, 3)", "prod(-k, k, 1, ∞)"} {
+		if result := testEval(input); !isError(result) {
+			t.Errorf("%s: expected an error, got %s", input, result.Inspect())
+		}
+	}
+}
+
+func TestStatistics(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"mean(1, 2, 3, 4)", "2.5"},
+		{"mean({1, 2, 3, 4})", "2.5"},
+		{"median([3, 1, 2, 10])", "2.5"},
+		{"mode({1, 2, 2, 3, 3})", "{2, 3}"},
+		{"mode(1, 1, 2)", "1"},
+		{"pstddev({2, 4, 4, 4, 5, 5, 7, 9})", "2"},
+		{"variance({1E9 + 4, 1E9 + 7, 1E9 + 13, 1E9 + 16})", "30"},
+		{"quantile({1, 2, 3, 4, 5}, 0.25, 0.5, 0.9)", "{2, 3, 4.6}"},
+		{"linreg({1, 2, 3}, {3, 5, 7})", "(slope = 2, intercept = 1, r2 = 1)"},
+		{"{1, 2 + 3}", "{1, 5}"},
+	}
+	for _, tt := range tests {
+		if result := testEval(tt.input).Inspect(); result != tt.expected {
+			t.Errorf("%s: expected %s got %s", tt.input, tt.expected, result)
+		}
+	}
+
+	if r, _ := toFloat(testEval("corr({1, 2, 3}, {2, 4, 7})")); math.Abs(r-0.9933992677987827) > 1e-12 {
+		t.Errorf("corr: got %v", r)
+	}
+	for _, input := range []string{"mean({})", "variance(5)", "corr({1, 2}, {1, 2, 3})", "quantile({1, 2}, 2)"} {
+		if result := testEval(input); !isError(result) {
+			t.Errorf("%s: expected an error, got %s", input, result.Inspect())
+		}
+	}
+}
+
+func TestDistributions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"normcdf(1.96)", 0.9750021048517795},
+		{"norminv(0.975)", 1.959963984540054},
+		{"norminv(0.000001)", -4.753424308822899},
+		{"normpdf(0)", 1 / math.Sqrt(2*math.Pi)},
+		{"normcdf(110, 100, 15)", 0.7475074624530771},
+		{"binopdf(2, 4, 0.5)", 0.375},
+		{"binocdf(2, 4, 0.5)", 0.6875},
+		{"binoinv(0.5, 10, 0.5)", 5},
+		{"poisspdf(2, 3)", 4.5 * math.Exp(-3)},
+		{"poisscdf(2, 3)", 8.5 * math.Exp(-3)},
+		{"poissinv(0.5, 3)", 3},
+		{"tcdf(2, 5)", 0.9490302605850709},
+		{"tinv(0.975, 10)", 2.228138851986274},
+		{"tpdf(0, 1)", 1 / math.Pi},
+		{"chi2cdf(2, 2)", 1 - math.Exp(-1)},
+		{"chi2inv(0.95, 1)", 3.841458820694124},
+		{"fcdf(3, 2, 10)", 0.904632568359375},
+		{"finv(0.95, 2, 10)", 4.102821015130399},
+		{"expcdf(1)", 1 - math.Exp(-1)},
+		{"expinv(0.5, 2)", 2 * math.Ln2},
+		{"unifcdf(0.3)", 0.3},
+		{"gamma(5)", 24},
+		{"betainc(0.5, 2, 3)", 0.6875},
+	}
+	for _, tt := range tests {
+		result, ok := toFloat(testEval(tt.input))
+		if !ok || math.Abs(result-tt.expected) > 1e-12*math.Max(1, math.Abs(tt.expected)) {
+			t.Errorf("%s: expected %v got %s", tt.input, tt.expected, testEval(tt.input).Inspect())
+		}
+	}
+
+	for _, input := range []string{"normcdf(1, 0, -1)", "binopdf(2)", "norminv(1.5)", "poisspdf(1, 0)"} {
+		if result := testEval(input); !isError(result) {
+			t.Errorf("%s: expected an error, got %s", input, result.Inspect())
+		}
+	}
+}
+
+func TestCombinatorics(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"25!", "15511210043330985984000000"},
+		{"5.0!", "120"},
+		{"0!", "1"},
+		{"nCr(50, 25)", "126410606437752"},
+		{"100 choose 50", "100891344545564193334812497256"},
+		{"(-3) choose 2", "6"},
+		{"5 choose 7", "0"},
+		{"nPr(10, 3)", "720"},
+		{"nCr(2^70, 2)", "696898287454081973172400900209902591410176"},
+		{"nPr(2^70, 2)", "1393796574908163946344801800419805182820352"},
+		{"(-(2^70)) choose 1", "-1180591620717411303424"},
+		{"nCr(2^70, 2^69)", "ERROR: binomial: 1180591620717411303424 choose 590295810358705651712 is too large"},
+		{"stirling2(2^70, 2)", "ERROR: stirling2: 1180591620717411303424 is too large"},
+		{"multinomial(2, 3, 4)", "1260"},
+		{"multinomial({1, 1, 1})", "6"},
+		{"stirling1(5, 2)", "-50"},
+		{"stirling2(10, 3)", "9330"},
+		{"catalan(30)", "3814986502092304"},
+		{"sum(5 choose k, k, 0, 5)", "32"},
+	}
+	for _, tt := range tests {
+		if result := testEval(tt.input).Inspect(); result != tt.expected {
+			t.Errorf("%s: expected %s got %s", tt.input, tt.expected, result)
+		}
+	}
+
+	if result, _ := toFloat(testEval("0.5!")); math.Abs(result-math.Sqrt(math.Pi)/2) > 1e-15 {
+		t.Errorf("0.5!: got %v", result)
+	}
+	for _, input := range []string{"(-1)!", "nCr(2.5, 1)", "multinomial(-1, 2)", "stirling2(2000, 3)"} {
+		if result := testEval(input); !isError(result) {
+			t.Errorf("%s: expected an error, got %s", input, result.Inspect())
+		}
+	}
+}
+
+func TestNumberTheory(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"gcd(12, 18, 27)", "3"},
+		{"lcm(4, 6, 10)", "60"},
+		{"gcd(2^100, 6^50)", "1125899906842624"},
+		{"isprime(2^127 - 1)", "1"},
+		{"isprime(561)", "0"},
+		{"nextprime(2^64)", "18446744073709551629"},
+		{"factor(360)", "{{2, 3}, {3, 2}, {5, 1}}"},
+		{"factor(-12)", "{{-1, 1}, {2, 2}, {3, 1}}"},
+		{"factor(2^64 + 1)", "{{274177, 1}, {67280421310721, 1}}"},
+		{"factor(1000000007 * 998244353 * 2^5)", "{{2, 5}, {998244353, 1}, {1000000007, 1}}"},
+		{"factor(x^2 - 1)", "((x + 1) * (x - 1))"},
+		{"totient(36)", "12"},
+		{"powmod(3, 200, 1000007)", "959082"},
+		{"powmod(3, -1, 7)", "5"},
+		{"modinv(3, 7)", "5"},
+	}
+	for _, tt := range tests {
+		if result := testEval(tt.input).Inspect(); result != tt.expected {
+			t.Errorf("%s: expected %s got %s", tt.input, tt.expected, result)
+		}
+	}
+
+	for _, input := range []string{"modinv(2, 4)", "factor(0)", "gcd(1.5, 3)", "powmod(2, 3, 0)", "factor(2^128 + 1)"} {
+		if result := testEval(input); !isError(result) {
+			t.Errorf("%s: expected an error, got %s", input, result.Inspect())
+		}
+	}
+}
+
+func TestRandom(t *testing.T) {
+	env := object.NewEnvironment()
+	eval := func(input string) object.Object {
+		return Eval(parser.New(lexer.New(input)).ParseProgram(), env)
+	}
+	eval("seed(42)")
+	first := eval("randint(1, 6, 20)").Inspect() + eval("rand()").Inspect() + eval("randn()").Inspect()
+	eval("seed(42)")
+	second := eval("randint(1, 6, 20)").Inspect() + eval("rand()").Inspect() + eval("randn()").Inspect()
+	if first != second {
+		t.Errorf("the same seed gave %s and %s", first, second)
+	}
+
+	for i := 0; i < 100; i++ {
+		n := eval("randint(-2, 2)").(*object.Integer).Value
+		if n < -2 || n > 2 {
+			t.Fatalf("randint(-2, 2) gave %d", n)
+		}
+	}
+	if m, _ := toFloat(eval("mean(randn(10, 2, 10000))")); math.Abs(m-10) > 0.1 {
+		t.Errorf("the mean of randn(10, 2, 10000) is %v", m)
+	}
+
+	// impure calls must not be folded or simplified away
+	if pure(parser.New(lexer.New("x + rand()")).ParseProgram()) {
+		t.Errorf("rand() was taken to be pure")
+	}
+	expr := parser.New(lexer.New("rand() - rand() + 2 * 3")).ParseProgram().Statements[0].(*ast.ExpressionStatement).Expression
+	if optimized := optimize(expr, "x", env).String(); optimized != "((rand() - rand()) + 6)" {
+		t.Errorf("rand() - rand() + 2 * 3 was optimized to %s", optimized)
+	}
+	for input, expected := range map[string]string{
+		"simplify(rand() - rand())": "(rand() - rand())",
+		"simplify(rand() + rand())": "(rand() + rand())",
+		"simplify(2 * 3 + rand())":  "(6 + rand())",
+		"expand(rand() - rand())":   "(rand() - rand())",
+		"factor(rand() - rand())":   "ERROR: factor: (rand() - rand()) calls a random function and is not a polynomial",
+	} {
+		if result := eval(input).Inspect(); result != expected {
+			t.Errorf("%s: expected %s got %s", input, expected, result)
+		}
+	}
+	if result := eval("randint(5, 1)"); !isError(result) {
+		t.Errorf("randint(5, 1): expected an error, got %s", result.Inspect())
+	}
+}
+
+func TestFinance(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"pmt(0.05 / 12, 360, 200000)", "-1073.64"},
+		{"fv(0.06 / 12, 120, -100, -1000)", "18207.33"},
+		{"pv(0.08 / 12, 240, 500)", "-59777.15"},
+		{"npv(0.1, -10000, 3000, 4200, 6800)", "1188.44"},
+		{"compound(1000, 0.05, 10, 12)", "1647.01"},
+		{"compound(1000, 0.05, 10, ∞)", "1648.72"},
+		{"round(2.675, 2)", "2.68"},
+		{"round(2.665, 2)", "2.66"},
+		{"round(2.5)", "2"},
+		{"round(0.1, 2) + round(0.2, 2)", "0.30"},
+		{"round(19.99, 2) * 3", "59.97"},
+		{"-round(0.5, 2)", "-0.50"},
+		{"pmt(0.05 / 12, 360, 200000) / 3", "-357.88"},
+		{"round(10.01, 2) / 3", "3.34"},
+		{"round(1.1, 2) ^ 2", "1.2100"},
+		{"round(2.5, 2) ^ -1", "0.40"},
+		{"round(1.05, 2) ^ 3", "1.157625"},
+		{"fv(0.05, 10, -100) + 0.1", "1257.89"},
+		{"0.25 * round(19.99, 2)", "4.9975"},
+		{"round(10.00, 2) / 0.3", "33.33"},
+		{"pmt(0.1, 0, 1000)", "ERROR: pmt: the number of periods must be positive"},
+		{"fv(0.1, 0, -100)", "ERROR: fv: the number of periods must be positive"},
+		{"pv(0.1, -1, 100)", "ERROR: pv: the number of periods must be positive"},
+		{"amortize(0.01, 3, 1000)", "{(period = 1, payment = 340.02, interest = 10.00, principal = 330.02, balance = 669.98), " +
+			"(period = 2, payment = 340.02, interest = 6.70, principal = 333.32, balance = 336.66), " +
+			"(period = 3, payment = 340.03, interest = 3.37, principal = 336.66, balance = 0.00)}"},
+	}
+	for _, tt := range tests {
+		if result := testEval(tt.input).Inspect(); result != tt.expected {
+			t.Errorf("%s: expected %s got %s", tt.input, tt.expected, result)
+		}
+	}
+
+	rates := []struct {
+		input    string
+		expected float64
+	}{
+		{"nper(0.01, -100, 1000)", 10.588644459423241},
+		{"rate(48, -200, 8000)", 0.0077014724882014},
+		{"irr({-70000, 12000, 15000, 18000, 21000, 26000})", 0.08663094803653162},
+	}
+	for _, tt := range rates {
+		result, ok := toFloat(testEval(tt.input))
+		if !ok || math.Abs(result-tt.expected) > 1e-9 {
+			t.Errorf("%s: expected %v got %s", tt.input, tt.expected, testEval(tt.input).Inspect())
+		}
+	}
+
+	for _, input := range []string{"irr({1, 2, 3})", "pmt(0.1, 12, 1000, 0, 2)", "amortize(0.01, 2.5, 1000)"} {
+		if result := testEval(input); !isError(result) {
+			t.Errorf("%s: expected an error, got %s", input, result.Inspect())
+		}
+	}
+}
+
+func TestDates(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"2026-10-18 + 90 days", "2027-01-16"},
+		{"90 days + 2026-10-18", "2027-01-16"},
+		{"days_between(2026-01-01, 2026-10-18)", "290"},
+		{"days_between(2026-10-18T23:00, 2026-10-19T01:00)", "1"},
+		{"2026-10-18 - 2026-01-01", "290 day"},
+		{"2026-01-31 + P1M", "2026-02-28"},
+		{"2024-01-31 + 1 month", "2024-02-29"},
+		{"2024-02-29 + P1Y", "2025-02-28"},
+		{"2026-03-01 - P1D", "2026-02-28"},
+		{"2026-01-15 - P1M", "2025-12-15"},
+		{"2026-10-18T14:30 + PT12H", "2026-10-19T02:30"},
+		{"2026-10-18 + PT90S", "2026-10-18T00:01:30"},
+		{"P1Y2M10DT2H30M", "P1Y2M10DT2H30M"},
+		{"P1D + 36 h", "P2DT12H"},
+		{"3 * P1W", "P21D"},
+		{"-P3D", "-P3D"},
+		{"P1D / PT1H", "24"},
+		{"P1DT2H to h", "26 h"},
+		{"10-3-1", "6"},
+		{"2024-02-29", "2024-02-29"},
+		{"2026-02-30", "ERROR: invalid date: 2026-02-30"},
+		{"2025-02-29", "ERROR: invalid date: 2025-02-29"},
+		{"2026-02-30 + 1 day", "ERROR: invalid date: 2026-02-30"},
+		{"2026-04-31T10:00 - 2026-01-01", "ERROR: invalid date: 2026-04-31T10:00"},
+	}
+	for _, tt := range tests {
+		if result := testEval(tt.input).Inspect(); result != tt.expected {
+			t.Errorf("%s: expected %s got %s", tt.input, tt.expected, result)
+		}
+	}
+
+	for _, input := range []string{"2026-10-18 + 90", "2026-10-18 + 1.5 months", "2026-10-18 * 2", "P1M / P1D"} {
+		if result := testEval(input); !isError(result) {
+			t.Errorf("%s: expected an error, got %s", input, result.Inspect())
+		}
+	}
+}
+
+func TestIntervals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"interval(1 + 2)", "[3 .. 3]"},
+		{"interval(0.1 + 0.2)", "[0.29999999999999993 .. 0.30000000000000004]"},
+		{"interval(1 / interval(2, 4))", "[0.25 .. 0.5]"},
+		{"interval(interval(-2, 3)^2)", "[0 .. 9]"},
+		{"interval(interval(-2, 3)^3)", "[-8 .. 27]"},
+		{"interval(1 / interval(-1, 1))", "[-Inf .. +Inf]"},
+		{"interval(√4)", "[2 .. 2]"},
+		{"interval(sin(interval(80, 100)))", "[0.984807753012204 .. 1]"},
+		{"interval(cos(interval(170, 190)))", "[-1 .. -0.984807753012204]"},
+		{"interval(sin(interval(0, 1000)))", "[-1 .. 1]"},
+		{"interval(tan(interval(80, 100)))", "[-Inf .. +Inf]"},
+		{"-interval(1, 2)", "[-2 .. -1]"},
+		{"interval(1.5E3)", "[1500 .. 1500]"},
+		{"interval(10!)", "[3.6288e+06 .. 3.6288e+06]"},
+		{"interval(mean(1, 2, 3))", "[2 .. 2]"},
+		{"interval(mean({1, 2, 3}))", "[2 .. 2]"},
+		{"interval(gamma(5))", "[24 .. 24]"},
+		{"width(interval(1, 2))", "1"},
+		{"interval(gamma(interval(1, 2)))", "ERROR: gamma: not supported on intervals such as [1 .. 2] in interval mode"},
+		{"interval(besselj(0, interval(1, 2)))", "ERROR: besselj: not supported on intervals such as [1 .. 2] in interval mode"},
+		{"interval(mean(0.1, 0.2))", "ERROR: mean: not supported on intervals such as [0.09999999999999999 .. 0.1] in interval mode"},
+	}
+	for _, tt := range tests {
+		if result := testEval(tt.input).Inspect(); result != tt.expected {
+			t.Errorf("%s: expected %s got %s", tt.input, tt.expected, result)
+		}
+	}
+
+	// the bounds hold the exact values
+	enclosures := []struct {
+		input string
+		exact float64
+	}{
+		{"interval(pi)", math.Pi},
+		{"interval(1/3)", 1.0 / 3},
+		{"interval(√2)", math.Sqrt2},
+		{"interval(2^0.5)", math.Sqrt2},
+		{"interval(sin(30))", 0.5},
+		{"interval(tan(45))", 1},
+		{"interval(ln(e))", 1},
+		{"interval(log(1000))", 3},
+		{"interval(arccos(0.5))", 60},
+		{"interval(arctan(1))", 45},
+		{"interval(sin(10^10))", math.Sin(280 * math.Pi / 180)},
+	}
+	for _, tt := range enclosures {
+		x, ok := testEval(tt.input).(*object.Interval)
+		if !ok || x.Lo > tt.exact || x.Hi < tt.exact || x.Hi-x.Lo > 1e-12 {
+			t.Errorf("%s: expected a tight interval around %v, got %s", tt.input, tt.exact, testEval(tt.input).Inspect())
+		}
+	}
+	// the other builtins are widened by builtinError
+	if x, ok := testEval("interval(besselj(0, 1))").(*object.Interval); !ok || x.Lo > 0.7651976865579665514 || x.Hi < 0.7651976865579665514 {
+		t.Errorf("interval(besselj(0, 1)): expected an interval around 0.765197686557966, got %s", testEval("interval(besselj(0, 1))").Inspect())
+	}
+
+	env := object.NewEnvironment()
+	env.SetIntervalMode(true)
+	if result := Eval(parser.New(lexer.New("0.1 * 3")).ParseProgram(), env); result.Type() != object.INTERVAL_OBJ {
+		t.Errorf("0.1 * 3 in interval mode: expected an interval, got %s", result.Inspect())
+	}
+	if result := testEval("0.1 * 3"); result.Type() != object.FLOAT_OBJ {
+		t.Errorf("0.1 * 3: expected a float, got %s", result.Inspect())
+	}
+
+	for _, input := range []string{"interval(ln(-1))", "interval(arcsin(2))", "interval(2, 1)", "interval(1 / interval(0, 0))", "interval(interval(-2, -1)^0.5)", "interval(2 m)"} {
+		if result := testEval(input); !isError(result) {
+			t.Errorf("%s: expected an error, got %s", input, result.Inspect())
+		}
+	}
+}
+
+func TestUncertainty(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"9.81 ± 0.02", "9.81 ± 0.02"},
+		{"9.81 +/- 0.02", "9.81 ± 0.02"},
+		{"(9.81 ± 0.02) * 2", "19.62 ± 0.04"},
+		{"(1 ± 0.1) + (2 ± 0.2)", "3.00 ± 0.22"},
+		{"(10 ± 1) / (5 ± 0.5)", "2.00 ± 0.28"},
+		{"(2 ± 0.1)^2", "4.0 ± 0.4"},
+		{"2^(3 ± 0.1)", "8.00 ± 0.55"},
+		{"sin(30 ± 1)", "0.500 ± 0.015"},
+		{"ln(2 ± 0.1)", "0.693 ± 0.050"},
+		{"gamma(2.5 ± 0.1)", "1.329 ± 0.093"},
+		{"-(3 ± 0.4)", "-3.0 ± 0.4"},
+		{"1.5 ± 2", "1.5 ± 2.0"},
+	}
+	for _, tt := range tests {
+		if result := testEval(tt.input).Inspect(); result != tt.expected {
+			t.Errorf("%s: expected %s got %s", tt.input, tt.expected, result)
+		}
+	}
+
+	// a value used twice is correlated with itself
+	env := object.NewEnvironment()
+	env.Set("x", testEval("2 ± 0.1"))
+	eval := func(input string) object.Object {
+		return Eval(parser.New(lexer.New(input)).ParseProgram(), env)
+	}
+	if u, ok := eval("x - x").(*object.Uncertain); !ok || u.Value != 0 || u.Sigma() != 0 {
+		t.Errorf("x - x: expected 0 ± 0, got %s", eval("x - x").Inspect())
+	}
+	if u, ok := eval("x * x").(*object.Uncertain); !ok || math.Abs(u.Sigma()-0.4) > 1e-12 {
+		t.Errorf("x * x: expected 4.0 ± 0.4, got %s", eval("x * x").Inspect())
+	}
+
+	eval("seed(1)")
+	u, ok := eval("montecarlo((1 ± 0.1) * (2 ± 0.2), 100000)").(*object.Uncertain)
+	if !ok || math.Abs(u.Value-2) > 0.01 || math.Abs(u.Sigma()-math.Sqrt(0.08)) > 0.01 {
+		t.Errorf("montecarlo: expected about 2.00 ± 0.28, got %v", u)
+	}
+
+	for _, input := range []string{"1 ± -1", "(-1 ± 0.1)^(2 ± 0.1)", "montecarlo(1 ± 0.1, 1)"} {
+		if result := testEval(input); !isError(result) {
+			t.Errorf("%s: expected an error, got %s", input, result.Inspect())
+		}
+	}
+	testInspect(t, "(1 ± 0.1) m", "ERROR: ± values with units are not supported, got (1.0 ± 0.1) * (1 m)")
+	testInspect(t, "2 m / (4 ± 0.2)", "ERROR: ± values with units are not supported, got (2 m) / (4.0 ± 0.2)")
+	testInspect(t, "round(1.234 ± 0.1, 2)", "ERROR: round: a step function drops the uncertainty of 1.23 ± 0.10")
+	testInspect(t, "nCr(5 ± 1, 2)", "ERROR: nCr: no derivative at 5 ± 1 to propagate its uncertainty")
+}
+
+func TestElementary(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"sinh(1)", 1.1752011936438014},
+		{"cosh(1)", 1.5430806348152437},
+		{"tanh(0.5)", 0.46211715726000974},
+		{"arcsinh(1)", 0.881373587019543},
+		{"arccosh(2)", 1.3169578969248166},
+		{"arctanh(0.5)", 0.5493061443340549},
+		{"sec(60)", 2},
+		{"csc(30)", 2},
+		{"cot(45)", 1},
+		{"arcsec(2)", 60},
+		{"arccsc(-2)", -30},
+		{"arccot(-1)", 135},
+		{"exp(1)", math.E},
+		{"log2(1024)", 10},
+		{"logb(81, 3)", 4},
+		{"logb(0.5, 4)", -0.5},
+		{"cbrt(-27)", -3},
+		{"nthroot(32, 5)", 2},
+		{"nthroot(-8, 3)", -2},
+		{"nthroot(4, -2)", 0.5},
+		{"expm1(0.000001)", 1.0000005000001665e-06},
+		{"log1p(0.000001)", 9.999995000003334e-07},
+		{"erf(1)", 0.8427007929497149},
+		{"erfc(1)", 0.15729920705028513},
+	}
+	for _, tt := range tests {
+		result, ok := toFloat(testEval(tt.input))
+		if !ok || math.Abs(result-tt.expected) > 1e-12 {
+			t.Errorf("%s: expected %v got %s", tt.input, tt.expected, testEval(tt.input).Inspect())
+		}
+	}
+
+	for _, input := range []string{"arccosh(0.5)", "arctanh(1)", "sec(90)", "csc(-180)", "cot(0)",
+		"arcsec(0.5)", "arccsc(0)", "exp(1000)", "log2(0)", "log1p(-1)", "logb(8, 1)", "logb(-8, 2)",
+		"nthroot(-16, 4)", "nthroot(8, 0)", "nthroot(8, 1.5)", "ln(0)", "log(-2)", "arccos(1.5)", "ln(-1 ± 0.1)"} {
+		if result := testEval(input); !isError(result) {
+			t.Errorf("%s: expected an error, got %s", input, result.Inspect())
+		}
+	}
+	testInspect(t, "ln(-1)", "ERROR: ln: -1 is outside the domain, x > 0")
+	testInspect(t, "√(-1)", "ERROR: √: -1 is outside the domain, x ≥ 0")
+	testInspect(t, "arcsin(2)", "ERROR: arcsin: 2 is outside the domain, |x| ≤ 1")
+
+	// the intervals enclose the values and the uncertainties follow the derivatives
+	for _, input := range []string{"interval(sinh(1))", "interval(cot(45))", "interval(arccot(1))", "interval(erfc(0.5))"} {
+		x, ok := testEval(input).(*object.Interval)
+		value, _ := toFloat(testEval(input[len("interval(") : len(input)-1]))
+		if !ok || x.Lo > value || x.Hi < value {
+			t.Errorf("%s: expected an interval around %v, got %s", input, value, testEval(input).Inspect())
+		}
+	}
+	if result := testEval("exp(2 ± 0.1)").Inspect(); result != "7.39 ± 0.74" {
+		t.Errorf("exp(2 ± 0.1): expected 7.39 ± 0.74 got %s", result)
+	}
+}
+
+func TestSpecialFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"besselj(0, 1)", 0.7651976865579665514},
+		{"besselj(-3, 2)", -0.1289432494744020511},
+		{"bessely(0, 1)", 0.0882569642156769580},
+		{"bessely(-3, 2)", 1.1277837768404277861},
+		{"besseli(0, 1)", 1.2660658777520083356},
+		{"besseli(3, -2)", -0.2127399592398526553},
+		{"besselk(0, 1)", 0.4210244382407083333},
+		{"besselk(1, 1)", 0.6019072301972345747},
+		{"besselk(3, 50)", 3.7279367738262107e-23},
+		{"airyai(0)", 0.3550280538878172393},
+		{"airyai(1)", 0.1352924163128814155},
+		{"airyai(-20)", -0.1764061270779846896},
+		{"airybi(1)", 1.2074235949528712594},
+		{"airybi(-20)", -0.2001393093226513493},
+		{"zeta(2)", math.Pi * math.Pi / 6},
+		{"zeta(3)", 1.2020569031595942854},
+		{"zeta(0.5)", -1.4603545088095868129},
+		{"zeta(-1)", -1.0 / 12},
+		{"zeta(-2)", 0},
+		{"zeta(-3.5)", 0.0044410113354794320},
+		{"polylog(2, 0.5)", 0.5822405264650125059},
+		{"polylog(2, -1)", -math.Pi * math.Pi / 12},
+		{"polylog(2, 0.9)", 1.2997147230049587252},
+		{"polylog(3, -5)", -3.5375114376186075357},
+		{"polylog(1, 0.5)", math.Ln2},
+		{"polylog(-2, 3)", -1.5},
+		{"ellipk(0.5)", 1.8540746773013719184},
+		{"ellipe(0.5)", 1.3506438810476755025},
+		{"ellipe(1)", 1},
+		{"ellipf(30, 0.5)", 0.5356227328054033197},
+		{"ellipf(-90, 0.5)", -1.8540746773013719184},
+		{"ellipe(30, 0.5)", 0.5120493223504269},
+		{"ellipe(100, 1)", 2 - math.Sin(80*math.Pi/180)},
+		{"ellippi(0.3, 0.5)", 2.250376821943947},
+		{"ellippi(0.3, 30, 0.5)", 0.5504562343273038631},
+		{"lambertw(1)", 0.5671432904097838730},
+		{"lambertw(e)", 1},
+		{"lambertw(-0.2)", -0.2591711018190737},
+		{"lambertw(-0.2, -1)", -2.5426413577735264243},
+	}
+	for _, tt := range tests {
+		result, ok := toFloat(testEval(tt.input))
+		if !ok || math.Abs(result-tt.expected) > 1e-12*math.Abs(tt.expected) || (tt.expected == 0 && result != 0) {
+			t.Errorf("%s: expected %v got %s", tt.input, tt.expected, testEval(tt.input).Inspect())
+		}
+	}
+
+	for _, input := range []string{"besselj(0.5, 1)", "bessely(0, 0)", "besselk(1, -1)", "besseli(1001, 1)",
+		"zeta(1)", "zeta(-301)", "polylog(2, 2)", "polylog(0, 1)", "polylog(0.5, 0.5)", "ellipk(1)",
+		"ellipe(1.5)", "ellipf(30, 5)", "ellipf(180, 1)", "ellippi(1, 0.5)", "lambertw(-0.5)",
+		"lambertw(1, -1)", "lambertw(1, 2)"} {
+		if result := testEval(input); !isError(result) {
+			t.Errorf("%s: expected an error, got %s", input, result.Inspect())
+		}
+	}
+}
+
+func TestPrecision(t *testing.T) {
+	env := object.NewEnvironment()
+	eval := func(input string) object.Object {
+		return Eval(parser.New(lexer.New(input)).ParseProgram(), env)
+	}
+	eval("precision(50)")
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1/3", "0.333333333333333333333333333333333333333333333333"},
+		{"π", "3.141592653589793238462643383279502884197169399375"},
+		{"2^0.5", "1.414213562373095048801688724209698078569671875376"},
+		{"10^-400", "1e-400"},
+		{"zeta(3)", "1.202056903159594285399738161511449990764986292340"},
+		{"zeta(0.5)", "-1.460354508809586812889499152515298012467229331012"},
+		{"polylog(2, 1/2)", "0.582240526465012505902656320159680108744198474806"},
+		{"besselj(0, 1)", "0.765197686557966551449717526102663220909274289755"},
+		{"besselk(0, 1)", "0.421024438240708333335627379212609036136219748226"},
+		{"airyai(0)", "0.355028053887817239260063186004183176397979174199"},
+		{"ellipk(1/2)", "1.854074677301371918433850347195260046217598823521"},
+		{"lambertw(1)", "0.567143290409783872999968662210355549753815787186"},
+	}
+	for _, tt := range tests {
+		result := eval(tt.input)
+		if _, ok := result.(*object.BigFloat); !ok || !strings.HasPrefix(result.Inspect(), tt.expected) {
+			t.Errorf("%s: expected %s got %s", tt.input, tt.expected, result.Inspect())
+		}
+	}
+	if result := eval("6/3"); result.Inspect() != "2" {
+		t.Errorf("6/3: expected 2 got %s", result.Inspect())
+	}
+	if result := eval("zeta(1)"); !isError(result) {
+		t.Errorf("zeta(1): expected an error, got %s", result.Inspect())
+	}
+
+	for _, input := range []string{"precision(-1)", "precision(61)", "precision(2.5)"} {
+		if result := eval(input); !isError(result) {
+			t.Errorf("%s: expected an error, got %s", input, result.Inspect())
+		}
+	}
+	// far out exponents print without Text's cost, and as Text would
+	eval("precision(20)")
+	if result := eval("(1/3)^1000000").Inspect(); result != "5.5626320991571287923e-477122" {
+		t.Errorf("(1/3)^1000000: expected 5.5626320991571287923e-477122 got %s", result)
+	}
+	for _, input := range []string{"(1/3)^5000", "(-7/3)^4001", "2^5000 / 3", "10^1300 * 1.5"} {
+		b, ok := eval(input).(*object.BigFloat)
+		if !ok {
+			t.Errorf("%s: expected a big float", input)
+			continue
+		}
+		if b.Inspect() != b.Value.Text('g', b.Digits) {
+			t.Errorf("%s: expected %s got %s", input, b.Value.Text('g', b.Digits), b.Inspect())
+		}
+	}
+
+	eval("precision(0)")
+	if _, ok := eval("1/3").(*object.Float); !ok {
+		t.Errorf("precision(0) did not go back to floats")
+	}
+}
+
+func TestMathConstants(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"sqrt2", "1.4142135623730951"},
+		{"√2", "1.4142135623730951"},
+		{"√2 - sqrt2", "0"},
+		{"τ / π", "2"},
+	}
+	for _, tt := range tests {
+		testInspect(t, tt.input, tt.expected)
+	}
+}
+
+func TestConstantUncertainty(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"uncertainty(G)", "1.5e-15 m^3/kg/s^2"},
+		{"uncertainty(m_e)", "2.8e-40 kg"},
+		{"uncertainty(c)", "0 m/s"},
+		{"uncertainty(pi)", "0"},
+		{"uncertainty(2 ± 0.1)", "0.1"},
+		{"uncertainty(3)", "ERROR: uncertainty: expected a ± value or a constant, got 3"},
+	}
+	for _, tt := range tests {
+		testInspect(t, tt.input, tt.expected)
+	}
+}
+
+func TestUnitNames(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"2 h^2 to s^2", "2.592e+07 s^2"},
+		{"h^2 / h", "6.62607015e-34 J*s"},
+		{"F^2 / F", "96485.33212 C/mol"},
+		{"60 km/h to m/s", "16.666666666666668 m/s"},
+		{"(1 + 2) m", "3 m"},
+		{"solve(m^2 = 4, m)", "[-2, 2]"},
+		{"3 N m to J", "3 J"},
+		{"3 kg m", "3 kg*m"},
+		{"5 km h", "5 km*h"},
+		{"1 in to cm", "2.54 cm"},
+		{"2.54 cm in in", "1 in"},
+		{"3 m in cm", "300 cm"},
+		{"3 to to cm", "ERROR: could not parse the expression starting at 3"},
+	}
+	for _, tt := range tests {
+		testInspect(t, tt.input, tt.expected)
+	}
+
+	for _, input := range []string{"integrate(t^2, t, 0, 1)", "integrate(s^2, s, 0, 1)", "integrate(m^2, m, 0, 1)"} {
+		tuple, ok := testEval(input).(*object.Tuple)
+		if !ok {
+			t.Fatalf("%s: expected a tuple, got %s", input, testEval(input).Inspect())
+		}
+		if value, _ := toFloat(tuple.Get("value")); math.Abs(value-1.0/3) > 1e-9 {
+			t.Errorf("%s: expected %v got %v", input, 1.0/3, value)
+		}
+	}
+	if result, ok := toFloat(testEval("diff(t^3, t, 2)")); !ok || math.Abs(result-12) > 1e-7 {
+		t.Errorf("diff(t^3, t, 2): expected 12 got %s", testEval("diff(t^3, t, 2)").Inspect())
+	}
+}
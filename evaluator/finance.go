@@ -0,0 +1,284 @@
+package evaluator
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/hellracer2007/webCalc/calculator/object"
+)
+
+// maxAmortizationPeriods bounds the rows of an amortization schedule.
+const maxAmortizationPeriods = 10000
+
+// The time value of money functions follow the spreadsheet conventions:
+// rate is the interest rate per period, money paid out is negative and
+// money received positive, and type 1 puts the payments at the start of
+// each period instead of at the end. Amounts of money are rounded to
+// cents.
+
+func init() {
+	builtins["fv"] = financeBuiltin("fv", 3, func(xs []float64) object.Object {
+		if err := checkPeriods("fv", xs[1]); err != nil {
+			return err
+		}
+		return money(futureValue(xs[0], xs[1], xs[2], xs[3], xs[4]))
+	})
+	builtins["pv"] = financeBuiltin("pv", 3, func(xs []float64) object.Object {
+		r, n, pmt, fv, t := xs[0], xs[1], xs[2], xs[3], xs[4]
+		if err := checkPeriods("pv", n); err != nil {
+			return err
+		}
+		if r == 0 {
+			return money(-(fv + pmt*n))
+		}
+		g := math.Pow(1+r, n)
+		return money(-(fv + pmt*(1+r*t)*(g-1)/r) / g)
+	})
+	builtins["pmt"] = financeBuiltin("pmt", 3, func(xs []float64) object.Object {
+		if err := checkPeriods("pmt", xs[1]); err != nil {
+			return err
+		}
+		return money(payment(xs[0], xs[1], xs[2], xs[3], xs[4]))
+	})
+	builtins["nper"] = financeBuiltin("nper", 3, func(xs []float64) object.Object {
+		r, pmt, pv, fv, t := xs[0], xs[1], xs[2], xs[3], xs[4]
+		if r == 0 {
+			if pmt == 0 {
+				return newError("nper: the payment must not be 0")
+			}
+			return number(-(pv + fv) / pmt)
+		}
+		z := pmt * (1 + r*t) / r
+		n := math.Log((z-fv)/(pv+z)) / math.Log1p(r)
+		if !isFinite(n) {
+			return newError("nper: the loan is never paid off")
+		}
+		return number(n)
+	})
+	builtins["rate"] = &object.Builtin{Fn: evalRate}
+	builtins["npv"] = &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) < 2 {
+				return newError("npv: wrong number of arguments. got=%d, want at least 2", len(args))
+			}
+			xs, err := sample("npv", args)
+			if err != nil {
+				return err
+			}
+			if xs[0] <= -1 {
+				return newError("npv: the rate must be greater than -1")
+			}
+			return money(netPresentValue(xs[0], xs[1:], 1))
+		},
+	}
+	builtins["irr"] = &object.Builtin{Fn: evalIRR}
+	builtins["compound"] = &object.Builtin{Fn: evalCompound}
+	builtins["amortize"] = &object.Builtin{Fn: evalAmortize}
+}
+
+// financeBuiltin wraps a function of want numbers followed by the
+// optional future or present value and the payment type, both 0 unless
+// given.
+func financeBuiltin(name string, want int, fn func([]float64) object.Object) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) < want || len(args) > want+2 {
+				return newError("%s: wrong number of arguments. got=%d, want=%d to %d", name, len(args), want, want+2)
+			}
+			xs, err := sample(name, args)
+			if err != nil {
+				return err
+			}
+			for len(xs) < want+2 {
+				xs = append(xs, 0)
+			}
+			if t := xs[want+1]; t != 0 && t != 1 {
+				return newError("%s: the payment type must be 0 or 1, got %v", name, t)
+			}
+			if xs[0] <= -1 {
+				return newError("%s: the rate must be greater than -1", name)
+			}
+			return fn(xs)
+		},
+	}
+}
+
+// checkPeriods rejects a number of periods n ≤ 0, for which the
+// payments divide by zero.
+func checkPeriods(name string, n float64) *object.Error {
+	if n <= 0 {
+		return newError("%s: the number of periods must be positive", name)
+	}
+	return nil
+}
+
+func futureValue(r, n, pmt, pv, t float64) float64 {
+	if r == 0 {
+		return -(pv + pmt*n)
+	}
+	g := math.Pow(1+r, n)
+	return -(pv*g + pmt*(1+r*t)*(g-1)/r)
+}
+
+func payment(r, n, pv, fv, t float64) float64 {
+	if r == 0 {
+		return -(pv + fv) / n
+	}
+	g := math.Pow(1+r, n)
+	return -r * (fv + pv*g) / ((1 + r*t) * (g - 1))
+}
+
+// netPresentValue discounts values[i] over i + first periods.
+func netPresentValue(r float64, values []float64, first int) float64 {
+	sum := 0.0
+	for i, v := range values {
+		sum += v / math.Pow(1+r, float64(i+first))
+	}
+	return sum
+}
+
+// evalRate implements rate(nper, pmt, pv, fv, type, guess), the interest
+// rate per period that makes the cash flows balance.
+func evalRate(args ...object.Object) object.Object {
+	if len(args) < 3 || len(args) > 6 {
+		return newError("rate: wrong number of arguments. got=%d, want=3 to 6", len(args))
+	}
+	xs, err := sample("rate", args)
+	if err != nil {
+		return err
+	}
+	xs = append(xs, []float64{0, 0, 0.1}[len(xs)-3:]...)
+	n, pmt, pv, fv, t := xs[0], xs[1], xs[2], xs[3], xs[4]
+	if err := checkPeriods("rate", n); err != nil {
+		return err
+	}
+	f := func(r float64) (float64, *object.Error) {
+		return futureValue(r, n, pmt, pv, t) - fv, nil
+	}
+	return solveRate("rate", f, xs[5])
+}
+
+// evalIRR implements irr(values) and irr(values, guess), the rate at which
+// the net present value of cash flows in consecutive periods is zero.
+func evalIRR(args ...object.Object) object.Object {
+	if len(args) != 1 && len(args) != 2 {
+		return newError("irr: wrong number of arguments. got=%d, want=1 or 2", len(args))
+	}
+	values, err := sample("irr", args[:1])
+	if err != nil {
+		return err
+	}
+	guess := 0.1
+	if len(args) == 2 {
+		g, err := sample("irr", args[1:])
+		if err != nil {
+			return err
+		}
+		guess = g[0]
+	}
+	positive, negative := false, false
+	for _, v := range values {
+		positive = positive || v > 0
+		negative = negative || v < 0
+	}
+	if !positive || !negative {
+		return newError("irr: the cash flows need both signs")
+	}
+	f := func(r float64) (float64, *object.Error) {
+		return netPresentValue(r, values, 0), nil
+	}
+	return solveRate("irr", f, guess)
+}
+
+// solveRate finds a root of f above -1, first by Newton's method from the
+// guess, then by scanning for a change of sign.
+func solveRate(fn string, f realFunc, guess float64) object.Object {
+	const lo, hi = -1 + 1e-9, 1e3
+	if r, err := newton(f, guess, lo, hi); err == nil {
+		if fr, _ := f(r); isFinite(fr) {
+			return number(r)
+		}
+	}
+	a := lo
+	fa, _ := f(a)
+	for b := -0.99; b <= hi; b = b + 0.01 + math.Abs(b)*0.1 {
+		fb, _ := f(b)
+		if isFinite(fa) && isFinite(fb) && fa*fb <= 0 {
+			r, err := brent(f, a, b, fa, fb)
+			if err != nil {
+				return err
+			}
+			return number(r)
+		}
+		a, fa = b, fb
+	}
+	return newError("%s: no rate balances the cash flows", fn)
+}
+
+// evalCompound implements compound(principal, rate, years, n), the
+// principal grown at the yearly rate compounded n times a year, once
+// unless given and continuously for n = ∞.
+func evalCompound(args ...object.Object) object.Object {
+	if len(args) != 3 && len(args) != 4 {
+		return newError("compound: wrong number of arguments. got=%d, want=3 or 4", len(args))
+	}
+	xs, err := sample("compound", args)
+	if err != nil {
+		return err
+	}
+	p, r, years, n := xs[0], xs[1], xs[2], 1.0
+	if len(xs) == 4 {
+		n = xs[3]
+	}
+	if n <= 0 {
+		return newError("compound: the periods per year must be positive")
+	}
+	if math.IsInf(n, 1) {
+		return money(p * math.Exp(r*years))
+	}
+	return money(p * math.Pow(1+r/n, n*years))
+}
+
+// evalAmortize implements amortize(rate, nper, pv), the schedule paying
+// off the loan pv in nper equal payments. Every row lists the period,
+// the payment, its interest and principal parts and the balance left,
+// all in cents; the last payment absorbs the rounding.
+func evalAmortize(args ...object.Object) object.Object {
+	if len(args) != 3 {
+		return newError("amortize: wrong number of arguments. got=%d, want=3", len(args))
+	}
+	xs, err := sample("amortize", args)
+	if err != nil {
+		return err
+	}
+	r, n, pv := xs[0], xs[1], xs[2]
+	if n <= 0 || n != math.Trunc(n) || n > maxAmortizationPeriods {
+		return newError("amortize: the number of periods must be a whole number from 1 to %d", maxAmortizationPeriods)
+	}
+	if r <= -1 {
+		return newError("amortize: the rate must be greater than -1")
+	}
+	rate := toRat(&object.Float{Value: r})
+	balance := toRat(money(pv))
+	pay := toRat(money(-payment(r, n, pv, 0, 0)))
+	rows := make([]object.Object, int(n))
+	for i := range rows {
+		interest := toRat(roundRat(new(big.Rat).Mul(balance, rate), 2))
+		principal := new(big.Rat).Sub(pay, interest)
+		if i == len(rows)-1 {
+			principal.Set(balance)
+		}
+		balance = new(big.Rat).Sub(balance, principal)
+		rows[i] = &object.Tuple{
+			Names: []string{"period", "payment", "interest", "principal", "balance"},
+			Elements: []object.Object{
+				&object.Integer{Value: int64(i + 1)},
+				roundRat(new(big.Rat).Add(interest, principal), 2),
+				roundRat(interest, 2),
+				roundRat(principal, 2),
+				roundRat(balance, 2),
+			},
+		}
+	}
+	return &object.List{Elements: rows}
+}
@@ -0,0 +1,205 @@
+package evaluator
+
+import (
+	"math"
+
+	"github.com/hellracer2007/webCalc/calculator/object"
+)
+
+const (
+	incompleteMaxIter = 10000
+	incompleteEps     = 1e-15
+	// tiny stands in for zero denominators in Lentz's method
+	tiny = 1e-300
+)
+
+func init() {
+	builtins["gamma"] = numericBuiltin("gamma", 1, func(xs []float64) object.Object {
+		if xs[0] <= 0 && xs[0] == math.Trunc(xs[0]) {
+			return newError("gamma: not defined for %v", xs[0])
+		}
+		return number(math.Gamma(xs[0]))
+	})
+	builtins["beta"] = numericBuiltin("beta", 2, func(xs []float64) object.Object {
+		if xs[0] <= 0 || xs[1] <= 0 {
+			return newError("beta: the arguments must be positive")
+		}
+		return number(math.Exp(lbeta(xs[0], xs[1])))
+	})
+	builtins["gammainc"] = numericBuiltin("gammainc", 2, func(xs []float64) object.Object {
+		if xs[0] < 0 || xs[1] <= 0 {
+			return newError("gammainc: needs x >= 0 and a > 0")
+		}
+		return number(gammaP(xs[1], xs[0]))
+	})
+	builtins["betainc"] = numericBuiltin("betainc", 3, func(xs []float64) object.Object {
+		if xs[0] < 0 || xs[0] > 1 || xs[1] <= 0 || xs[2] <= 0 {
+			return newError("betainc: needs 0 <= x <= 1, a > 0 and b > 0")
+		}
+		return number(betaI(xs[0], xs[1], xs[2]))
+	})
+}
+
+// numericBuiltin wraps a function of n plain numbers.
+func numericBuiltin(name string, n int, fn func([]float64) object.Object) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != n {
+				return newError("%s: wrong number of arguments. got=%d, want=%d", name, len(args), n)
+			}
+			xs := make([]float64, n)
+			for i, arg := range args {
+				f, ok := toFloat(arg)
+				if !ok {
+					return newError("%s: expected numbers, got %s", name, arg.Inspect())
+				}
+				xs[i] = f
+			}
+			return fn(xs)
+		},
+	}
+}
+
+// finite returns y as a number, an error when it overflowed or is not
+// defined.
+func finite(name string, y float64) object.Object {
+	switch {
+	case math.IsNaN(y):
+		return newError("%s: not defined for these arguments", name)
+	case math.IsInf(y, 0):
+		return newError("%s: the result overflows", name)
+	}
+	return number(y)
+}
+
+func lbeta(a, b float64) float64 {
+	la, _ := math.Lgamma(a)
+	lb, _ := math.Lgamma(b)
+	lab, _ := math.Lgamma(a + b)
+	return la + lb - lab
+}
+
+// gammaP is the regularized lower incomplete gamma function P(a, x),
+// gammaQ its complement 1 - P. Below x = a + 1 the power series converges
+// quickly, above it the continued fraction for Q does.
+func gammaP(a, x float64) float64 {
+	switch {
+	case x <= 0:
+		return 0
+	case math.IsInf(x, 1):
+		return 1
+	}
+	if x < a+1 {
+		return gammaSeries(a, x)
+	}
+	return 1 - gammaFraction(a, x)
+}
+
+func gammaQ(a, x float64) float64 {
+	switch {
+	case x <= 0:
+		return 1
+	case math.IsInf(x, 1):
+		return 0
+	}
+	if x < a+1 {
+		return 1 - gammaSeries(a, x)
+	}
+	return gammaFraction(a, x)
+}
+
+// gammaPrefix is x^a e^-x / Γ(a), computed in logarithms.
+func gammaPrefix(a, x float64) float64 {
+	lg, _ := math.Lgamma(a)
+	return math.Exp(a*math.Log(x) - x - lg)
+}
+
+func gammaSeries(a, x float64) float64 {
+	term := 1 / a
+	sum := term
+	for n := 1; n < incompleteMaxIter; n++ {
+		term *= x / (a + float64(n))
+		sum += term
+		if math.Abs(term) < math.Abs(sum)*incompleteEps {
+			break
+		}
+	}
+	return sum * gammaPrefix(a, x)
+}
+
+// gammaFraction evaluates the continued fraction for Q(a, x) with the
+// modified Lentz method.
+func gammaFraction(a, x float64) float64 {
+	b := x + 1 - a
+	c := 1 / tiny
+	d := 1 / b
+	h := d
+	for n := 1; n < incompleteMaxIter; n++ {
+		an := -float64(n) * (float64(n) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < incompleteEps {
+			break
+		}
+	}
+	return gammaPrefix(a, x) * h
+}
+
+// betaI is the regularized incomplete beta function I_x(a, b). The
+// continued fraction converges for x below (a + 1) / (a + b + 2), larger
+// x use the symmetry I_x(a, b) = 1 - I_{1-x}(b, a).
+func betaI(x, a, b float64) float64 {
+	switch {
+	case x <= 0:
+		return 0
+	case x >= 1:
+		return 1
+	}
+	prefix := math.Exp(a*math.Log(x) + b*math.Log1p(-x) - lbeta(a, b))
+	if x < (a+1)/(a+b+2) {
+		return prefix * betaFraction(x, a, b) / a
+	}
+	return 1 - prefix*betaFraction(1-x, b, a)/b
+}
+
+func betaFraction(x, a, b float64) float64 {
+	c := 1.0
+	d := 1 - (a+b)*x/(a+1)
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	h := d
+	step := func(an float64) float64 {
+		d = 1 + an*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		return d * c
+	}
+	for m := 1; m < incompleteMaxIter; m++ {
+		fm := float64(m)
+		h *= step(fm * (b - fm) * x / ((a + 2*fm - 1) * (a + 2*fm)))
+		delta := step(-(a + fm) * (a + b + fm) * x / ((a + 2*fm) * (a + 2*fm + 1)))
+		h *= delta
+		if math.Abs(delta-1) < incompleteEps {
+			break
+		}
+	}
+	return h
+}
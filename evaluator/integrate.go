@@ -0,0 +1,179 @@
+package evaluator
+
+import (
+	"math"
+
+	"github.com/hellracer2007/webCalc/calculator/ast"
+	"github.com/hellracer2007/webCalc/calculator/object"
+)
+
+// Nodes and weights of the 15 point Kronrod rule and its embedded 7 point
+// Gauss rule, the Gauss nodes are the odd entries of xgk.
+var (
+	xgk = [8]float64{
+		0.991455371120812639206854697526329, 0.949107912342758524526189684047851,
+		0.864864423359769072789712788640926, 0.741531185599394439863864773280788,
+		0.586087235467691130294144845693013, 0.405845151377397166906606412076961,
+		0.207784955007898467600689403773245, 0,
+	}
+	wgk = [8]float64{
+		0.022935322010529224963732008058970, 0.063092092629978553290700663189204,
+		0.104790010322250183839876322541518, 0.140653259715525918745189590510238,
+		0.169004726639267902826583426598550, 0.190350578064785409913256402421014,
+		0.204432940075298892414161999234649, 0.209482141084727828012999174891714,
+	}
+	wg = [4]float64{
+		0.129484966168869693270611432679082, 0.279705391489276667901467771423780,
+		0.381830050505118944950369775488975, 0.417959183673469387755102040816327,
+	}
+)
+
+const (
+	quadAbsTol      = 1e-10
+	quadRelTol      = 1e-10
+	quadMaxSegments = 500
+)
+
+// evalIntegrate implements integrate(expr, var, a, b). The result holds
+// the value and the estimated absolute error.
+func evalIntegrate(node *ast.CallExpression, env *object.Environment) object.Object {
+	if err := checkArgs(node, 4); err != nil {
+		return err
+	}
+	name, err := variableName("integrate", node.Arguments[1])
+	if err != nil {
+		return err
+	}
+	a, err := evalNumber("integrate", node.Arguments[2], env)
+	if err != nil {
+		return err
+	}
+	b, err := evalNumber("integrate", node.Arguments[3], env)
+	if err != nil {
+		return err
+	}
+	f := bindReal("integrate", node.Arguments[0], name, env)
+	value, estimate, err := integrate(f, a, b)
+	if err != nil {
+		return err
+	}
+	return &object.Tuple{
+		Names:    []string{"value", "error"},
+		Elements: []object.Object{normalizeNumber(&object.Float{Value: value}), &object.Float{Value: estimate}},
+	}
+}
+
+func integrate(f realFunc, a, b float64) (float64, float64, *object.Error) {
+	if math.IsNaN(a) || math.IsNaN(b) {
+		return 0, 0, newError("integrate: bounds must be numbers")
+	}
+	if a == b {
+		return 0, 0, nil
+	}
+	if a > b {
+		value, estimate, err := integrate(f, b, a)
+		return -value, estimate, err
+	}
+	// infinite ranges are mapped onto finite ones
+	switch {
+	case math.IsInf(a, -1) && math.IsInf(b, 1):
+		return adaptive(func(t float64) (float64, *object.Error) {
+			y, err := f(t / (1 - t*t))
+			return y * (1 + t*t) / ((1 - t*t) * (1 - t*t)), err
+		}, -1, 1)
+	case math.IsInf(b, 1):
+		return adaptive(func(t float64) (float64, *object.Error) {
+			y, err := f(a + t/(1-t))
+			return y / ((1 - t) * (1 - t)), err
+		}, 0, 1)
+	case math.IsInf(a, -1):
+		return adaptive(func(t float64) (float64, *object.Error) {
+			y, err := f(b - t/(1-t))
+			return y / ((1 - t) * (1 - t)), err
+		}, 0, 1)
+	}
+	return adaptive(f, a, b)
+}
+
+type segment struct {
+	a, b     float64
+	value    float64
+	estimate float64
+}
+
+// adaptive bisects the segment with the largest error estimate until the
+// total estimate is within tolerance.
+func adaptive(f realFunc, a, b float64) (float64, float64, *object.Error) {
+	first, err := gaussKronrod(f, a, b)
+	if err != nil {
+		return 0, 0, err
+	}
+	segments := []segment{first}
+	for {
+		value, estimate := 0.0, 0.0
+		worst := 0
+		for i, s := range segments {
+			value += s.value
+			estimate += s.estimate
+			if s.estimate > segments[worst].estimate {
+				worst = i
+			}
+		}
+		if !isFinite(value) || !isFinite(estimate) {
+			return 0, 0, newError("integrate: the integral diverges")
+		}
+		if estimate <= math.Max(quadAbsTol, quadRelTol*math.Abs(value)) {
+			return value, estimate, nil
+		}
+		if len(segments) >= quadMaxSegments {
+			return 0, 0, newError("integrate: the integral does not converge (estimated error %g)", estimate)
+		}
+		s := segments[worst]
+		mid := s.a + (s.b-s.a)/2
+		if mid <= s.a || mid >= s.b {
+			return 0, 0, newError("integrate: the integral does not converge (estimated error %g)", estimate)
+		}
+		left, err := gaussKronrod(f, s.a, mid)
+		if err != nil {
+			return 0, 0, err
+		}
+		right, err := gaussKronrod(f, mid, s.b)
+		if err != nil {
+			return 0, 0, err
+		}
+		segments[worst] = left
+		segments = append(segments, right)
+	}
+}
+
+func gaussKronrod(f realFunc, a, b float64) (segment, *object.Error) {
+	center := (a + b) / 2
+	half := (b - a) / 2
+	fc, err := f(center)
+	if err != nil {
+		return segment{}, err
+	}
+	kronrod := fc * wgk[7]
+	gauss := fc * wg[3]
+	for j := 0; j < 7; j++ {
+		x := half * xgk[j]
+		f1, err := f(center - x)
+		if err != nil {
+			return segment{}, err
+		}
+		f2, err := f(center + x)
+		if err != nil {
+			return segment{}, err
+		}
+		kronrod += wgk[j] * (f1 + f2)
+		if j%2 == 1 {
+			gauss += wg[j/2] * (f1 + f2)
+		}
+	}
+	return segment{
+		a:        a,
+		b:        b,
+		value:    kronrod * half,
+		estimate: math.Abs((kronrod - gauss) * half),
+	}, nil
+}
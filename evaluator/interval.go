@@ -0,0 +1,658 @@
+package evaluator
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/hellracer2007/webCalc/calculator/ast"
+	"github.com/hellracer2007/webCalc/calculator/object"
+)
+
+// Go rounds every operation to nearest, so the bounds of an interval are
+// moved outward by hand. The basic operations find the exact error of
+// the rounded result with TwoSum and fused multiply-add and only move a
+// bound when the result was inexact, in the direction of the error. The
+// functions of the math package are correct to about an ulp, their bounds
+// are widened by the error bounds below.
+const (
+	// libm is the relative error allowed for exp, log, sqrt and the
+	// inverse trigonometric functions, four times the unit roundoff.
+	libm = 0x1p-50
+	// underflow is the magnitude below which the error of a product or
+	// quotient may underflow and is no longer exact.
+	underflow = 0x1p-900
+	// trigError bounds the absolute error of sin and cos of an angle in
+	// degrees reduced to [-180, 180]: the conversion to radians is off by
+	// a few roundings of π, the sine by an ulp.
+	trigError = 4e-15
+	// builtinError is the relative error allowed for the results of the
+	// other builtins, the special functions being accurate to about
+	// 1e-13 away from their zeros.
+	builtinError = 0x1p-40
+)
+
+// intervalAware lists the builtins that take intervals as they are.
+var intervalAware = map[string]bool{"lower": true, "upper": true, "width": true}
+
+func init() {
+	builtins["lower"] = intervalBuiltin("lower", func(x *object.Interval) object.Object { return number(x.Lo) })
+	builtins["upper"] = intervalBuiltin("upper", func(x *object.Interval) object.Object { return number(x.Hi) })
+	builtins["width"] = intervalBuiltin("width", func(x *object.Interval) object.Object {
+		_, w := addBounds(x.Hi, -x.Lo)
+		return number(w)
+	})
+}
+
+// intervalBuiltin wraps a function of one interval, a plain number being
+// the interval holding just that number.
+func intervalBuiltin(name string, fn func(*object.Interval) object.Object) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("%s: wrong number of arguments. got=%d, want=1", name, len(args))
+			}
+			x, ok := toInterval(args[0])
+			if !ok {
+				return newError("%s: expected an interval, got %s", name, args[0].Inspect())
+			}
+			return fn(x)
+		},
+	}
+}
+
+func hasInterval(args []object.Object) bool {
+	for _, arg := range args {
+		switch v := arg.(type) {
+		case *object.Interval:
+			return true
+		case *object.Vector:
+			if hasInterval(v.Elements) {
+				return true
+			}
+		case *object.List:
+			if hasInterval(v.Elements) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// pointValues replaces the intervals holding a single number by that
+// number, in vectors and lists too. It returns the first wider interval
+// instead.
+func pointValues(objs []object.Object) ([]object.Object, *object.Interval) {
+	xs := make([]object.Object, len(objs))
+	for i, obj := range objs {
+		switch v := obj.(type) {
+		case *object.Interval:
+			if v.Lo != v.Hi {
+				return nil, v
+			}
+			xs[i] = number(v.Lo)
+		case *object.Vector:
+			els, wide := pointValues(v.Elements)
+			if wide != nil {
+				return nil, wide
+			}
+			xs[i] = &object.Vector{Elements: els}
+		case *object.List:
+			els, wide := pointValues(v.Elements)
+			if wide != nil {
+				return nil, wide
+			}
+			xs[i] = &object.List{Elements: els}
+		default:
+			xs[i] = obj
+		}
+	}
+	return xs, nil
+}
+
+// applyIntervals calls a builtin that knows nothing of intervals. An
+// interval holding a single number, like the 1, 2 and 3 of mean(1, 2, 3)
+// in interval mode, is passed as that number and a fractional result is
+// widened by builtinError. Wider intervals would need the range of the
+// function over them, which only the arithmetic and the procedures such
+// as sin and exp compute.
+func applyIntervals(name string, builtin *object.Builtin, args []object.Object) object.Object {
+	xs, wide := pointValues(args)
+	if wide != nil {
+		return newError("%s: not supported on intervals such as %s in interval mode", name, wide.Inspect())
+	}
+	result := builtin.Fn(xs...)
+	if f, ok := result.(*object.Float); ok && isFinite(f.Value) {
+		lo, hi := enclose(f.Value, 0, builtinError)
+		return &object.Interval{Lo: lo, Hi: hi}
+	}
+	return result
+}
+
+// evalInterval implements interval(expr), which evaluates expr with every
+// number and constant replaced by the smallest interval holding it, so
+// the result carries guaranteed bounds, and interval(a, b), the interval
+// from a to b.
+func evalInterval(node *ast.CallExpression, env *object.Environment) object.Object {
+	if len(node.Arguments) != 1 && len(node.Arguments) != 2 {
+		return newError("interval: wrong number of arguments. got=%d, want=1 or 2", len(node.Arguments))
+	}
+	inner := object.NewEnclosedEnvironment(env)
+	inner.SetIntervalMode(true)
+	args := evalExpressions(node.Arguments, inner)
+	if len(args) == 1 && isError(args[0]) {
+		return args[0]
+	}
+	if len(args) == 1 {
+		if x, ok := toInterval(args[0]); ok {
+			return x
+		}
+		return args[0]
+	}
+	lo, ok := toInterval(args[0])
+	if !ok {
+		return newError("interval: expected numbers, got %s", args[0].Inspect())
+	}
+	hi, ok := toInterval(args[1])
+	if !ok {
+		return newError("interval: expected numbers, got %s", args[1].Inspect())
+	}
+	if lo.Lo > hi.Hi {
+		return newError("interval: the lower bound %v is above the upper bound %v", lo.Lo, hi.Hi)
+	}
+	return &object.Interval{Lo: lo.Lo, Hi: hi.Hi}
+}
+
+// toInterval returns the smallest interval holding a number.
+func toInterval(obj object.Object) (*object.Interval, bool) {
+	switch v := obj.(type) {
+	case *object.Interval:
+		return v, true
+	case *object.Integer:
+		if v.Value >= -1<<53 && v.Value <= 1<<53 {
+			return &object.Interval{Lo: float64(v.Value), Hi: float64(v.Value)}, true
+		}
+		return ratInterval(new(big.Rat).SetInt64(v.Value)), true
+	case *object.Float:
+		return &object.Interval{Lo: v.Value, Hi: v.Value}, true
+	case *object.BigInt, *object.Decimal:
+		return ratInterval(toRat(v)), true
+	}
+	return nil, false
+}
+
+// ratInterval returns the floats next to r.
+func ratInterval(r *big.Rat) *object.Interval {
+	f, exact := r.Float64()
+	switch {
+	case exact:
+		return &object.Interval{Lo: f, Hi: f}
+	case math.IsInf(f, 1):
+		return &object.Interval{Lo: math.MaxFloat64, Hi: f}
+	case math.IsInf(f, -1):
+		return &object.Interval{Lo: f, Hi: -math.MaxFloat64}
+	case new(big.Rat).SetFloat64(f).Cmp(r) < 0:
+		return &object.Interval{Lo: f, Hi: up(f)}
+	}
+	return &object.Interval{Lo: down(f), Hi: f}
+}
+
+// literalInterval encloses a number as written, 0.1 has no exact float.
+func literalInterval(lit string, value float64) *object.Interval {
+	if r, ok := new(big.Rat).SetString(lit); ok {
+		return ratInterval(r)
+	}
+	return constantInterval(value)
+}
+
+// constantInterval encloses a constant known to the nearest float.
+func constantInterval(value float64) *object.Interval {
+	if value == math.Trunc(value) || math.IsInf(value, 0) {
+		return &object.Interval{Lo: value, Hi: value}
+	}
+	return &object.Interval{Lo: down(value), Hi: up(value)}
+}
+
+func down(x float64) float64 { return math.Nextafter(x, math.Inf(-1)) }
+func up(x float64) float64   { return math.Nextafter(x, math.Inf(1)) }
+
+// bracket returns the floats around the exact value r + e, r being the
+// rounded result of an operation and e its error.
+func bracket(r, e float64) (float64, float64) {
+	switch {
+	case math.IsInf(r, 1):
+		return math.MaxFloat64, r
+	case math.IsInf(r, -1):
+		return r, -math.MaxFloat64
+	case e > 0:
+		return r, up(r)
+	case e < 0:
+		return down(r), r
+	}
+	return r, r
+}
+
+// enclose returns bounds for a value computed as r with an error of at
+// most abs + rel |r|.
+func enclose(r, abs, rel float64) (float64, float64) {
+	if math.IsInf(r, 0) {
+		return bracket(r, 0)
+	}
+	e := abs + rel*math.Abs(r)
+	return down(r - e), up(r + e)
+}
+
+func addBounds(a, b float64) (float64, float64) {
+	s := a + b
+	if math.IsInf(a, 0) || math.IsInf(b, 0) {
+		return s, s
+	}
+	bb := s - a
+	return bracket(s, (a-(s-bb))+(b-bb))
+}
+
+func mulBounds(a, b float64) (float64, float64) {
+	if a == 0 || b == 0 {
+		// 0 times an infinite bound is 0
+		return 0, 0
+	}
+	p := a * b
+	switch {
+	case math.IsInf(a, 0) || math.IsInf(b, 0):
+		return p, p
+	case math.Abs(p) < underflow:
+		return down(p), up(p)
+	}
+	return bracket(p, math.FMA(a, b, -p))
+}
+
+func divBounds(a, b float64) (float64, float64) {
+	q := a / b
+	switch {
+	case a == 0 || math.IsInf(a, 0) || math.IsInf(b, 0):
+		return q, q
+	case math.Abs(q) < underflow:
+		return down(q), up(q)
+	}
+	// a - q b has the sign of the error of q when b is positive
+	e := math.FMA(-q, b, a)
+	if b < 0 {
+		e = -e
+	}
+	return bracket(q, e)
+}
+
+func sqrtBounds(x float64) (float64, float64) {
+	r := math.Sqrt(x)
+	switch {
+	case x == 0 || math.IsInf(x, 1):
+		return r, r
+	case x < underflow:
+		return math.Max(down(r), 0), up(r)
+	}
+	return bracket(r, math.FMA(-r, r, x))
+}
+
+// evalIntervalInfixExpression computes the interval of all results of the
+// operator applied to a member of each operand.
+func evalIntervalInfixExpression(operator string, left, right object.Object) object.Object {
+	l, ok := toInterval(left)
+	if !ok {
+		return newError("unsupported operands for %s: %s and %s, intervals only combine with plain numbers", operator, left.Type(), right.Type())
+	}
+	r, ok := toInterval(right)
+	if !ok {
+		return newError("unsupported operands for %s: %s and %s, intervals only combine with plain numbers", operator, left.Type(), right.Type())
+	}
+	switch operator {
+	case "+":
+		lo, _ := addBounds(l.Lo, r.Lo)
+		_, hi := addBounds(l.Hi, r.Hi)
+		return &object.Interval{Lo: lo, Hi: hi}
+	case "-":
+		lo, _ := addBounds(l.Lo, -r.Hi)
+		_, hi := addBounds(l.Hi, -r.Lo)
+		return &object.Interval{Lo: lo, Hi: hi}
+	case "*":
+		return corners(l, r, mulBounds)
+	case "/":
+		if r.Lo <= 0 && r.Hi >= 0 {
+			if r.Lo == 0 && r.Hi == 0 {
+				return newError("division by zero")
+			}
+			if l.Lo == 0 && l.Hi == 0 {
+				return l
+			}
+			return &object.Interval{Lo: math.Inf(-1), Hi: math.Inf(1)}
+		}
+		return corners(l, r, divBounds)
+	case "^":
+		return intervalPower(l, r)
+	case "E":
+		p := intervalPower(&object.Interval{Lo: 10, Hi: 10}, r)
+		if isError(p) {
+			return p
+		}
+		return corners(l, p.(*object.Interval), mulBounds)
+	}
+	return newError("operator %s is not supported for intervals", operator)
+}
+
+// corners applies an operation that is monotonic in each argument to the
+// corners of l × r.
+func corners(l, r *object.Interval, op func(a, b float64) (float64, float64)) *object.Interval {
+	result := &object.Interval{Lo: math.Inf(1), Hi: math.Inf(-1)}
+	for _, a := range []float64{l.Lo, l.Hi} {
+		for _, b := range []float64{r.Lo, r.Hi} {
+			lo, hi := op(a, b)
+			result.Lo = math.Min(result.Lo, lo)
+			result.Hi = math.Max(result.Hi, hi)
+		}
+	}
+	return result
+}
+
+// intervalPower raises l to r. Whole exponents work for every base, even
+// powers of an interval around 0 starting at 0; other exponents need a
+// base that is not negative.
+func intervalPower(l, r *object.Interval) object.Object {
+	if r.Lo == r.Hi && r.Lo == math.Trunc(r.Lo) && math.Abs(r.Lo) <= 1<<62 {
+		n := int64(r.Lo)
+		if n < 0 {
+			return evalIntervalInfixExpression("/", &object.Interval{Lo: 1, Hi: 1}, intervalIntegerPower(l, -n))
+		}
+		return intervalIntegerPower(l, n)
+	}
+	if l.Hi < 0 {
+		return newError("a negative base %s needs a whole exponent", l.Inspect())
+	}
+	base := &object.Interval{Lo: math.Max(l.Lo, 0), Hi: l.Hi}
+	return corners(base, r, func(x, y float64) (float64, float64) {
+		// math.Pow squares for the whole part of y, doubling the
+		// relative error every time
+		return enclose(math.Pow(x, y), 0, libm*(2+math.Abs(y)))
+	})
+}
+
+func intervalIntegerPower(l *object.Interval, n int64) *object.Interval {
+	if n%2 == 0 {
+		lo, hi := math.Abs(l.Lo), math.Abs(l.Hi)
+		if lo > hi {
+			lo, hi = hi, lo
+		}
+		if l.Lo <= 0 && l.Hi >= 0 {
+			lo = 0
+		}
+		a, _ := powBounds(lo, n)
+		_, b := powBounds(hi, n)
+		return &object.Interval{Lo: a, Hi: b}
+	}
+	// odd powers keep the sign and are increasing
+	lo, _ := powBounds(l.Lo, n)
+	_, hi := powBounds(l.Hi, n)
+	return &object.Interval{Lo: lo, Hi: hi}
+}
+
+// powBounds encloses x^n by repeated squaring, for negative x only with
+// odd n.
+func powBounds(x float64, n int64) (float64, float64) {
+	if x < 0 {
+		lo, hi := powBounds(-x, n)
+		return -hi, -lo
+	}
+	lo, hi := 1.0, 1.0
+	blo, bhi := x, x
+	for n > 0 {
+		if n&1 == 1 {
+			lo, _ = mulBounds(lo, blo)
+			_, hi = mulBounds(hi, bhi)
+		}
+		if n >>= 1; n > 0 {
+			blo, _ = mulBounds(blo, blo)
+			_, bhi = mulBounds(bhi, bhi)
+		}
+	}
+	return lo, hi
+}
+
+// intervalProcedures evaluate the procedures on intervals. Monotonic
+// functions map the ends of the interval, the periodic ones also take in
+// the extrema between them.
+var intervalProcedures = map[string]func(*object.Interval) object.Object{
+	"sin": func(x *object.Interval) object.Object { return trigDegrees(x, math.Sin, 90, 270) },
+	"cos": func(x *object.Interval) object.Object { return trigDegrees(x, math.Cos, 0, 180) },
+	"tan": tanDegrees,
+	"√": func(x *object.Interval) object.Object {
+		return monotone("√", x, 0, math.Inf(1), true, sqrtBounds)
+	},
+	"ln": func(x *object.Interval) object.Object {
+		return logarithm("ln", x, math.Log)
+	},
+	"log": func(x *object.Interval) object.Object {
+		return logarithm("log", x, math.Log10)
+	},
+	"arcsin": func(x *object.Interval) object.Object {
+		return monotone("arcsin", x, -1, 1, true, degreeBounds(math.Asin, 0, -90, 90))
+	},
+	"arccos": func(x *object.Interval) object.Object {
+		// math.Acos is π/2 - asin, which cancels near 1
+		return monotone("arccos", x, -1, 1, false, degreeBounds(math.Acos, 1e-13, 0, 180))
+	},
+	"arctan": func(x *object.Interval) object.Object {
+		return monotone("arctan", x, math.Inf(-1), math.Inf(1), true, degreeBounds(math.Atan, 0, -90, 90))
+	},
+	"sinh":    increasing("sinh", math.Sinh),
+	"tanh":    increasing("tanh", math.Tanh),
+	"arcsinh": increasing("arcsinh", math.Asinh),
+	"exp":     increasing("exp", math.Exp),
+	"expm1":   increasing("expm1", math.Expm1),
+	"cbrt":    increasing("cbrt", math.Cbrt),
+	"erf":     increasing("erf", math.Erf),
+	"erfc": func(x *object.Interval) object.Object {
+		return monotone("erfc", x, math.Inf(-1), math.Inf(1), false, libmBounds(math.Erfc))
+	},
+	"cosh": func(x *object.Interval) object.Object {
+		// cosh is even, so it only depends on the magnitude
+		lo, hi := math.Abs(x.Lo), math.Abs(x.Hi)
+		if x.Lo <= 0 && x.Hi >= 0 {
+			lo, hi = 0, math.Max(lo, hi)
+		} else if lo > hi {
+			lo, hi = hi, lo
+		}
+		return monotone("cosh", &object.Interval{Lo: lo, Hi: hi}, 0, math.Inf(1), true, libmBounds(math.Cosh))
+	},
+	"arccosh": func(x *object.Interval) object.Object {
+		return monotone("arccosh", x, 1, math.Inf(1), true, libmBounds(math.Acosh))
+	},
+	"arctanh": func(x *object.Interval) object.Object {
+		if x.Hi <= -1 || x.Lo >= 1 {
+			return newError("arctanh: %s lies outside the domain", x.Inspect())
+		}
+		return monotone("arctanh", x, -1, 1, true, libmBounds(math.Atanh))
+	},
+	"log2": func(x *object.Interval) object.Object {
+		return logarithm("log2", x, math.Log2)
+	},
+	"log1p": func(x *object.Interval) object.Object {
+		if x.Hi <= -1 {
+			return newError("log1p: %s lies outside the domain", x.Inspect())
+		}
+		return monotone("log1p", x, -1, math.Inf(1), true, libmBounds(math.Log1p))
+	},
+	"sec": func(x *object.Interval) object.Object {
+		return reciprocal(trigDegrees(x, math.Cos, 0, 180))
+	},
+	"csc": func(x *object.Interval) object.Object {
+		return reciprocal(trigDegrees(x, math.Sin, 90, 270))
+	},
+	"cot": func(x *object.Interval) object.Object {
+		// cot x = tan(90 - x)
+		return tanDegrees(evalIntervalInfixExpression("-", &object.Interval{Lo: 90, Hi: 90}, x).(*object.Interval))
+	},
+	"arcsec": func(x *object.Interval) object.Object {
+		// arcsec x = arccos(1/x)
+		r, ok := reciprocal(x).(*object.Interval)
+		if !ok {
+			return newError("arcsec: %s lies outside the domain", x.Inspect())
+		}
+		return monotone("arcsec", r, -1, 1, false, degreeBounds(math.Acos, 1e-13, 0, 180))
+	},
+	"arccsc": func(x *object.Interval) object.Object {
+		// arccsc x = arcsin(1/x)
+		r, ok := reciprocal(x).(*object.Interval)
+		if !ok {
+			return newError("arccsc: %s lies outside the domain", x.Inspect())
+		}
+		return monotone("arccsc", r, -1, 1, true, degreeBounds(math.Asin, 0, -90, 90))
+	},
+	"arccot": func(x *object.Interval) object.Object {
+		// arccot x = 90 - arctan x, between 0 and 180
+		return monotone("arccot", x, math.Inf(-1), math.Inf(1), false, degreeBounds(func(v float64) float64 {
+			return math.Pi/2 - math.Atan(v)
+		}, 1e-13, 0, 180))
+	},
+}
+
+// increasing encloses a function increasing on the whole line.
+func increasing(proc string, f func(float64) float64) func(*object.Interval) object.Object {
+	return func(x *object.Interval) object.Object {
+		return monotone(proc, x, math.Inf(-1), math.Inf(1), true, libmBounds(f))
+	}
+}
+
+// libmBounds encloses a function of the math package, which is accurate to
+// a few units in the last place.
+func libmBounds(f func(float64) float64) func(float64) (float64, float64) {
+	return func(v float64) (float64, float64) {
+		return enclose(f(v), 0, libm)
+	}
+}
+
+// reciprocal is 1/x, the whole line when x contains 0.
+func reciprocal(x object.Object) object.Object {
+	if isError(x) {
+		return x
+	}
+	return evalIntervalInfixExpression("/", &object.Interval{Lo: 1, Hi: 1}, x)
+}
+
+// evalIntervalProcedure applies a procedure to an interval.
+func evalIntervalProcedure(proc string, x *object.Interval) object.Object {
+	fn, ok := intervalProcedures[proc]
+	if !ok {
+		return newError("%s is not supported for intervals", proc)
+	}
+	return fn(x)
+}
+
+// monotone applies f, increasing or decreasing on the domain [lo, hi], to
+// the part of x inside the domain. f returns bounds for its value.
+func monotone(proc string, x *object.Interval, lo, hi float64, increasing bool, f func(float64) (float64, float64)) object.Object {
+	a, b := math.Max(x.Lo, lo), math.Min(x.Hi, hi)
+	if a > b {
+		return newError("%s: %s lies outside the domain", proc, x.Inspect())
+	}
+	if !increasing {
+		a, b = b, a
+	}
+	rlo, _ := f(a)
+	_, rhi := f(b)
+	return &object.Interval{Lo: rlo, Hi: rhi}
+}
+
+// logarithm applies ln or log, which go to -∞ at 0.
+func logarithm(proc string, x *object.Interval, f func(float64) float64) object.Object {
+	if x.Hi <= 0 {
+		return newError("%s: %s lies outside the domain", proc, x.Inspect())
+	}
+	return monotone(proc, x, 0, math.Inf(1), true, func(v float64) (float64, float64) {
+		return enclose(f(v), 0, libm)
+	})
+}
+
+// degreeBounds encloses an inverse trigonometric function in degrees,
+// whose values lie between min and max.
+func degreeBounds(f func(float64) float64, abs, min, max float64) func(float64) (float64, float64) {
+	return func(v float64) (float64, float64) {
+		lo, hi := enclose(f(v)*180/math.Pi, abs, 2*libm)
+		return clamp(lo, min, max), clamp(hi, min, max)
+	}
+}
+
+// trigDegrees applies sin or cos, taking in the maximum and the minimum
+// when the interval reaches an angle congruent to maxAt or minAt.
+func trigDegrees(x *object.Interval, f func(float64) float64, maxAt, minAt float64) object.Object {
+	full := &object.Interval{Lo: -1, Hi: 1}
+	if !isFinite(x.Lo) || !isFinite(x.Hi) {
+		return full
+	}
+	a, b, ok := reduceDegrees(x, 360)
+	if !ok {
+		return full
+	}
+	lo1, hi1 := trigBounds(f, x.Lo)
+	lo2, hi2 := trigBounds(f, x.Hi)
+	result := &object.Interval{Lo: math.Max(math.Min(lo1, lo2), -1), Hi: math.Min(math.Max(hi1, hi2), 1)}
+	for k := 0.0; k <= 720; k += 360 {
+		if a <= maxAt+k && maxAt+k <= b {
+			result.Hi = 1
+		}
+		if a <= minAt+k && minAt+k <= b {
+			result.Lo = -1
+		}
+	}
+	return result
+}
+
+// reduceDegrees moves x to start in [0, period), rounding outward. It
+// reports false when x spans a whole period.
+func reduceDegrees(x *object.Interval, period float64) (float64, float64, bool) {
+	_, width := addBounds(x.Hi, -x.Lo)
+	if width >= period {
+		return 0, 0, false
+	}
+	a := math.Mod(x.Lo, period)
+	if a < 0 {
+		a, _ = addBounds(a, period)
+	}
+	_, b := addBounds(a, width)
+	return a, b, true
+}
+
+// trigBounds encloses sin or cos of v degrees. The angle is first reduced
+// exactly to [-180, 180].
+func trigBounds(f func(float64) float64, v float64) (float64, float64) {
+	m := math.Mod(v, 360)
+	if m > 180 {
+		m -= 360
+	} else if m < -180 {
+		m += 360
+	}
+	return enclose(f(m*math.Pi/180), trigError, 0)
+}
+
+// tanDegrees applies tan, which is increasing between its poles at 90 +
+// 180k. An interval around a pole takes in every value.
+func tanDegrees(x *object.Interval) object.Object {
+	all := &object.Interval{Lo: math.Inf(-1), Hi: math.Inf(1)}
+	if !isFinite(x.Lo) || !isFinite(x.Hi) {
+		return all
+	}
+	a, b, ok := reduceDegrees(x, 180)
+	if !ok || (a <= 90 && 90 <= b) || (a <= 270 && 270 <= b) {
+		return all
+	}
+	bounds := func(v float64) (float64, float64) {
+		m := math.Mod(v, 180)
+		if m > 90 {
+			m -= 180
+		} else if m < -90 {
+			m += 180
+		}
+		r := math.Tan(m * math.Pi / 180)
+		// the error of the angle is magnified by the slope 1 + tan²
+		return enclose(r, trigError*(1+r*r), libm)
+	}
+	lo, _ := bounds(x.Lo)
+	_, hi := bounds(x.Hi)
+	return &object.Interval{Lo: lo, Hi: hi}
+}
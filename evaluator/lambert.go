@@ -0,0 +1,169 @@
+package evaluator
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/hellracer2007/webCalc/calculator/object"
+)
+
+// lambertMaxIter bounds Halley's iteration for W, which from the initial
+// guesses below converges in a handful of steps.
+const lambertMaxIter = 100
+
+func init() {
+	builtins["lambertw"] = &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("lambertw: wrong number of arguments. got=%d, want=1 or 2", len(args))
+			}
+			xs, err := sample("lambertw", args)
+			if err != nil {
+				return err
+			}
+			branch, err := lambertBranch(xs[1:])
+			if err != nil {
+				return err
+			}
+			x := xs[0]
+			if err := lambertDomain(fmt.Sprint(x), math.E*x+1, x < 0, branch); err != nil {
+				return err
+			}
+			return finite("lambertw", lambertW(x, branch))
+		},
+	}
+	preciseBuiltins["lambertw"] = func(prec uint, xs []*big.Float) (*big.Float, *object.Error) {
+		if len(xs) != 1 && len(xs) != 2 {
+			return nil, newError("lambertw: wrong number of arguments. got=%d, want=1 or 2", len(xs))
+		}
+		var ks []float64
+		for _, k := range xs[1:] {
+			if !k.IsInt() {
+				return nil, newError("lambertw: the branch must be 0 or -1, got %s", k.Text('g', 10))
+			}
+			f, _ := k.Float64()
+			ks = append(ks, f)
+		}
+		branch, err := lambertBranch(ks)
+		if err != nil {
+			return nil, err
+		}
+		return bigLambertW(xs[0], branch, prec)
+	}
+}
+
+// lambertBranch reads the optional branch of W, 0 or -1.
+func lambertBranch(ks []float64) (int, *object.Error) {
+	if len(ks) == 0 {
+		return 0, nil
+	}
+	if ks[0] != 0 && ks[0] != -1 {
+		return 0, newError("lambertw: the branch must be 0 or -1, got %v", ks[0])
+	}
+	return int(ks[0]), nil
+}
+
+// lambertDomain checks x against the real branches of W: both start at the
+// branch point -1/e, where q = e x + 1 is 0, and W_-1 ends at 0.
+func lambertDomain(x string, q float64, negative bool, branch int) *object.Error {
+	if q < 0 {
+		return newError("lambertw: x must be at least -1/e, got %s", x)
+	}
+	if branch == -1 && !negative {
+		return newError("lambertw: the branch -1 needs -1/e ≤ x < 0, got %s", x)
+	}
+	return nil
+}
+
+// lambertGuess starts Halley's iteration for W(x) with q = e x + 1: the
+// series in p = ±√(2q) near the branch point, ln(1 + x) for moderate x and
+// the asymptotic L1 - L2 + L2/L1 with L1 = ln |x| and L2 = ln |L1| for
+// large x and for W_-1 near 0.
+func lambertGuess(x, q float64, branch int) float64 {
+	if q < 0.3 {
+		p := math.Sqrt(2 * q)
+		if branch == -1 {
+			p = -p
+		}
+		return -1 + p - p*p/3 + 11.0/72*p*p*p
+	}
+	if branch == 0 && x < 3 {
+		return math.Log1p(x)
+	}
+	l1 := math.Log(math.Abs(x))
+	l2 := math.Log(math.Abs(l1))
+	return l1 - l2 + l2/l1
+}
+
+// lambertW is the Lambert W function, the w with w e^w = x, on the
+// principal branch w ≥ -1 or on the branch w ≤ -1 for branch -1.
+func lambertW(x float64, branch int) float64 {
+	q := math.E*x + 1
+	switch {
+	case x == 0 && branch == 0:
+		return 0
+	case q <= 0:
+		return -1
+	}
+	w := lambertGuess(x, q, branch)
+	for i := 0; i < lambertMaxIter; i++ {
+		ew := math.Exp(w)
+		f := w*ew - x
+		next := w - f/(ew*(w+1)-(w+2)*f/(2*w+2))
+		if math.Abs(next-w) <= 1e-15*math.Abs(next) {
+			return next
+		}
+		w = next
+	}
+	return w
+}
+
+// bigLambertW is W(x) like lambertW, iterating from the float guess in big
+// floats. Right at the branch point x is only known to the precision, so
+// that -1/e, rounded either way, is taken as the branch point.
+func bigLambertW(x *big.Float, branch int, prec uint) (*big.Float, *object.Error) {
+	work := prec + guardBits
+	q := newBig(work).Mul(x, bigExp(bigInt(1, work), work))
+	q.Add(q, bigInt(1, work))
+	if magnitude(q) < -int(prec)+4 {
+		q.SetInt64(0)
+	}
+	xf, _ := x.Float64()
+	qf, _ := q.Float64()
+	if err := lambertDomain(x.Text('g', 10), qf, x.Sign() < 0, branch); err != nil {
+		return nil, err
+	}
+	switch {
+	case x.Sign() == 0:
+		return newBig(prec), nil
+	case q.Sign() == 0:
+		return bigInt(-1, prec), nil
+	}
+	var guess float64
+	if math.IsInf(xf, 0) || (xf == 0 && branch == -1) {
+		// beyond the range of floats, where W ≈ L1 - ln |L1|
+		l, _ := bigLog(newBig(work).Abs(x), 64).Float64()
+		guess = l - math.Log(math.Abs(l))
+	} else {
+		guess = lambertGuess(xf, qf, branch)
+	}
+	w := newBig(work).SetFloat64(guess)
+	for i := 0; i < lambertMaxIter; i++ {
+		// Halley's step f / (e^w (w + 1) - (w + 2) f / (2w + 2))
+		ew := bigExp(w, work)
+		f := newBig(work).Mul(w, ew)
+		f.Sub(f, x)
+		w1 := newBig(work).Add(w, bigInt(1, work))
+		d := newBig(work).Add(w1, bigInt(1, work))
+		d.Mul(d, f)
+		d.Quo(d, newBig(work).Add(w1, w1))
+		d.Sub(newBig(work).Mul(ew, w1), d)
+		step := f.Quo(f, d)
+		w.Sub(w, step)
+		if negligible(step, w, work-8) {
+			break
+		}
+	}
+	return newBig(prec).Set(w), nil
+}
@@ -0,0 +1,412 @@
+package evaluator
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/hellracer2007/webCalc/calculator/object"
+)
+
+// newVectorOrMatrix turns a vector whose elements are numeric vectors of
+// the same length into a matrix, [[1, 2], [3, 4]] has two rows.
+func newVectorOrMatrix(elements []object.Object) object.Object {
+	vector := &object.Vector{Elements: elements}
+	if len(elements) == 0 {
+		return vector
+	}
+	var rows [][]float64
+	for _, el := range elements {
+		row, ok := el.(*object.Vector)
+		if !ok {
+			return vector
+		}
+		xs, err := floats("matrix", row)
+		if err != nil {
+			return vector
+		}
+		rows = append(rows, xs)
+	}
+	cols := len(rows[0])
+	if cols == 0 {
+		return vector
+	}
+	m := object.NewMatrix(len(rows), cols)
+	for i, row := range rows {
+		if len(row) != cols {
+			return newError("matrix rows must have the same length: row 1 has %d elements, row %d has %d", cols, i+1, len(row))
+		}
+		copy(m.Data[i*cols:], row)
+	}
+	return m
+}
+
+func evalMatrixInfixExpression(operator string, left, right object.Object) object.Object {
+	switch l := left.(type) {
+	case *object.Matrix:
+		switch r := right.(type) {
+		case *object.Matrix:
+			switch operator {
+			case "+", "-":
+				return elementWise(operator, l, r)
+			case "*":
+				return matMul(l, r)
+			}
+		case *object.Vector:
+			if operator == "*" {
+				return matVec(l, r)
+			}
+		default:
+			if s, ok := toFloat(right); ok {
+				switch operator {
+				case "*":
+					return scaleMatrix(l, s)
+				case "/":
+					return scaleMatrix(l, 1/s)
+				case "^":
+					n, ok := right.(*object.Integer)
+					if !ok {
+						return newError("a matrix can only be raised to an integer power")
+					}
+					return matPow(l, n.Value)
+				}
+			}
+		}
+	case *object.Vector:
+		if r, ok := right.(*object.Matrix); ok && operator == "*" {
+			return vecMat(l, r)
+		}
+	default:
+		if s, ok := toFloat(left); ok && operator == "*" {
+			return scaleMatrix(right.(*object.Matrix), s)
+		}
+	}
+	return newError("unsupported operator %s for %s and %s", operator, left.Type(), right.Type())
+}
+
+func toMatrix(name string, obj object.Object) (*object.Matrix, *object.Error) {
+	m, ok := obj.(*object.Matrix)
+	if !ok {
+		return nil, newError("%s: argument must be a matrix, got %s", name, obj.Type())
+	}
+	return m, nil
+}
+
+func requireSquare(name string, m *object.Matrix) *object.Error {
+	if m.Rows != m.Cols {
+		return newError("%s: matrix must be square, got %dx%d", name, m.Rows, m.Cols)
+	}
+	return nil
+}
+
+func identity(n int) *object.Matrix {
+	m := object.NewMatrix(n, n)
+	for i := 0; i < n; i++ {
+		m.Set(i, i, 1)
+	}
+	return m
+}
+
+func scaleMatrix(m *object.Matrix, s float64) *object.Matrix {
+	result := object.NewMatrix(m.Rows, m.Cols)
+	for i, x := range m.Data {
+		result.Data[i] = x * s
+	}
+	return result
+}
+
+func elementWise(operator string, a, b *object.Matrix) object.Object {
+	if a.Rows != b.Rows || a.Cols != b.Cols {
+		return newError("dimension mismatch: %dx%d %s %dx%d", a.Rows, a.Cols, operator, b.Rows, b.Cols)
+	}
+	result := object.NewMatrix(a.Rows, a.Cols)
+	for i := range a.Data {
+		if operator == "+" {
+			result.Data[i] = a.Data[i] + b.Data[i]
+		} else {
+			result.Data[i] = a.Data[i] - b.Data[i]
+		}
+	}
+	return result
+}
+
+func matMul(a, b *object.Matrix) object.Object {
+	if a.Cols != b.Rows {
+		return newError("dimension mismatch: %dx%d * %dx%d", a.Rows, a.Cols, b.Rows, b.Cols)
+	}
+	result := object.NewMatrix(a.Rows, b.Cols)
+	for i := 0; i < a.Rows; i++ {
+		for j := 0; j < b.Cols; j++ {
+			sum := 0.0
+			for k := 0; k < a.Cols; k++ {
+				sum += a.At(i, k) * b.At(k, j)
+			}
+			result.Set(i, j, sum)
+		}
+	}
+	return result
+}
+
+func matVec(a *object.Matrix, v *object.Vector) object.Object {
+	xs, err := floats("*", v)
+	if err != nil {
+		return err
+	}
+	if a.Cols != len(xs) {
+		return newError("dimension mismatch: %dx%d * vector of length %d", a.Rows, a.Cols, len(xs))
+	}
+	result := make([]float64, a.Rows)
+	for i := range result {
+		for k, x := range xs {
+			result[i] += a.At(i, k) * x
+		}
+	}
+	return newVector(result)
+}
+
+func vecMat(v *object.Vector, a *object.Matrix) object.Object {
+	xs, err := floats("*", v)
+	if err != nil {
+		return err
+	}
+	if a.Rows != len(xs) {
+		return newError("dimension mismatch: vector of length %d * %dx%d", len(xs), a.Rows, a.Cols)
+	}
+	result := make([]float64, a.Cols)
+	for j := range result {
+		for k, x := range xs {
+			result[j] += x * a.At(k, j)
+		}
+	}
+	return newVector(result)
+}
+
+func matPow(m *object.Matrix, n int64) object.Object {
+	if err := requireSquare("^", m); err != nil {
+		return err
+	}
+	if n < 0 {
+		inv := inverse(m)
+		if isError(inv) {
+			return inv
+		}
+		m, n = inv.(*object.Matrix), -n
+	}
+	var result object.Object = identity(m.Rows)
+	for ; n > 0; n-- {
+		result = matMul(result.(*object.Matrix), m)
+	}
+	return result
+}
+
+func newVector(xs []float64) *object.Vector {
+	elements := make([]object.Object, len(xs))
+	for i, x := range xs {
+		elements[i] = normalizeNumber(&object.Float{Value: x})
+	}
+	return &object.Vector{Elements: elements}
+}
+
+func transpose(m *object.Matrix) *object.Matrix {
+	result := object.NewMatrix(m.Cols, m.Rows)
+	for i := 0; i < m.Rows; i++ {
+		for j := 0; j < m.Cols; j++ {
+			result.Set(j, i, m.At(i, j))
+		}
+	}
+	return result
+}
+
+// Elimination works on exact rationals when every element is an integer,
+// so det([[1,2],[3,4]]) is exactly -2, and on floats with partial
+// pivoting otherwise.
+
+func isIntegral(m *object.Matrix) bool {
+	for _, x := range m.Data {
+		if x != math.Trunc(x) || math.Abs(x) > 1<<53 {
+			return false
+		}
+	}
+	return true
+}
+
+// augment returns the rows of m followed by the columns of extra.
+func augment(m *object.Matrix, extra *object.Matrix) [][]float64 {
+	rows := make([][]float64, m.Rows)
+	for i := range rows {
+		rows[i] = append([]float64{}, m.Data[i*m.Cols:(i+1)*m.Cols]...)
+		if extra != nil {
+			rows[i] = append(rows[i], extra.Data[i*extra.Cols:(i+1)*extra.Cols]...)
+		}
+	}
+	return rows
+}
+
+// reduce brings rows to reduced row echelon form using pivots from the
+// first n columns only. It returns the pivot columns and the product of
+// the pivots with the sign of the row swaps, which is the determinant
+// for a square matrix of full rank.
+func reduce(rows [][]float64, n int, exact bool) ([]int, float64) {
+	if exact {
+		return reduceRat(rows, n)
+	}
+	return reduceFloat(rows, n)
+}
+
+func reduceFloat(rows [][]float64, n int) ([]int, float64) {
+	maxAbs := 0.0
+	for _, row := range rows {
+		for _, x := range row[:n] {
+			maxAbs = math.Max(maxAbs, math.Abs(x))
+		}
+	}
+	tol := float64(len(rows)+n) * maxAbs * 1e-14
+	det := 1.0
+	var pivots []int
+	r := 0
+	for c := 0; c < n && r < len(rows); c++ {
+		p := r
+		for i := r + 1; i < len(rows); i++ {
+			if math.Abs(rows[i][c]) > math.Abs(rows[p][c]) {
+				p = i
+			}
+		}
+		if math.Abs(rows[p][c]) <= tol {
+			det = 0
+			continue
+		}
+		if p != r {
+			rows[p], rows[r] = rows[r], rows[p]
+			det = -det
+		}
+		pivot := rows[r][c]
+		det *= pivot
+		for j := range rows[r] {
+			rows[r][j] /= pivot
+		}
+		for i := range rows {
+			if i == r || rows[i][c] == 0 {
+				continue
+			}
+			f := rows[i][c]
+			for j := range rows[i] {
+				rows[i][j] -= f * rows[r][j]
+			}
+		}
+		pivots = append(pivots, c)
+		r++
+	}
+	return pivots, det
+}
+
+func reduceRat(rows [][]float64, n int) ([]int, float64) {
+	q := make([][]*big.Rat, len(rows))
+	for i, row := range rows {
+		q[i] = make([]*big.Rat, len(row))
+		for j, x := range row {
+			q[i][j] = new(big.Rat).SetFloat64(x)
+		}
+	}
+	det := big.NewRat(1, 1)
+	var pivots []int
+	r := 0
+	tmp := new(big.Rat)
+	for c := 0; c < n && r < len(q); c++ {
+		p := -1
+		for i := r; i < len(q); i++ {
+			if q[i][c].Sign() != 0 {
+				p = i
+				break
+			}
+		}
+		if p < 0 {
+			det.SetInt64(0)
+			continue
+		}
+		if p != r {
+			q[p], q[r] = q[r], q[p]
+			det.Neg(det)
+		}
+		pivot := new(big.Rat).Set(q[r][c])
+		det.Mul(det, pivot)
+		for j := range q[r] {
+			q[r][j].Quo(q[r][j], pivot)
+		}
+		for i := range q {
+			if i == r || q[i][c].Sign() == 0 {
+				continue
+			}
+			f := new(big.Rat).Set(q[i][c])
+			for j := range q[i] {
+				q[i][j].Sub(q[i][j], tmp.Mul(f, q[r][j]))
+			}
+		}
+		pivots = append(pivots, c)
+		r++
+	}
+	for i, row := range q {
+		for j, x := range row {
+			rows[i][j], _ = x.Float64()
+		}
+	}
+	d, _ := det.Float64()
+	return pivots, d
+}
+
+func determinant(m *object.Matrix) object.Object {
+	if err := requireSquare("det", m); err != nil {
+		return err
+	}
+	pivots, det := reduce(augment(m, nil), m.Cols, isIntegral(m))
+	if len(pivots) < m.Rows {
+		det = 0
+	}
+	return normalizeNumber(&object.Float{Value: det})
+}
+
+func inverse(m *object.Matrix) object.Object {
+	if err := requireSquare("inv", m); err != nil {
+		return err
+	}
+	rows := augment(m, identity(m.Rows))
+	pivots, _ := reduce(rows, m.Cols, isIntegral(m))
+	if len(pivots) < m.Rows {
+		return newError("inv: matrix is singular")
+	}
+	result := object.NewMatrix(m.Rows, m.Cols)
+	for i, row := range rows {
+		copy(result.Data[i*m.Cols:], row[m.Cols:])
+	}
+	return result
+}
+
+func rank(m *object.Matrix) object.Object {
+	pivots, _ := reduce(augment(m, nil), m.Cols, isIntegral(m))
+	return &object.Integer{Value: int64(len(pivots))}
+}
+
+// linsolve solves the square system a x = b.
+func linsolve(a *object.Matrix, b *object.Vector) object.Object {
+	if err := requireSquare("linsolve", a); err != nil {
+		return err
+	}
+	xs, err := floats("linsolve", b)
+	if err != nil {
+		return err
+	}
+	if len(xs) != a.Rows {
+		return newError("linsolve: dimension mismatch: %dx%d matrix and vector of length %d", a.Rows, a.Cols, len(xs))
+	}
+	rhs := object.NewMatrix(len(xs), 1)
+	copy(rhs.Data, xs)
+	exact := isIntegral(a) && isIntegral(rhs)
+	rows := augment(a, rhs)
+	pivots, _ := reduce(rows, a.Cols, exact)
+	if len(pivots) < a.Rows {
+		return newError("linsolve: matrix is singular")
+	}
+	solution := make([]float64, a.Rows)
+	for i, row := range rows {
+		solution[i] = row[a.Cols]
+	}
+	return newVector(solution)
+}
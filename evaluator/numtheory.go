@@ -0,0 +1,279 @@
+package evaluator
+
+import (
+	"math"
+	"math/big"
+	"sort"
+
+	"github.com/hellracer2007/webCalc/calculator/object"
+)
+
+const (
+	// primeRounds is the number of Miller-Rabin rounds, ProbablyPrime adds
+	// a Baillie-PSW test on top of them
+	primeRounds = 20
+	// trialDivisionLimit bounds the small factors removed by division
+	// before Pollard's rho takes over.
+	trialDivisionLimit = 1000
+	// rhoMaxSteps bounds the iterations of a single Pollard rho attempt,
+	// enough for factors of about 10 digits; with rhoAttempts a number
+	// without such a factor fails in about a second.
+	rhoMaxSteps = 1 << 16
+	rhoAttempts = 4
+)
+
+func init() {
+	builtins["gcd"] = bigIntBuiltin("gcd", func(xs []*big.Int) object.Object {
+		g := new(big.Int)
+		for _, x := range xs {
+			g.GCD(nil, nil, g, new(big.Int).Abs(x))
+		}
+		return newInteger(g)
+	})
+	builtins["lcm"] = bigIntBuiltin("lcm", func(xs []*big.Int) object.Object {
+		l := big.NewInt(1)
+		for _, x := range xs {
+			if x.Sign() == 0 {
+				return &object.Integer{Value: 0}
+			}
+			g := new(big.Int).GCD(nil, nil, l, new(big.Int).Abs(x))
+			l.Mul(l, new(big.Int).Quo(new(big.Int).Abs(x), g))
+		}
+		return newInteger(l)
+	})
+	builtins["isprime"] = bigIntBuiltin("isprime", func(xs []*big.Int) object.Object {
+		if len(xs) != 1 {
+			return newError("isprime: wrong number of arguments. got=%d, want=1", len(xs))
+		}
+		if xs[0].Sign() > 0 && xs[0].ProbablyPrime(primeRounds) {
+			return &object.Integer{Value: 1}
+		}
+		return &object.Integer{Value: 0}
+	})
+	builtins["nextprime"] = bigIntBuiltin("nextprime", func(xs []*big.Int) object.Object {
+		if len(xs) != 1 {
+			return newError("nextprime: wrong number of arguments. got=%d, want=1", len(xs))
+		}
+		return newInteger(nextPrime(xs[0]))
+	})
+	builtins["totient"] = bigIntBuiltin("totient", func(xs []*big.Int) object.Object {
+		if len(xs) != 1 {
+			return newError("totient: wrong number of arguments. got=%d, want=1", len(xs))
+		}
+		if xs[0].Sign() <= 0 {
+			return newError("totient: n must be positive")
+		}
+		factors, err := factorInteger(xs[0])
+		if err != nil {
+			return err
+		}
+		phi := big.NewInt(1)
+		for _, f := range factors {
+			phi.Mul(phi, new(big.Int).Sub(f.prime, big.NewInt(1)))
+			phi.Mul(phi, new(big.Int).Exp(f.prime, big.NewInt(int64(f.exp-1)), nil))
+		}
+		return newInteger(phi)
+	})
+	builtins["powmod"] = bigIntBuiltin("powmod", func(xs []*big.Int) object.Object {
+		if len(xs) != 3 {
+			return newError("powmod: wrong number of arguments. got=%d, want=3", len(xs))
+		}
+		a, b, m := xs[0], xs[1], xs[2]
+		if m.Sign() <= 0 {
+			return newError("powmod: the modulus must be positive")
+		}
+		a = new(big.Int).Mod(a, m)
+		if b.Sign() < 0 {
+			if a = new(big.Int).ModInverse(a, m); a == nil {
+				return newError("powmod: %s has no inverse modulo %s", xs[0], m)
+			}
+			b = new(big.Int).Neg(b)
+		}
+		return newInteger(new(big.Int).Exp(a, b, m))
+	})
+	builtins["modinv"] = bigIntBuiltin("modinv", func(xs []*big.Int) object.Object {
+		if len(xs) != 2 {
+			return newError("modinv: wrong number of arguments. got=%d, want=2", len(xs))
+		}
+		a, m := xs[0], xs[1]
+		if m.Sign() <= 0 {
+			return newError("modinv: the modulus must be positive")
+		}
+		inv := new(big.Int).ModInverse(new(big.Int).Mod(a, m), m)
+		if inv == nil || m.Cmp(big.NewInt(1)) == 0 {
+			return newError("modinv: %s has no inverse modulo %s", a, m)
+		}
+		return newInteger(inv)
+	})
+}
+
+// bigIntBuiltin wraps a function of integers of any size.
+func bigIntBuiltin(name string, fn func([]*big.Int) object.Object) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) == 0 {
+				return newError("%s: wrong number of arguments. got=0", name)
+			}
+			xs := make([]*big.Int, len(args))
+			for i, arg := range args {
+				x, ok := bigInteger(arg)
+				if !ok {
+					return newError("%s: expected integers, got %s", name, arg.Inspect())
+				}
+				xs[i] = x
+			}
+			return fn(xs)
+		},
+	}
+}
+
+// bigInteger reads obj as an integer, accepting floats without a fraction
+// as long as they are exact.
+func bigInteger(obj object.Object) (*big.Int, bool) {
+	if n, ok := toBigInt(obj); ok {
+		return n, true
+	}
+	f, ok := obj.(*object.Float)
+	if !ok || f.Value != math.Trunc(f.Value) || math.Abs(f.Value) > 1<<53 {
+		return nil, false
+	}
+	return big.NewInt(int64(f.Value)), true
+}
+
+func nextPrime(n *big.Int) *big.Int {
+	two := big.NewInt(2)
+	if n.Cmp(two) < 0 {
+		return two
+	}
+	p := new(big.Int).Add(n, big.NewInt(1))
+	if p.Bit(0) == 0 {
+		if p.Cmp(two) == 0 {
+			return p
+		}
+		p.Add(p, big.NewInt(1))
+	}
+	for !p.ProbablyPrime(primeRounds) {
+		p.Add(p, two)
+	}
+	return p
+}
+
+type primePower struct {
+	prime *big.Int
+	exp   int
+}
+
+// factorization returns the factorization of n as a list of {prime,
+// exponent} pairs in increasing order, with {-1, 1} first for negative n.
+func factorization(n *big.Int) object.Object {
+	if n.Sign() == 0 {
+		return newError("factor: 0 has no factorization")
+	}
+	factors, err := factorInteger(new(big.Int).Abs(n))
+	if err != nil {
+		return err
+	}
+	var pairs []object.Object
+	if n.Sign() < 0 {
+		pairs = append(pairs, &object.List{Elements: []object.Object{&object.Integer{Value: -1}, &object.Integer{Value: 1}}})
+	}
+	for _, f := range factors {
+		pairs = append(pairs, &object.List{Elements: []object.Object{newInteger(f.prime), &object.Integer{Value: int64(f.exp)}}})
+	}
+	return &object.List{Elements: pairs}
+}
+
+// factorInteger splits n > 0 into prime powers: small primes by trial
+// division, the rest with Pollard's rho.
+func factorInteger(n *big.Int) ([]primePower, *object.Error) {
+	counts := map[string]*primePower{}
+	record := func(p *big.Int) {
+		if f, ok := counts[p.String()]; ok {
+			f.exp++
+			return
+		}
+		counts[p.String()] = &primePower{new(big.Int).Set(p), 1}
+	}
+	n = new(big.Int).Set(n)
+	d, rem := new(big.Int), new(big.Int)
+	for p := int64(2); p < trialDivisionLimit && n.Cmp(big.NewInt(1)) > 0; p++ {
+		d.SetInt64(p)
+		for {
+			q, r := new(big.Int).QuoRem(n, d, rem)
+			if r.Sign() != 0 {
+				break
+			}
+			record(d)
+			n = q
+		}
+	}
+	stack := []*big.Int{n}
+	for len(stack) > 0 {
+		m := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		switch {
+		case m.Cmp(big.NewInt(1)) == 0:
+		case m.ProbablyPrime(primeRounds):
+			record(m)
+		default:
+			f := pollardRho(m)
+			if f == nil {
+				return nil, newError("factor: could not split %s", m)
+			}
+			stack = append(stack, f, new(big.Int).Quo(m, f))
+		}
+	}
+	factors := make([]primePower, 0, len(counts))
+	for _, f := range counts {
+		factors = append(factors, *f)
+	}
+	sort.Slice(factors, func(i, j int) bool { return factors[i].prime.Cmp(factors[j].prime) < 0 })
+	return factors, nil
+}
+
+// pollardRho finds a non-trivial factor of the composite n, iterating
+// x² + c with Brent's cycle detection and batching the gcds. It returns
+// nil when every attempt fails.
+func pollardRho(n *big.Int) *big.Int {
+	const batch = 128
+	one := big.NewInt(1)
+	f := func(x, c *big.Int) *big.Int {
+		x = new(big.Int).Mul(x, x)
+		x.Add(x, c)
+		return x.Mod(x, n)
+	}
+	for c := int64(1); c <= rhoAttempts; c++ {
+		cc := big.NewInt(c)
+		y, x, ys := big.NewInt(2), new(big.Int), new(big.Int)
+		g, q := big.NewInt(1), big.NewInt(1)
+		for r := 1; g.Cmp(one) == 0 && r <= rhoMaxSteps; r *= 2 {
+			x.Set(y)
+			for i := 0; i < r; i++ {
+				y = f(y, cc)
+			}
+			for k := 0; k < r && g.Cmp(one) == 0; k += batch {
+				ys.Set(y)
+				for i := 0; i < batch && i < r-k; i++ {
+					y = f(y, cc)
+					q.Mul(q, new(big.Int).Abs(new(big.Int).Sub(x, y)))
+					q.Mod(q, n)
+				}
+				g.GCD(nil, nil, q, n)
+			}
+		}
+		if g.Cmp(n) == 0 {
+			// the batch overshot, redo it one step at a time
+			for {
+				ys = f(ys, cc)
+				g.GCD(nil, nil, new(big.Int).Abs(new(big.Int).Sub(x, ys)), n)
+				if g.Cmp(one) != 0 {
+					break
+				}
+			}
+		}
+		if g.Cmp(one) != 0 && g.Cmp(n) != 0 {
+			return g
+		}
+	}
+	return nil
+}
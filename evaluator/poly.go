@@ -0,0 +1,216 @@
+package evaluator
+
+import (
+	"math"
+	"math/big"
+	"math/cmplx"
+	"sort"
+
+	"github.com/hellracer2007/webCalc/calculator/ast"
+	"github.com/hellracer2007/webCalc/calculator/object"
+	"github.com/hellracer2007/webCalc/calculator/symbolic"
+)
+
+// evalExpand implements expand(expr).
+func evalExpand(node *ast.CallExpression, env *object.Environment) object.Object {
+	if err := checkArgs(node, 1); err != nil {
+		return err
+	}
+	if !pure(node.Arguments[0]) {
+		return expressionResult(node.Arguments[0], env)
+	}
+	expanded, err := symbolic.Expand(node.Arguments[0])
+	if err != nil {
+		return newError("expand: %s", err)
+	}
+	return expressionResult(expanded, env)
+}
+
+// polynomials reads the first n arguments of fn as polynomials in one
+// variable, which is named by an extra argument or inferred.
+func polynomials(node *ast.CallExpression, n int, env *object.Environment) ([]*symbolic.Polynomial, *object.Error) {
+	args := node.Arguments
+	if len(args) != n && len(args) != n+1 {
+		return nil, newError("%s: wrong number of arguments. got=%d, want=%d or %d", node.Func, len(args), n, n+1)
+	}
+	var name string
+	var err *object.Error
+	if len(args) == n+1 {
+		if name, err = variableName(node.Func, args[n]); err != nil {
+			return nil, err
+		}
+	} else {
+		free := map[string]bool{}
+		for _, a := range args {
+			for _, v := range freeVariables(a, env) {
+				free[v] = true
+			}
+		}
+		if len(free) > 1 {
+			return nil, newError("%s: cannot tell which variable the polynomial is in, name it explicitly", node.Func)
+		}
+		name = "x"
+		for v := range free {
+			name = v
+		}
+	}
+	polys := make([]*symbolic.Polynomial, n)
+	for i := 0; i < n; i++ {
+		if !pure(args[i]) {
+			return nil, newError("%s: %s calls a random function and is not a polynomial", node.Func, args[i].String())
+		}
+		expr := symbolic.Fold(args[i], constantValue(name, env, false))
+		p, perr := symbolic.NewPolynomial(expr, name)
+		if perr != nil {
+			return nil, newError("%s: %s", node.Func, perr)
+		}
+		polys[i] = p
+	}
+	return polys, nil
+}
+
+// evalFactor implements factor(p) and factor(p, x), the factorisation of
+// a polynomial over the integers. An argument without variables that is
+// an integer is factored into primes instead.
+func evalFactor(node *ast.CallExpression, env *object.Environment) object.Object {
+	if len(node.Arguments) == 1 && len(freeVariables(node.Arguments[0], env)) == 0 {
+		// factor(360) factors the integer rather than a constant polynomial
+		if n, ok := bigInteger(Eval(node.Arguments[0], env)); ok {
+			return factorization(n)
+		}
+	}
+	polys, err := polynomials(node, 1, env)
+	if err != nil {
+		return err
+	}
+	return expressionResult(polys[0].FactorExpression(), env)
+}
+
+// evalPolyDiv implements polydiv(a, b), the quotient and remainder of
+// polynomial division.
+func evalPolyDiv(node *ast.CallExpression, env *object.Environment) object.Object {
+	polys, err := polynomials(node, 2, env)
+	if err != nil {
+		return err
+	}
+	q, r, derr := polys[0].Div(polys[1])
+	if derr != nil {
+		return newError("polydiv: %s", derr)
+	}
+	return &object.Tuple{
+		Names:    []string{"quotient", "remainder"},
+		Elements: []object.Object{expressionResult(q.Expression(), env), expressionResult(r.Expression(), env)},
+	}
+}
+
+// evalPolyGCD implements polygcd(a, b), the monic greatest common divisor.
+func evalPolyGCD(node *ast.CallExpression, env *object.Environment) object.Object {
+	polys, err := polynomials(node, 2, env)
+	if err != nil {
+		return err
+	}
+	return expressionResult(symbolic.GCD(polys[0], polys[1]).Expression(), env)
+}
+
+// evalRoots implements roots(p) and roots(p, x): every root of the
+// polynomial, complex ones included, repeated by multiplicity. Rational
+// roots are found exactly, the others are the eigenvalues of the
+// companion matrix of each square-free factor, polished with Newton's
+// method on the original polynomial.
+func evalRoots(node *ast.CallExpression, env *object.Environment) object.Object {
+	args := node.Arguments
+	if len(args) > 0 {
+		// roots(x^2 = 2) means roots(x^2 - 2)
+		args = append([]ast.Expression{equationFunction(args[0])}, args[1:]...)
+	}
+	polys, err := polynomials(&ast.CallExpression{Token: node.Token, Func: node.Func, Arguments: args}, 1, env)
+	if err != nil {
+		return err
+	}
+	p := polys[0]
+	if p.IsZero() {
+		return newError("roots: every number is a root of 0")
+	}
+	var roots []complex128
+	for i, s := range p.SquareFree() {
+		if s.Degree() < 1 {
+			continue
+		}
+		rs, err := squareFreeRoots(s)
+		if err != nil {
+			return err
+		}
+		for _, r := range rs {
+			for k := 0; k <= i; k++ {
+				roots = append(roots, r)
+			}
+		}
+	}
+	sort.SliceStable(roots, func(i, j int) bool {
+		ri, rj := imag(roots[i]) == 0, imag(roots[j]) == 0
+		if ri != rj {
+			return ri
+		}
+		if real(roots[i]) != real(roots[j]) {
+			return real(roots[i]) < real(roots[j])
+		}
+		return imag(roots[i]) < imag(roots[j])
+	})
+	return newComplexVector(roots)
+}
+
+func squareFreeRoots(p *symbolic.Polynomial) ([]complex128, *object.Error) {
+	var roots []complex128
+	_, factors := p.Factor()
+	for _, f := range factors {
+		if f.Poly.Degree() == 1 {
+			// q x - p has the exact root p/q
+			r := new(big.Rat).Quo(f.Poly.Coeffs[0], f.Poly.Coeffs[1])
+			v, _ := r.Neg(r).Float64()
+			roots = append(roots, complex(v, 0))
+			continue
+		}
+		cs := f.Poly.Floats()
+		n := len(cs) - 1
+		companion := make([][]float64, n)
+		for i := range companion {
+			companion[i] = make([]float64, n)
+			if i > 0 {
+				companion[i][i-1] = 1
+			}
+			companion[i][n-1] = -cs[i] / cs[n]
+		}
+		values, err := eigenvalues(companion)
+		if err != nil {
+			return nil, newError("roots: QR iteration on the companion matrix did not converge")
+		}
+		for _, v := range values {
+			roots = append(roots, polishComplexRoot(cs, v))
+		}
+	}
+	return roots, nil
+}
+
+// polishComplexRoot refines a root of the polynomial with coefficients cs
+// with a few Newton steps and drops an imaginary part lost in rounding.
+func polishComplexRoot(cs []float64, z complex128) complex128 {
+	for i := 0; i < 3; i++ {
+		var p, dp complex128
+		for k := len(cs) - 1; k >= 0; k-- {
+			dp = dp*z + p
+			p = p*z + complex(cs[k], 0)
+		}
+		if dp == 0 {
+			break
+		}
+		step := p / dp
+		if cmplx.IsNaN(step) || cmplx.IsInf(step) {
+			break
+		}
+		z -= step
+	}
+	if math.Abs(imag(z)) < 1e-14*math.Max(1, cmplx.Abs(z)) {
+		z = complex(real(z), 0)
+	}
+	return z
+}
@@ -0,0 +1,237 @@
+package evaluator
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/hellracer2007/webCalc/calculator/ast"
+	"github.com/hellracer2007/webCalc/calculator/constants"
+	"github.com/hellracer2007/webCalc/calculator/object"
+)
+
+// maxPrecision bounds the digits of precision(digits), the constants being
+// known to 64 digits.
+const maxPrecision = 60
+
+// In high precision, set with precision(digits), decimal literals and the
+// mathematical constants are read to the precision, quotients of integers
+// and the arithmetic of such numbers is carried out in big floats, and
+// the functions in preciseBuiltins use their big-float versions. Other
+// functions still compute in floating point.
+
+// preciseFunc is the big-float version of a builtin. It computes to prec
+// bits and checks its arguments like the builtin.
+type preciseFunc func(prec uint, xs []*big.Float) (*big.Float, *object.Error)
+
+var preciseBuiltins = map[string]preciseFunc{}
+
+// preciseBuiltin wraps the big-float version of a function of n numbers.
+func preciseBuiltin(name string, n int, fn preciseFunc) preciseFunc {
+	return func(prec uint, xs []*big.Float) (*big.Float, *object.Error) {
+		if len(xs) != n {
+			return nil, newError("%s: wrong number of arguments. got=%d, want=%d", name, len(xs), n)
+		}
+		return fn(prec, xs)
+	}
+}
+
+// floatBuiltin makes a builtin from the big-float version of a function,
+// computed to the 53 bits of a float. It serves functions whose float
+// version would repeat the big-float one.
+func floatBuiltin(name string, fn preciseFunc) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			xs := make([]*big.Float, len(args))
+			for i, arg := range args {
+				f, ok := toFloat(arg)
+				if !ok || !isFinite(f) {
+					return newError("%s: expected finite numbers, got %s", name, arg.Inspect())
+				}
+				xs[i] = new(big.Float).SetFloat64(f)
+			}
+			y, err := fn(53, xs)
+			if err != nil {
+				return err
+			}
+			f, _ := y.Float64()
+			return finite(name, f)
+		},
+	}
+}
+
+// precisionBits is the precision of a big float shown to digits digits,
+// with a few bits to spare for the arithmetic on it.
+func precisionBits(digits int) uint {
+	return uint(math.Ceil(float64(digits)*math.Log2(10))) + 8
+}
+
+// evalPrecision implements precision(digits), which sets the number of
+// significant digits of the session, 0 going back to floating point, and
+// precision(), which returns it.
+func evalPrecision(node *ast.CallExpression, env *object.Environment) object.Object {
+	if len(node.Arguments) == 0 {
+		return &object.Integer{Value: int64(env.Precision())}
+	}
+	if err := checkArgs(node, 1); err != nil {
+		return err
+	}
+	digits := Eval(node.Arguments[0], env)
+	if isError(digits) {
+		return digits
+	}
+	ns, err := integers(node.Func, []object.Object{digits})
+	if err != nil {
+		return err
+	}
+	if ns[0] < 0 || ns[0] > maxPrecision {
+		return newError("precision: the number of digits must be between 0 and %d, got %d", maxPrecision, ns[0])
+	}
+	env.SetPrecision(int(ns[0]))
+	return &object.Integer{Value: ns[0]}
+}
+
+// applyPrecise calls the big-float version of a builtin. Arguments that
+// are not finite real numbers are left to the builtin.
+func applyPrecise(name string, fn preciseFunc, args []object.Object, digits int) object.Object {
+	prec := precisionBits(digits)
+	xs := make([]*big.Float, len(args))
+	for i, arg := range args {
+		x, ok := toBigFloat(arg, prec)
+		if !ok {
+			return applyFunction(name, args)
+		}
+		xs[i] = x
+	}
+	y, err := fn(prec, xs)
+	if err != nil {
+		return err
+	}
+	return &object.BigFloat{Value: newBig(prec).Set(y), Digits: digits}
+}
+
+// toBigFloat reads a finite real number exactly, rounded to prec bits.
+func toBigFloat(obj object.Object, prec uint) (*big.Float, bool) {
+	switch v := obj.(type) {
+	case *object.Integer:
+		return bigInt(v.Value, prec), true
+	case *object.BigInt:
+		return newBig(prec).SetInt(v.Value), true
+	case *object.Decimal:
+		return newBig(prec).SetRat(toRat(v)), true
+	case *object.Float:
+		if !isFinite(v.Value) {
+			return nil, false
+		}
+		return newBig(prec).SetFloat64(v.Value), true
+	case *object.BigFloat:
+		if v.Value.IsInf() {
+			return nil, false
+		}
+		return newBig(prec).Set(v.Value), true
+	}
+	return nil, false
+}
+
+// isReal reports whether obj is a plain real number, the values big floats
+// keep their precision among.
+func isReal(obj object.Object) bool {
+	switch obj.Type() {
+	case object.INTEGER_OBJ, object.BIGINT_OBJ, object.DECIMAL_OBJ, object.FLOAT_OBJ, object.BIGFLOAT_OBJ:
+		return true
+	}
+	return false
+}
+
+func bigFloatToFloat(b *object.BigFloat) float64 {
+	f, _ := b.Value.Float64()
+	return f
+}
+
+// preciseLiteral reads a decimal literal, or e, to the precision of the
+// session.
+func preciseLiteral(lit *ast.FloatLiteral, digits int) object.Object {
+	prec := precisionBits(digits)
+	if lit == ast.Euler {
+		return &object.BigFloat{Value: constants.E.Float(prec), Digits: digits}
+	}
+	f, _, err := big.ParseFloat(lit.Token.Literal, 10, prec, big.ToNearestEven)
+	if err != nil {
+		f = newBig(prec).SetFloat64(lit.Value)
+	}
+	return &object.BigFloat{Value: f, Digits: digits}
+}
+
+// preciseIntegers is the quotient or power of two integers in high
+// precision, 1/3 and 2^-1000 are not rounded to floats.
+func preciseIntegers(operator string, left, right object.Object, digits int) object.Object {
+	q := evalInfixExpression(operator, left, right)
+	// whole results and division by zero stay, but not powers that
+	// underflowed to 0
+	if r, _ := toFloat(right); q.Type() != object.FLOAT_OBJ && (operator != "^" || r >= 0) {
+		return q
+	}
+	l, _ := toBigFloat(left, precisionBits(digits))
+	return evalBigFloatInfixExpression(operator, &object.BigFloat{Value: l, Digits: digits}, right)
+}
+
+// evalBigFloatInfixExpression computes +, -, *, / and powers in big
+// floats to the larger precision of the operands. Other operators work in
+// floating point.
+func evalBigFloatInfixExpression(operator string, left, right object.Object) object.Object {
+	if !isReal(left) || !isReal(right) {
+		return newError("unsupported operands for %s: %s and %s", operator, left.Type(), right.Type())
+	}
+	digits := max(bigDigits(left), bigDigits(right))
+	prec := precisionBits(digits)
+	l, lok := toBigFloat(left, prec)
+	r, rok := toBigFloat(right, prec)
+	if lok && rok {
+		z := newBig(prec)
+		switch operator {
+		case "+":
+			return &object.BigFloat{Value: z.Add(l, r), Digits: digits}
+		case "-":
+			return &object.BigFloat{Value: z.Sub(l, r), Digits: digits}
+		case "*":
+			return &object.BigFloat{Value: z.Mul(l, r), Digits: digits}
+		case "/":
+			if r.Sign() == 0 {
+				return newError("division by zero")
+			}
+			return &object.BigFloat{Value: z.Quo(l, r), Digits: digits}
+		case "^":
+			if p, ok := preciseIntegerPower(l, r, prec); ok {
+				return &object.BigFloat{Value: p, Digits: digits}
+			}
+			if l.Sign() > 0 {
+				return &object.BigFloat{Value: bigPow(l, r, prec), Digits: digits}
+			}
+		case "E":
+			if p, ok := preciseIntegerPower(bigInt(10, prec), r, prec); ok {
+				return &object.BigFloat{Value: z.Mul(l, p), Digits: digits}
+			}
+		}
+	}
+	lf, _ := toFloat(left)
+	rf, _ := toFloat(right)
+	return evalInfixExpression(operator, &object.Float{Value: lf}, &object.Float{Value: rf})
+}
+
+// preciseIntegerPower is x^n for a whole exponent n, false when n is not
+// whole or x^n divides by zero.
+func preciseIntegerPower(x, n *big.Float, prec uint) (*big.Float, bool) {
+	if !n.IsInt() || magnitude(n) > 31 || (x.Sign() == 0 && n.Sign() < 0) {
+		return nil, false
+	}
+	e, _ := n.Int64()
+	return bigPowInt(x, e, prec), true
+}
+
+// bigDigits is the number of digits a number is shown with, 0 for numbers
+// other than big floats.
+func bigDigits(obj object.Object) int {
+	if b, ok := obj.(*object.BigFloat); ok {
+		return b.Digits
+	}
+	return 0
+}
@@ -0,0 +1,117 @@
+package evaluator
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/hellracer2007/webCalc/calculator/ast"
+	"github.com/hellracer2007/webCalc/calculator/object"
+	"github.com/hellracer2007/webCalc/calculator/units"
+)
+
+func evalUnitLiteral(node *ast.UnitLiteral) object.Object {
+	u, err := units.Parse(node.Value)
+	if err != nil {
+		return newError("%s", err)
+	}
+	return &object.Quantity{Value: 1, Unit: u}
+}
+
+// newQuantity drops the unit once it cancels out, m/cm is just 100.
+func newQuantity(value float64, u *units.Unit) object.Object {
+	if u.Dimensionless() {
+		factor, _ := u.Factor.Float64()
+		return normalizeNumber(&object.Float{Value: value * factor})
+	}
+	return &object.Quantity{Value: value, Unit: u}
+}
+
+func toQuantity(obj object.Object) (*object.Quantity, bool) {
+	switch v := obj.(type) {
+	case *object.Quantity:
+		return v, true
+	case *object.Integer:
+		return &object.Quantity{Value: float64(v.Value), Unit: units.One}, true
+	case *object.Float:
+		return &object.Quantity{Value: v.Value, Unit: units.One}, true
+	case *object.Duration:
+		// months have no fixed length
+		if v.Months == 0 {
+			s, _ := units.Lookup("s")
+			return &object.Quantity{Value: durationSeconds(v), Unit: s}, true
+		}
+		return nil, false
+	}
+	if f, ok := toFloat(obj); ok {
+		return &object.Quantity{Value: f, Unit: units.One}, true
+	}
+	return nil, false
+}
+
+func unitName(u *units.Unit) string {
+	if len(u.Terms) == 0 {
+		return "no unit"
+	}
+	return u.String()
+}
+
+func evalQuantityInfixExpression(operator string, left, right object.Object) object.Object {
+	l, ok := toQuantity(left)
+	if !ok {
+		return newError("unsupported operand for %s: %s", operator, left.Type())
+	}
+	r, ok := toQuantity(right)
+	if !ok {
+		return newError("unsupported operand for %s: %s", operator, right.Type())
+	}
+
+	switch operator {
+	case "+", "-":
+		if !l.Unit.Compatible(r.Unit) {
+			return newError("incompatible units: %s %s %s", unitName(l.Unit), operator, unitName(r.Unit))
+		}
+		// a sum is a difference of temperatures, so no offsets here
+		scale, _ := new(big.Rat).Quo(r.Unit.Factor, l.Unit.Factor).Float64()
+		if operator == "+" {
+			return newQuantity(l.Value+r.Value*scale, l.Unit)
+		}
+		return newQuantity(l.Value-r.Value*scale, l.Unit)
+	case "*":
+		return newQuantity(l.Value*r.Value, l.Unit.Mul(r.Unit))
+	case "/":
+		return newQuantity(l.Value/r.Value, l.Unit.Div(r.Unit))
+	case "^":
+		n, ok := right.(*object.Integer)
+		if !ok {
+			return newError("a quantity can only be raised to an integer power")
+		}
+		return newQuantity(math.Pow(l.Value, float64(n.Value)), l.Unit.Pow(int(n.Value)))
+	}
+	return newError("operator %s is not supported for quantities", operator)
+}
+
+func evalConversion(left object.Object, target *ast.UnitLiteral) object.Object {
+	if v, ok := left.(*object.Vector); ok {
+		return mapVector(v, func(el object.Object) object.Object {
+			return evalConversion(el, target)
+		})
+	}
+	to, err := units.Parse(target.Value)
+	if err != nil {
+		return newError("%s", err)
+	}
+	q, ok := toQuantity(left)
+	if !ok {
+		return newError("cannot convert %s to %s", left.Type(), target.Value)
+	}
+	value := new(big.Rat).SetFloat64(q.Value)
+	if value == nil {
+		return newError("cannot convert %v to %s", q.Value, target.Value)
+	}
+	converted, err := units.Convert(value, q.Unit, to)
+	if err != nil {
+		return newError("%s", err)
+	}
+	f, _ := converted.Float64()
+	return &object.Quantity{Value: f, Unit: to}
+}
@@ -0,0 +1,147 @@
+package evaluator
+
+import (
+	"math"
+
+	"github.com/hellracer2007/webCalc/calculator/ast"
+	"github.com/hellracer2007/webCalc/calculator/object"
+)
+
+// maxRandomCount bounds the length of the lists of random numbers.
+const maxRandomCount = 1000000
+
+// impure lists the functions whose value changes from call to call. An
+// expression calling them is never folded into a constant or simplified,
+// rand() - rand() is not 0. They are special forms only to reach the
+// random number generator of the session in env.
+var impure = map[string]bool{
+	"rand":       true,
+	"randint":    true,
+	"randn":      true,
+	"montecarlo": true,
+}
+
+// pure reports whether expr calls none of the impure functions.
+func pure(expr ast.Node) bool {
+	result := true
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpression); ok && impure[call.Func] {
+			result = false
+		}
+		return result
+	})
+	return result
+}
+
+// evalSeed implements seed(n), making the random numbers that follow
+// reproducible.
+func evalSeed(node *ast.CallExpression, env *object.Environment) object.Object {
+	xs, err := randomArgs(node, env, 1, 1)
+	if err != nil {
+		return err
+	}
+	env.Seed(xs[0])
+	return &object.Integer{Value: xs[0]}
+}
+
+// evalRand implements rand(), uniform in [0, 1), and rand(n), a list of
+// n such numbers.
+func evalRand(node *ast.CallExpression, env *object.Environment) object.Object {
+	xs, err := randomArgs(node, env, 0, 1)
+	if err != nil {
+		return err
+	}
+	rng := env.Rand()
+	return randomList(node.Func, xs, func() object.Object {
+		return &object.Float{Value: rng.Float64()}
+	})
+}
+
+// evalRandInt implements randint(a, b), uniform among the integers from a
+// to b, and randint(a, b, n).
+func evalRandInt(node *ast.CallExpression, env *object.Environment) object.Object {
+	xs, err := randomArgs(node, env, 2, 3)
+	if err != nil {
+		return err
+	}
+	a, b := xs[0], xs[1]
+	if a > b {
+		return newError("randint: the range %d to %d is empty", a, b)
+	}
+	if b-a < 0 || b-a == math.MaxInt64 {
+		return newError("randint: the range %d to %d is too large", a, b)
+	}
+	rng := env.Rand()
+	return randomList(node.Func, xs[2:], func() object.Object {
+		return &object.Integer{Value: a + rng.Int63n(b-a+1)}
+	})
+}
+
+// evalRandn implements randn(), randn(mu, sigma) and randn(mu, sigma, n),
+// normally distributed random numbers.
+func evalRandn(node *ast.CallExpression, env *object.Environment) object.Object {
+	if len(node.Arguments) == 1 {
+		return newError("randn: wrong number of arguments. got=1, want=0, 2 or 3")
+	}
+	args := evalExpressions(node.Arguments, env)
+	if len(args) == 1 && isError(args[0]) {
+		return args[0]
+	}
+	mu, sigma := 0.0, 1.0
+	if len(args) >= 2 {
+		ps, err := sample(node.Func, args[:2])
+		if err != nil {
+			return err
+		}
+		mu, sigma = ps[0], ps[1]
+		if sigma < 0 {
+			return newError("randn: sigma must not be negative")
+		}
+	}
+	var count []int64
+	if len(args) == 3 {
+		n, err := integers(node.Func, args[2:])
+		if err != nil {
+			return err
+		}
+		count = n
+	} else if len(args) > 3 {
+		return newError("randn: wrong number of arguments. got=%d, want=0, 2 or 3", len(args))
+	}
+	rng := env.Rand()
+	return randomList(node.Func, count, func() object.Object {
+		return &object.Float{Value: mu + sigma*rng.NormFloat64()}
+	})
+}
+
+// randomArgs evaluates between min and max integer arguments.
+func randomArgs(node *ast.CallExpression, env *object.Environment, min, max int) ([]int64, *object.Error) {
+	if len(node.Arguments) < min || len(node.Arguments) > max {
+		if min == max {
+			return nil, newError("%s: wrong number of arguments. got=%d, want=%d", node.Func, len(node.Arguments), min)
+		}
+		return nil, newError("%s: wrong number of arguments. got=%d, want=%d to %d", node.Func, len(node.Arguments), min, max)
+	}
+	args := evalExpressions(node.Arguments, env)
+	if len(args) == 1 && isError(args[0]) {
+		return nil, args[0].(*object.Error)
+	}
+	return integers(node.Func, args)
+}
+
+// randomList returns a single draw, or a list of draws when the optional
+// count is given.
+func randomList(fn string, count []int64, draw func() object.Object) object.Object {
+	if len(count) == 0 {
+		return draw()
+	}
+	n := count[0]
+	if n < 0 || n > maxRandomCount {
+		return newError("%s: the count must be between 0 and %d, got %d", fn, maxRandomCount, n)
+	}
+	elements := make([]object.Object, n)
+	for j := range elements {
+		elements[j] = draw()
+	}
+	return &object.List{Elements: elements}
+}
@@ -0,0 +1,91 @@
+package evaluator
+
+import (
+	"math"
+
+	"github.com/hellracer2007/webCalc/calculator/ast"
+	"github.com/hellracer2007/webCalc/calculator/object"
+	"github.com/hellracer2007/webCalc/calculator/symbolic"
+)
+
+// rewritingForms are the special forms whose value is a rewritten
+// expression.
+var rewritingForms = map[string]bool{"diff": true, "expand": true, "simplify": true}
+
+// evalSimplify implements simplify(expr). Subexpressions that evaluate to
+// whole numbers are folded, everything else keeps its symbolic form so
+// simplify(x * π / 180 * 2) stays readable.
+func evalSimplify(node *ast.CallExpression, env *object.Environment) object.Object {
+	if err := checkArgs(node, 1); err != nil {
+		return err
+	}
+	// simplify(diff(x^3, x)) simplifies the derivative, not the call
+	var failed *object.Error
+	expr := symbolic.Replace(node.Arguments[0], func(e ast.Expression) ast.Expression {
+		call, ok := e.(*ast.CallExpression)
+		if !ok || !rewritingForms[call.Func] || failed != nil {
+			return nil
+		}
+		switch result := Eval(call, env).(type) {
+		case *object.Expression:
+			return result.Node
+		case *object.Error:
+			failed = result
+		}
+		return nil
+	})
+	if failed != nil {
+		return failed
+	}
+	expr = symbolic.Fold(expr, constantValue("", env, true))
+	if !pure(expr) {
+		// rand() - rand() is not 0, the calls are left as they are
+		return expressionResult(expr, env)
+	}
+	return expressionResult(symbolic.Simplify(expr), env)
+}
+
+// expressionResult wraps a rewritten expression, one that came out as a
+// plain number is returned as that number.
+func expressionResult(expr ast.Expression, env *object.Environment) object.Object {
+	switch expr.(type) {
+	case *ast.IntegerLiteral, *ast.FloatLiteral:
+		return Eval(expr, env)
+	}
+	return &object.Expression{Node: expr}
+}
+
+// optimize prepares expr for repeated evaluation with the variable name
+// bound to different values: it is simplified and every subtree that does
+// not depend on name is replaced by its value.
+func optimize(expr ast.Expression, name string, env *object.Environment) ast.Expression {
+	if !pure(expr) {
+		// simplifying would merge rand() - rand() into 0
+		return symbolic.Fold(expr, constantValue(name, env, false))
+	}
+	return symbolic.Fold(symbolic.Simplify(expr), constantValue(name, env, false))
+}
+
+// constantValue returns the value function for symbolic.Fold. Subtrees
+// depending on the variable name or calling impure functions are never
+// folded, with exact set only whole numbers are.
+func constantValue(name string, env *object.Environment, exact bool) func(ast.Expression) (float64, bool) {
+	return func(expr ast.Expression) (float64, bool) {
+		if (name != "" && symbolic.DependsOn(expr, name)) || !pure(expr) {
+			return 0, false
+		}
+		var v float64
+		switch val := Eval(expr, env).(type) {
+		case *object.Integer:
+			v = float64(val.Value)
+		case *object.Float:
+			v = val.Value
+		default:
+			return 0, false
+		}
+		if exact && (v != math.Trunc(v) || math.Abs(v) >= 1<<53) {
+			return 0, false
+		}
+		return v, isFinite(v)
+	}
+}
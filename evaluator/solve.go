@@ -0,0 +1,420 @@
+package evaluator
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/hellracer2007/webCalc/calculator/ast"
+	"github.com/hellracer2007/webCalc/calculator/constants"
+	"github.com/hellracer2007/webCalc/calculator/object"
+	"github.com/hellracer2007/webCalc/calculator/symbolic"
+	"github.com/hellracer2007/webCalc/calculator/token"
+)
+
+const (
+	solveSamples  = 10000
+	solveMaxIter  = 100
+	defaultSolveA = -1000
+	defaultSolveB = 1000
+)
+
+// freeVariables lists the identifiers of expr that are neither bound in
+// env nor constants, in order of appearance.
+func freeVariables(expr ast.Node, env *object.Environment) []string {
+	seen := map[string]bool{}
+	var names []string
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpression); ok {
+			// the bound variable of integrate(x^2, x, 0, 1) is not free
+			if _, special := specialForms[call.Func]; special && !impure[call.Func] && len(call.Arguments) > 1 {
+				if v, ok := call.Arguments[1].(*ast.Identifier); ok {
+					seen[v.Value] = true
+				}
+			}
+		}
+		ident, ok := n.(*ast.Identifier)
+		if !ok || seen[ident.Value] {
+			return true
+		}
+		seen[ident.Value] = true
+		if _, bound := env.Get(ident.Value); bound {
+			return true
+		}
+		if _, constant := constants.Lookup(ident.Value); constant {
+			return true
+		}
+		names = append(names, ident.Value)
+		return true
+	})
+	return names
+}
+
+// isVariable reports whether arg names a variable rather than a value.
+func isVariable(arg ast.Expression, env *object.Environment) bool {
+	ident, ok := arg.(*ast.Identifier)
+	if !ok {
+		return false
+	}
+	return len(freeVariables(ident, env)) == 1
+}
+
+func inferVariable(fn string, expr ast.Expression, env *object.Environment) (string, *object.Error) {
+	free := freeVariables(expr, env)
+	if len(free) != 1 {
+		return "", newError("%s: cannot tell which variable to solve for in %s, name it explicitly", fn, expr.String())
+	}
+	return free[0], nil
+}
+
+// equationFunction turns left = right into left - right, a plain
+// expression is taken to equal zero.
+func equationFunction(expr ast.Expression) ast.Expression {
+	eq, ok := expr.(*ast.Equation)
+	if !ok {
+		return expr
+	}
+	return &ast.InfixExpression{
+		Token:    token.Token{Type: token.MINUS, Literal: "-"},
+		Left:     eq.Left,
+		Operator: "-",
+		Right:    eq.Right,
+	}
+}
+
+// evalSolve implements solve(equation, x) and solve(equation, x, a, b),
+// returning every real root in [a, b], [-1000, 1000] unless given, in
+// increasing order. solve(A, b) with a matrix and a vector solves the
+// linear system instead.
+func evalSolve(node *ast.CallExpression, env *object.Environment) object.Object {
+	args := node.Arguments
+	if len(args) == 2 && !isVariable(args[1], env) {
+		values := evalExpressions(args, env)
+		if len(values) == 1 && isError(values[0]) {
+			return values[0]
+		}
+		if a, ok := values[0].(*object.Matrix); ok {
+			if b, ok := values[1].(*object.Vector); ok {
+				return linsolve(a, b)
+			}
+		}
+		return newError("solve: expected solve(equation, variable) or solve(matrix, vector)")
+	}
+	if len(args) != 2 && len(args) != 4 {
+		return newError("solve: wrong number of arguments. got=%d, want=2 or 4", len(args))
+	}
+	name, err := variableName("solve", args[1])
+	if err != nil {
+		return err
+	}
+	a, b := float64(defaultSolveA), float64(defaultSolveB)
+	if len(args) == 4 {
+		if a, err = evalNumber("solve", args[2], env); err != nil {
+			return err
+		}
+		if b, err = evalNumber("solve", args[3], env); err != nil {
+			return err
+		}
+	}
+	if !(a < b) || !isFinite(a) || !isFinite(b) {
+		return newError("solve: invalid interval [%v, %v]", a, b)
+	}
+	expr := equationFunction(args[0])
+	// identities such as 2 (x + 1) = 2 x + 2 expand to 0, where rounding
+	// would hide them from the grid of allRoots; an expression too large
+	// to expand is searched like any other
+	expanded, _ := symbolic.Expand(expr)
+	if lit, ok := expanded.(*ast.IntegerLiteral); ok && lit.Value == 0 && pure(expr) {
+		return everySolution(name, a, b)
+	}
+	f := bindReal("solve", expr, name, env)
+	roots, err := allRoots(f, name, a, b)
+	if err != nil {
+		return err
+	}
+	if len(roots) == 0 {
+		if len(args) == 2 {
+			return newError("solve: no real root in [%v, %v], the interval searched unless given as solve(equation, %s, a, b)", a, b, name)
+		}
+		return newError("solve: no real root in [%v, %v]", a, b)
+	}
+	return newVector(roots)
+}
+
+// evalRoot implements root(f, a, b) with Brent's method on a bracket and
+// root(f, x0) with Newton's method from a starting point. The variable
+// may be named as second argument, root(f, x, a, b) or root(f, x, x0).
+func evalRoot(node *ast.CallExpression, env *object.Environment) object.Object {
+	args := node.Arguments
+	if len(args) < 2 {
+		return newError("root: wrong number of arguments. got=%d, want=2 to 4", len(args))
+	}
+	expr := equationFunction(args[0])
+	var name string
+	var err *object.Error
+	if isVariable(args[1], env) && len(args) > 2 {
+		name, _ = variableName("root", args[1])
+		args = args[2:]
+	} else {
+		if name, err = inferVariable("root", expr, env); err != nil {
+			return err
+		}
+		args = args[1:]
+	}
+	f := bindReal("root", expr, name, env)
+	switch len(args) {
+	case 1:
+		x0, err := evalNumber("root", args[0], env)
+		if err != nil {
+			return err
+		}
+		x, err := newton(f, x0, math.Inf(-1), math.Inf(1))
+		if err != nil {
+			return err
+		}
+		return normalizeNumber(&object.Float{Value: polishRoot(f, x)})
+	case 2:
+		a, err := evalNumber("root", args[0], env)
+		if err != nil {
+			return err
+		}
+		b, err := evalNumber("root", args[1], env)
+		if err != nil {
+			return err
+		}
+		fa, err := f(a)
+		if err != nil {
+			return err
+		}
+		fb, err := f(b)
+		if err != nil {
+			return err
+		}
+		if fa*fb > 0 {
+			return newError("root: f(%v) and f(%v) must have opposite signs", a, b)
+		}
+		x, err := brent(f, a, b, fa, fb)
+		if err != nil {
+			return err
+		}
+		if isPole(f, x, fa, fb) {
+			return newError("root: f changes sign at a pole near %v, not at a root", x)
+		}
+		return normalizeNumber(&object.Float{Value: polishRoot(f, x)})
+	}
+	return newError("root: wrong number of arguments")
+}
+
+// allRoots samples f on a grid, refines every sign change with Brent's
+// method and every local minimum of |f| close to zero with Newton's
+// method, which catches double roots such as x^2 = 0. A function that is
+// 0 all over the grid, like that of x = x, is taken to be identically 0.
+func allRoots(f realFunc, name string, a, b float64) ([]float64, *object.Error) {
+	xs := make([]float64, solveSamples+1)
+	ys := make([]float64, solveSamples+1)
+	scale := 0.0
+	zero := true
+	var failed *object.Error
+	defined := false
+	for i := range xs {
+		xs[i] = a + (b-a)*float64(i)/solveSamples
+		y, err := f(xs[i])
+		if err != nil {
+			// outside the domain, as ln(x) is for x ≤ 0, f has no value
+			// to search; an error everywhere is reported
+			if failed == nil {
+				failed = err
+			}
+			y = math.NaN()
+		} else {
+			defined = true
+		}
+		ys[i] = y
+		if isFinite(y) {
+			scale = math.Max(scale, math.Abs(y))
+		}
+		zero = zero && y == 0
+	}
+	if !defined {
+		return nil, failed
+	}
+	if zero {
+		return nil, everySolution(name, a, b)
+	}
+	var roots []float64
+	for i := 0; i < solveSamples; i++ {
+		x0, x1, y0, y1 := xs[i], xs[i+1], ys[i], ys[i+1]
+		if !isFinite(y0) || !isFinite(y1) {
+			continue
+		}
+		switch {
+		case y0 == 0:
+			roots = append(roots, x0)
+		case y0*y1 < 0:
+			x, err := brent(f, x0, x1, y0, y1)
+			if err != nil {
+				return nil, err
+			}
+			if !isPole(f, x, y0, y1) {
+				roots = append(roots, x)
+			}
+		case i > 0 && isFinite(ys[i-1]) && math.Abs(y0) <= math.Abs(ys[i-1]) && math.Abs(y0) <= math.Abs(y1) && y0*ys[i-1] > 0:
+			x, err := newton(f, x0, xs[i-1], x1)
+			if err != nil {
+				continue
+			}
+			if y, _ := f(x); math.Abs(y) <= 1e-12*math.Max(1, scale) {
+				roots = append(roots, x)
+			}
+		}
+	}
+	if ys[solveSamples] == 0 {
+		roots = append(roots, b)
+	}
+	sort.Float64s(roots)
+	var result []float64
+	for _, r := range roots {
+		r = polishRoot(f, r)
+		if len(result) > 0 && math.Abs(r-result[len(result)-1]) <= 1e-9*math.Max(1, math.Abs(r)) {
+			continue
+		}
+		result = append(result, r)
+	}
+	return result, nil
+}
+
+// isPole reports whether the point x where f changes sign from fa to fb is
+// a pole rather than a root, f being far from 0 there.
+func isPole(f realFunc, x, fa, fb float64) bool {
+	y, _ := f(x)
+	return !(math.Abs(y) <= 1e-6*math.Max(1, math.Min(math.Abs(fa), math.Abs(fb))))
+}
+
+func everySolution(name string, a, b float64) *object.Error {
+	return newError("solve: every %s in [%v, %v] is a solution", name, a, b)
+}
+
+// polishRoot prefers a short decimal near x when f is at least as small
+// there, so the root of x^2 = 0 prints as 0 and not 1e-9.
+func polishRoot(f realFunc, x float64) float64 {
+	fx, err := f(x)
+	if err != nil {
+		return x
+	}
+	for digits := 0; digits <= 12; digits++ {
+		rounded, _ := strconv.ParseFloat(strconv.FormatFloat(x, 'f', digits, 64), 64)
+		if fr, err := f(rounded); err == nil && math.Abs(fr) <= math.Abs(fx) {
+			return rounded
+		}
+	}
+	return x
+}
+
+// brent finds a root in [a, b] given f(a) and f(b) of opposite signs.
+func brent(f realFunc, a, b, fa, fb float64) (float64, *object.Error) {
+	if fa == 0 {
+		return a, nil
+	}
+	if fb == 0 {
+		return b, nil
+	}
+	c, fc := a, fa
+	d := b - a
+	e := d
+	for i := 0; i < solveMaxIter; i++ {
+		if fb*fc > 0 {
+			c, fc = a, fa
+			d = b - a
+			e = d
+		}
+		if math.Abs(fc) < math.Abs(fb) {
+			a, b, c = b, c, b
+			fa, fb, fc = fb, fc, fb
+		}
+		tol := 2*1e-16*math.Abs(b) + 1e-300
+		m := (c - b) / 2
+		if math.Abs(m) <= tol || fb == 0 {
+			return b, nil
+		}
+		if math.Abs(e) >= tol && math.Abs(fa) > math.Abs(fb) {
+			// inverse quadratic interpolation, or secant with two points
+			var p, q float64
+			s := fb / fa
+			if a == c {
+				p = 2 * m * s
+				q = 1 - s
+			} else {
+				q = fa / fc
+				r := fb / fc
+				p = s * (2*m*q*(q-r) - (b-a)*(r-1))
+				q = (q - 1) * (r - 1) * (s - 1)
+			}
+			if p > 0 {
+				q = -q
+			} else {
+				p = -p
+			}
+			if 2*p < math.Min(3*m*q-math.Abs(tol*q), math.Abs(e*q)) {
+				e = d
+				d = p / q
+			} else {
+				d = m
+				e = m
+			}
+		} else {
+			d = m
+			e = m
+		}
+		a, fa = b, fb
+		if math.Abs(d) > tol {
+			b += d
+		} else {
+			b += math.Copysign(tol, m)
+		}
+		var err *object.Error
+		if fb, err = f(b); err != nil {
+			return 0, err
+		}
+	}
+	return b, nil
+}
+
+// newton iterates from x0 with a central difference derivative and gives
+// up when it leaves [lo, hi] or does not settle.
+func newton(f realFunc, x0, lo, hi float64) (float64, *object.Error) {
+	x := x0
+	for i := 0; i < solveMaxIter; i++ {
+		fx, err := f(x)
+		if err != nil {
+			return 0, err
+		}
+		if fx == 0 {
+			return x, nil
+		}
+		h := 1e-6 * math.Max(1, math.Abs(x))
+		fp, err := f(x + h)
+		if err != nil {
+			return 0, err
+		}
+		fm, err := f(x - h)
+		if err != nil {
+			return 0, err
+		}
+		slope := (fp - fm) / (2 * h)
+		if slope == 0 || !isFinite(slope) {
+			return 0, newError("root: zero derivative at %v", x)
+		}
+		next := x - fx/slope
+		if next < lo || next > hi || !isFinite(next) {
+			return 0, newError("root: Newton's method left the search interval")
+		}
+		if math.Abs(next-x) <= 1e-15*math.Max(1, math.Abs(x)) {
+			return next, nil
+		}
+		x = next
+	}
+	if fx, _ := f(x); math.Abs(fx) < 1e-10 {
+		return x, nil
+	}
+	return 0, newError("root: Newton's method did not converge from %v", x0)
+}
@@ -0,0 +1,100 @@
+package evaluator
+
+import (
+	"math"
+
+	"github.com/hellracer2007/webCalc/calculator/ast"
+	"github.com/hellracer2007/webCalc/calculator/object"
+)
+
+type specialForm func(node *ast.CallExpression, env *object.Environment) object.Object
+
+// specialForms receive their arguments unevaluated, so an expression like
+// the x^2 of integrate(x^2, x, 0, 1) can be evaluated many times with x
+// bound to different values.
+var specialForms map[string]specialForm
+
+func init() {
+	specialForms = map[string]specialForm{
+		"integrate":   evalIntegrate,
+		"solve":       evalSolve,
+		"root":        evalRoot,
+		"diff":        evalDiff,
+		"simplify":    evalSimplify,
+		"expand":      evalExpand,
+		"factor":      evalFactor,
+		"roots":       evalRoots,
+		"polydiv":     evalPolyDiv,
+		"polygcd":     evalPolyGCD,
+		"sum":         evalSum,
+		"Σ":           evalSum,
+		"∑":           evalSum,
+		"prod":        evalProduct,
+		"∏":           evalProduct,
+		"rand":        evalRand,
+		"randint":     evalRandInt,
+		"randn":       evalRandn,
+		"seed":        evalSeed,
+		"interval":    evalInterval,
+		"montecarlo":  evalMonteCarlo,
+		"uncertainty": evalUncertaintyOf,
+		"precision":   evalPrecision,
+	}
+}
+
+func checkArgs(node *ast.CallExpression, want int) *object.Error {
+	if len(node.Arguments) != want {
+		return newError("%s: wrong number of arguments. got=%d, want=%d", node.Func, len(node.Arguments), want)
+	}
+	return nil
+}
+
+// variableName returns the name of an argument that has to be a plain
+// identifier, such as the x in integrate(x^2, x, 0, 1).
+func variableName(fn string, arg ast.Expression) (string, *object.Error) {
+	ident, ok := arg.(*ast.Identifier)
+	if !ok {
+		return "", newError("%s: expected a variable name, got %s", fn, arg.String())
+	}
+	return ident.Value, nil
+}
+
+func evalNumber(fn string, arg ast.Expression, env *object.Environment) (float64, *object.Error) {
+	val := Eval(arg, env)
+	if err, ok := val.(*object.Error); ok {
+		return 0, err
+	}
+	f, ok := toFloat(val)
+	if !ok {
+		return 0, newError("%s: expected a number, got %s", fn, arg.String())
+	}
+	return f, nil
+}
+
+type realFunc func(x float64) (float64, *object.Error)
+
+// bindReal turns expr into a function of the variable name, evaluated in
+// an environment enclosed by env.
+func bindReal(fn string, expr ast.Expression, name string, env *object.Environment) realFunc {
+	expr = optimize(expr, name, env)
+	inner := object.NewEnclosedEnvironment(env)
+	return func(x float64) (float64, *object.Error) {
+		inner.Set(name, &object.Float{Value: x})
+		val := Eval(expr, inner)
+		if err, ok := val.(*object.Error); ok {
+			return 0, err
+		}
+		f, ok := toFloat(val)
+		if !ok {
+			if val == nil {
+				return 0, newError("%s: cannot evaluate %s", fn, expr.String())
+			}
+			return 0, newError("%s: %s is not a number at %s = %v", fn, expr.String(), name, x)
+		}
+		return f, nil
+	}
+}
+
+func isFinite(x float64) bool {
+	return !math.IsNaN(x) && !math.IsInf(x, 0)
+}
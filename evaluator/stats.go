@@ -0,0 +1,252 @@
+package evaluator
+
+import (
+	"math"
+	"sort"
+
+	"github.com/hellracer2007/webCalc/calculator/object"
+)
+
+func init() {
+	for name, fn := range map[string]func([]float64) object.Object{
+		"mean":      func(xs []float64) object.Object { m, _ := welford(xs); return number(m) },
+		"median":    func(xs []float64) object.Object { return number(quantile(sorted(xs), 0.5)) },
+		"mode":      mode,
+		"variance":  func(xs []float64) object.Object { return variance(xs, 1) },
+		"pvariance": func(xs []float64) object.Object { return variance(xs, 0) },
+		"stddev":    func(xs []float64) object.Object { return stddev(xs, 1) },
+		"pstddev":   func(xs []float64) object.Object { return stddev(xs, 0) },
+	} {
+		builtins[name] = sampleBuiltin(name, fn)
+	}
+	builtins["quantile"] = &object.Builtin{Fn: evalQuantile}
+	builtins["corr"] = pairedBuiltin("corr", func(xs, ys []float64) object.Object {
+		_, _, sxx, syy, sxy := comoments(xs, ys)
+		if sxx == 0 || syy == 0 {
+			return newError("corr: the correlation of constant data is undefined")
+		}
+		return number(clamp(sxy/math.Sqrt(sxx*syy), -1, 1))
+	})
+	builtins["linreg"] = pairedBuiltin("linreg", linreg)
+}
+
+// sampleBuiltin wraps a statistic of one sample. The sample is given
+// either as a single list or vector, mean({1, 2, 3}), or as the arguments
+// themselves, mean(1, 2, 3).
+func sampleBuiltin(name string, fn func([]float64) object.Object) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			xs, err := sample(name, args)
+			if err != nil {
+				return err
+			}
+			if len(xs) == 0 {
+				return newError("%s: the sample is empty", name)
+			}
+			return fn(xs)
+		},
+	}
+}
+
+// pairedBuiltin wraps a statistic of two samples of the same length.
+func pairedBuiltin(name string, fn func(xs, ys []float64) object.Object) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("%s: wrong number of arguments. got=%d, want=2", name, len(args))
+			}
+			xs, err := sample(name, args[:1])
+			if err != nil {
+				return err
+			}
+			ys, err := sample(name, args[1:])
+			if err != nil {
+				return err
+			}
+			if len(xs) != len(ys) {
+				return newError("%s: the samples differ in length, %d and %d", name, len(xs), len(ys))
+			}
+			if len(xs) < 2 {
+				return newError("%s: at least two data points are needed", name)
+			}
+			return fn(xs, ys)
+		},
+	}
+}
+
+// sample collects the numbers of args, the elements of lists and vectors
+// being taken one by one.
+func sample(name string, args []object.Object) ([]float64, *object.Error) {
+	var xs []float64
+	for _, arg := range args {
+		var elements []object.Object
+		switch arg := arg.(type) {
+		case *object.List:
+			elements = arg.Elements
+		case *object.Vector:
+			elements = arg.Elements
+		default:
+			elements = []object.Object{arg}
+		}
+		for _, el := range elements {
+			f, ok := toFloat(el)
+			if !ok {
+				return nil, newError("%s: expected numbers, got %s", name, el.Inspect())
+			}
+			if math.IsNaN(f) {
+				return nil, newError("%s: the sample contains NaN", name)
+			}
+			xs = append(xs, f)
+		}
+	}
+	return xs, nil
+}
+
+func number(f float64) object.Object {
+	return normalizeNumber(&object.Float{Value: f})
+}
+
+// welford returns the mean and the sum of squared deviations from it,
+// updated one value at a time so large offsets do not cancel the way
+// they do in the sum of squares minus the squared sum.
+func welford(xs []float64) (mean, m2 float64) {
+	for i, x := range xs {
+		delta := x - mean
+		mean += delta / float64(i+1)
+		m2 += delta * (x - mean)
+	}
+	return mean, m2
+}
+
+// variance divides by n - ddof, ddof being 1 for the sample and 0 for the
+// population variance.
+func variance(xs []float64, ddof int) object.Object {
+	if len(xs) <= ddof {
+		return newError("variance: at least %d data points are needed", ddof+1)
+	}
+	_, m2 := welford(xs)
+	return number(m2 / float64(len(xs)-ddof))
+}
+
+func stddev(xs []float64, ddof int) object.Object {
+	v := variance(xs, ddof)
+	if isError(v) {
+		return v
+	}
+	f, _ := toFloat(v)
+	return number(math.Sqrt(f))
+}
+
+func sorted(xs []float64) []float64 {
+	ys := append([]float64(nil), xs...)
+	sort.Float64s(ys)
+	return ys
+}
+
+// quantile interpolates linearly between the order statistics of the
+// sorted sample ys, the definition spreadsheets and R use by default.
+func quantile(ys []float64, p float64) float64 {
+	h := p * float64(len(ys)-1)
+	i := int(math.Floor(h))
+	if i >= len(ys)-1 {
+		return ys[len(ys)-1]
+	}
+	return ys[i] + (h-float64(i))*(ys[i+1]-ys[i])
+}
+
+// evalQuantile implements quantile(data, p, ...), one quantile for one p
+// and a list for several.
+func evalQuantile(args ...object.Object) object.Object {
+	if len(args) < 2 {
+		return newError("quantile: wrong number of arguments. got=%d, want at least 2", len(args))
+	}
+	xs, err := sample("quantile", args[:1])
+	if err != nil {
+		return err
+	}
+	if len(xs) == 0 {
+		return newError("quantile: the sample is empty")
+	}
+	ps, err := sample("quantile", args[1:])
+	if err != nil {
+		return err
+	}
+	ys := sorted(xs)
+	qs := make([]object.Object, len(ps))
+	for i, p := range ps {
+		if p < 0 || p > 1 {
+			return newError("quantile: the probability must be between 0 and 1, got %v", p)
+		}
+		qs[i] = number(quantile(ys, p))
+	}
+	if len(qs) == 1 {
+		return qs[0]
+	}
+	return &object.List{Elements: qs}
+}
+
+// mode returns the most frequent value, or the list of them when several
+// are equally frequent.
+func mode(xs []float64) object.Object {
+	counts := map[float64]int{}
+	most := 0
+	for _, x := range xs {
+		counts[x]++
+		if counts[x] > most {
+			most = counts[x]
+		}
+	}
+	var modes []float64
+	for x, n := range counts {
+		if n == most {
+			modes = append(modes, x)
+		}
+	}
+	sort.Float64s(modes)
+	if len(modes) == 1 {
+		return number(modes[0])
+	}
+	elements := make([]object.Object, len(modes))
+	for i, m := range modes {
+		elements[i] = number(m)
+	}
+	return &object.List{Elements: elements}
+}
+
+// comoments returns the means and the sums of squared and crossed
+// deviations of two samples in a single Welford pass.
+func comoments(xs, ys []float64) (mx, my, sxx, syy, sxy float64) {
+	for i := range xs {
+		n := float64(i + 1)
+		dx := xs[i] - mx
+		dy := ys[i] - my
+		mx += dx / n
+		my += dy / n
+		sxx += dx * (xs[i] - mx)
+		syy += dy * (ys[i] - my)
+		sxy += dx * (ys[i] - my)
+	}
+	return mx, my, sxx, syy, sxy
+}
+
+// linreg fits y = slope x + intercept by least squares and reports the
+// coefficient of determination r2.
+func linreg(xs, ys []float64) object.Object {
+	mx, my, sxx, syy, sxy := comoments(xs, ys)
+	if sxx == 0 {
+		return newError("linreg: the x values are all equal")
+	}
+	slope := sxy / sxx
+	r2 := 1.0
+	if syy != 0 {
+		r2 = clamp(sxy*sxy/(sxx*syy), 0, 1)
+	}
+	return &object.Tuple{
+		Names:    []string{"slope", "intercept", "r2"},
+		Elements: []object.Object{number(slope), number(my - slope*mx), number(r2)},
+	}
+}
+
+func clamp(x, lo, hi float64) float64 {
+	return math.Max(lo, math.Min(hi, x))
+}
@@ -0,0 +1,210 @@
+package evaluator
+
+import (
+	"math"
+
+	"github.com/hellracer2007/webCalc/calculator/ast"
+	"github.com/hellracer2007/webCalc/calculator/object"
+)
+
+const (
+	sumMaxTerms = 10000000
+	// an infinite series is accelerated from its first levinTerms terms,
+	// more make the transform unstable
+	levinTerms  = 20
+	levinTol    = 1e-8
+	sumProbeExp = 10
+)
+
+// evalSum implements sum(expr, k, a, b) and evalProduct prod(expr, k, a, b):
+// expr is evaluated for every integer k from a to b. Integers stay exact,
+// growing into big integers when needed. With b = ∞ the series is summed
+// numerically, after checking that its terms shrink fast enough.
+func evalSum(node *ast.CallExpression, env *object.Environment) object.Object {
+	return evalSeries(node, env, false)
+}
+
+func evalProduct(node *ast.CallExpression, env *object.Environment) object.Object {
+	return evalSeries(node, env, true)
+}
+
+func evalSeries(node *ast.CallExpression, env *object.Environment, product bool) object.Object {
+	if err := checkArgs(node, 4); err != nil {
+		return err
+	}
+	name, err := variableName(node.Func, node.Arguments[1])
+	if err != nil {
+		return err
+	}
+	a, err := evalNumber(node.Func, node.Arguments[2], env)
+	if err != nil {
+		return err
+	}
+	b, err := evalNumber(node.Func, node.Arguments[3], env)
+	if err != nil {
+		return err
+	}
+	if a != math.Trunc(a) || math.IsInf(a, 0) || (b != math.Trunc(b) && !math.IsInf(b, 1)) {
+		return newError("%s: the bounds must be integers, got %v and %v", node.Func, a, b)
+	}
+	inner := object.NewEnclosedEnvironment(env)
+	term := func(k int64) object.Object {
+		inner.Set(name, &object.Integer{Value: k})
+		return Eval(node.Arguments[0], inner)
+	}
+	if math.IsInf(b, 1) {
+		return infiniteSeries(node.Func, term, int64(a), product)
+	}
+	if b-a >= sumMaxTerms {
+		return newError("%s: too many terms, %v", node.Func, b-a+1)
+	}
+	operator := "+"
+	if product {
+		operator = "*"
+	}
+	if b < a {
+		if product {
+			return &object.Integer{Value: 1}
+		}
+		return &object.Integer{Value: 0}
+	}
+	var result object.Object
+	for k := int64(a); k <= int64(b); k++ {
+		val := term(k)
+		if isError(val) {
+			return val
+		}
+		if val == nil {
+			return newError("%s: cannot evaluate %s", node.Func, node.Arguments[0].String())
+		}
+		if result == nil {
+			// starting from the first term keeps units and vectors intact
+			result = val
+			continue
+		}
+		l, r := normalizeExpr(result, val)
+		result = evalInfixExpression(operator, l, r)
+		if isError(result) {
+			return result
+		}
+	}
+	return result
+}
+
+// infiniteSeries sums the terms from k = a on. A product is the
+// exponential of the sum of the logarithms of its terms.
+func infiniteSeries(fn string, term func(int64) object.Object, a int64, product bool) object.Object {
+	value := func(k int64) (float64, *object.Error) {
+		val := term(k)
+		if err, ok := val.(*object.Error); ok {
+			return 0, err
+		}
+		f, ok := toFloat(val)
+		if !ok {
+			return 0, newError("%s: the terms of an infinite series must be numbers", fn)
+		}
+		if product {
+			if f <= 0 {
+				return 0, newError("%s: an infinite product needs positive terms, got %v", fn, f)
+			}
+			f = math.Log(f)
+		}
+		if !isFinite(f) {
+			return 0, newError("%s: term %d is not finite", fn, k)
+		}
+		return f, nil
+	}
+	if err := checkConvergence(fn, value, a); err != nil {
+		return err
+	}
+	terms := make([]float64, levinTerms)
+	for i := range terms {
+		f, err := value(a + int64(i))
+		if err != nil {
+			return err
+		}
+		terms[i] = f
+	}
+	s, ok := levin(terms)
+	if !ok {
+		return newError("%s: the series converges too slowly to be evaluated", fn)
+	}
+	if product {
+		s = math.Exp(s)
+	}
+	return normalizeNumber(&object.Float{Value: s})
+}
+
+// checkConvergence rejects series whose terms do not shrink like a
+// convergent series: terms that keep their sign must fall faster than
+// 1/k, alternating terms must at least tend to zero.
+func checkConvergence(fn string, value func(int64) (float64, *object.Error), a int64) *object.Error {
+	k := a + 1<<sumProbeExp
+	t1, err := value(k)
+	if err != nil {
+		return err
+	}
+	t2, err := value(2 * k)
+	if err != nil {
+		return err
+	}
+	next, err := value(k + 1)
+	if err != nil {
+		return err
+	}
+	if t1 == 0 && t2 == 0 {
+		return nil
+	}
+	ratio := math.Abs(t2 / t1)
+	alternating := t1*next < 0
+	if ratio >= 1 || (!alternating && ratio >= 0.5) {
+		return newError("%s: the series does not converge", fn)
+	}
+	return nil
+}
+
+// levin accelerates the partial sums of terms with the Levin u-transform,
+// which handles alternating as well as slowly converging series. The
+// estimate with the smallest change from its predecessor is returned,
+// ok reports whether that change was small enough to trust.
+func levin(terms []float64) (float64, bool) {
+	partial := make([]float64, len(terms))
+	s := 0.0
+	for i, t := range terms {
+		s += t
+		partial[i] = s
+	}
+	// terms that vanish exactly end the series
+	last := len(terms) - 1
+	for last > 0 && terms[last] == 0 {
+		last--
+	}
+	if last < 2 {
+		return partial[len(partial)-1], true
+	}
+	best, bestDiff := math.NaN(), math.Inf(1)
+	prev := math.NaN()
+	for k := 1; k <= last; k++ {
+		num, den := 0.0, 0.0
+		binomial := 1.0
+		for j := 0; j <= k; j++ {
+			if terms[j] == 0 {
+				// the weights divide by the terms
+				return math.NaN(), false
+			}
+			w := binomial * math.Pow(float64(1+j)/float64(1+k), float64(k-1)) / (float64(1+j) * terms[j])
+			if j%2 == 1 {
+				w = -w
+			}
+			num += w * partial[j]
+			den += w
+			binomial = binomial * float64(k-j) / float64(j+1)
+		}
+		estimate := num / den
+		if diff := math.Abs(estimate - prev); diff < bestDiff {
+			best, bestDiff = estimate, diff
+		}
+		prev = estimate
+	}
+	return best, isFinite(best) && bestDiff <= levinTol*math.Max(1, math.Abs(best))
+}
@@ -0,0 +1,281 @@
+package evaluator
+
+import (
+	"math"
+	"sync/atomic"
+
+	"github.com/hellracer2007/webCalc/calculator/ast"
+	"github.com/hellracer2007/webCalc/calculator/constants"
+	"github.com/hellracer2007/webCalc/calculator/object"
+	"github.com/hellracer2007/webCalc/calculator/symbolic"
+	"github.com/hellracer2007/webCalc/calculator/token"
+)
+
+// monteCarloSamples is the number of evaluations of montecarlo unless
+// given.
+const monteCarloSamples = 10000
+
+// uncertaintySources numbers the independent sources of uncertainty, every
+// evaluation of x ± s adds one.
+var uncertaintySources atomic.Int64
+
+// Uncertainties are propagated to first order: the share of a source in
+// f(x, y) is the partial derivative of f times the share in x plus the
+// one in y. This is exact for sums and good while the uncertainties are
+// small against the curvature of f, montecarlo does without that
+// assumption.
+
+// evalPlusMinus implements x ± s, the value x with the standard
+// uncertainty s from a new independent source.
+func evalPlusMinus(left, right object.Object) object.Object {
+	s, ok := toFloat(right)
+	if !ok {
+		return newError("the uncertainty after ± must be a plain number, got %s", right.Inspect())
+	}
+	if s < 0 || !isFinite(s) {
+		return newError("the uncertainty after ± must not be negative, got %v", s)
+	}
+	x, ok := toUncertain(left)
+	if !ok {
+		return newError("± needs a plain number before it, got %s", left.Inspect())
+	}
+	terms := make(map[int64]float64, len(x.Terms)+1)
+	for id, t := range x.Terms {
+		terms[id] = t
+	}
+	terms[uncertaintySources.Add(1)] = s
+	return &object.Uncertain{Value: x.Value, Terms: terms}
+}
+
+// toUncertain reads a plain number as a value without uncertainty.
+func toUncertain(obj object.Object) (*object.Uncertain, bool) {
+	if u, ok := obj.(*object.Uncertain); ok {
+		return u, true
+	}
+	f, ok := toFloat(obj)
+	if !ok {
+		return nil, false
+	}
+	return &object.Uncertain{Value: f}, true
+}
+
+// propagate returns value with the shares of l and r weighted by the
+// partial derivatives dl and dr.
+func propagate(value float64, l *object.Uncertain, dl float64, r *object.Uncertain, dr float64) *object.Uncertain {
+	terms := make(map[int64]float64, len(l.Terms)+len(r.Terms))
+	for id, t := range l.Terms {
+		terms[id] += dl * t
+	}
+	for id, t := range r.Terms {
+		terms[id] += dr * t
+	}
+	return &object.Uncertain{Value: value, Terms: terms}
+}
+
+func evalUncertainInfixExpression(operator string, left, right object.Object) object.Object {
+	// the magnitude of a quantity is a plain float, (1 ± 0.1) m has no
+	// place to keep its uncertainty
+	if left.Type() == object.QUANTITY_OBJ || right.Type() == object.QUANTITY_OBJ {
+		return newError("± values with units are not supported, got (%s) %s (%s)", left.Inspect(), operator, right.Inspect())
+	}
+	l, ok := toUncertain(left)
+	if !ok {
+		return newError("unsupported operands for %s: %s and %s", operator, left.Type(), right.Type())
+	}
+	r, ok := toUncertain(right)
+	if !ok {
+		return newError("unsupported operands for %s: %s and %s", operator, left.Type(), right.Type())
+	}
+	a, b := l.Value, r.Value
+	switch operator {
+	case "+":
+		return propagate(a+b, l, 1, r, 1)
+	case "-":
+		return propagate(a-b, l, 1, r, -1)
+	case "*":
+		return propagate(a*b, l, b, r, a)
+	case "/":
+		if b == 0 {
+			return newError("division by zero")
+		}
+		return propagate(a/b, l, 1/b, r, -a/(b*b))
+	case "^":
+		if len(r.Terms) > 0 && a <= 0 {
+			return newError("an uncertain exponent needs a positive base, got %v", a)
+		}
+		v := math.Pow(a, b)
+		return propagate(v, l, b*math.Pow(a, b-1), r, v*math.Log(a))
+	case "E":
+		p := math.Pow(10, b)
+		return propagate(a*p, l, p, r, a*p*math.Ln10)
+	}
+	return newError("operator %s is not supported for uncertain values", operator)
+}
+
+// evalUncertainProcedure applies a procedure to the value and scales the
+// uncertainty by its derivative, found by the rules of diff.
+func evalUncertainProcedure(proc string, x *object.Uncertain) object.Object {
+	result := evalProcedure(proc, &object.Float{Value: x.Value})
+	if isError(result) {
+		return result
+	}
+	value, ok := toFloat(result)
+	if !ok {
+		return newError("%s: expected a number, got %s", proc, result.Inspect())
+	}
+	variable := &ast.Identifier{Token: token.Token{Type: token.IDENT, Literal: "x"}, Value: "x"}
+	body := &ast.Procedure{Token: token.Token{Type: token.PROC, Literal: proc}, Func: proc, Body: variable}
+	derivative, err := symbolic.Derivative(body, "x")
+	if err != nil {
+		return newError("%s", err)
+	}
+	env := object.NewEnvironment()
+	env.Set("x", &object.Float{Value: x.Value})
+	d, errObj := evalNumber(proc, derivative, env)
+	if errObj != nil {
+		return errObj
+	}
+	return propagate(value, x, d, &object.Uncertain{}, 0)
+}
+
+// hasUncertain reports whether any of args is an uncertain value.
+func hasUncertain(args []object.Object) bool {
+	for _, arg := range args {
+		if arg.Type() == object.UNCERTAIN_OBJ {
+			return true
+		}
+	}
+	return false
+}
+
+// stepBuiltins lists the builtins that are constant between jumps, their
+// zero derivative would turn any uncertainty into ± 0.
+var stepBuiltins = map[string]bool{"round": true}
+
+// applyUncertain calls a builtin on the values of uncertain arguments and
+// propagates their uncertainties with partial derivatives taken by
+// central differences.
+func applyUncertain(name string, builtin *object.Builtin, args []object.Object) object.Object {
+	values := make([]object.Object, len(args))
+	for i, arg := range args {
+		values[i] = arg
+		if u, ok := arg.(*object.Uncertain); ok {
+			values[i] = &object.Float{Value: u.Value}
+		}
+	}
+	call := func() (float64, *object.Error) {
+		result := builtin.Fn(values...)
+		if err, ok := result.(*object.Error); ok {
+			return 0, err
+		}
+		f, ok := toFloat(result)
+		if !ok {
+			return 0, newError("%s: uncertain arguments need a function with a numeric value, got %s", name, result.Inspect())
+		}
+		return f, nil
+	}
+	f, err := call()
+	if err != nil {
+		return err
+	}
+	result := &object.Uncertain{Value: f}
+	for i, arg := range args {
+		u, ok := arg.(*object.Uncertain)
+		if !ok || len(u.Terms) == 0 {
+			continue
+		}
+		if stepBuiltins[name] {
+			return newError("%s: a step function drops the uncertainty of %s", name, u.Inspect())
+		}
+		// the errors at the shifted values would name them, not the
+		// value the user gave
+		h := math.Cbrt(0x1p-52) * math.Max(math.Abs(u.Value), 1)
+		values[i] = &object.Float{Value: u.Value + h}
+		fp, err := call()
+		if err != nil {
+			return newError("%s: no derivative at %s to propagate its uncertainty", name, u.Inspect())
+		}
+		values[i] = &object.Float{Value: u.Value - h}
+		fm, err := call()
+		if err != nil {
+			return newError("%s: no derivative at %s to propagate its uncertainty", name, u.Inspect())
+		}
+		values[i] = &object.Float{Value: u.Value}
+		result = propagate(f, result, 1, u, (fp-fm)/(2*h))
+	}
+	return result
+}
+
+// evalMonteCarlo implements montecarlo(expr) and montecarlo(expr, n). It
+// evaluates expr n times with every x ± s drawn from the normal
+// distribution around x with standard deviation s, and returns the mean
+// of the results with their standard deviation.
+func evalMonteCarlo(node *ast.CallExpression, env *object.Environment) object.Object {
+	if len(node.Arguments) != 1 && len(node.Arguments) != 2 {
+		return newError("montecarlo: wrong number of arguments. got=%d, want=1 or 2", len(node.Arguments))
+	}
+	n := int64(monteCarloSamples)
+	if len(node.Arguments) == 2 {
+		count := Eval(node.Arguments[1], env)
+		if isError(count) {
+			return count
+		}
+		xs, err := integers(node.Func, []object.Object{count})
+		if err != nil {
+			return err
+		}
+		if n = xs[0]; n < 2 || n > maxRandomCount {
+			return newError("montecarlo: the number of samples must be between 2 and %d, got %d", maxRandomCount, n)
+		}
+	}
+	inner := object.NewEnclosedEnvironment(env)
+	inner.SetSamplingMode(true)
+	xs := make([]float64, n)
+	for i := range xs {
+		f, err := evalNumber(node.Func, node.Arguments[0], inner)
+		if err != nil {
+			return err
+		}
+		xs[i] = f
+	}
+	mean, m2 := welford(xs)
+	sigma := math.Sqrt(m2 / float64(n-1))
+	return &object.Uncertain{Value: mean, Terms: map[int64]float64{uncertaintySources.Add(1): sigma}}
+}
+
+// samplePlusMinus draws x ± s for montecarlo.
+func samplePlusMinus(left, right object.Object, env *object.Environment) object.Object {
+	x, ok := toFloat(left)
+	if !ok {
+		return newError("± needs a plain number before it, got %s", left.Inspect())
+	}
+	s, ok := toFloat(right)
+	if !ok || s < 0 {
+		return newError("the uncertainty after ± must be a plain number that is not negative, got %s", right.Inspect())
+	}
+	return &object.Float{Value: x + s*env.Rand().NormFloat64()}
+}
+
+// evalUncertaintyOf implements uncertainty(x), the standard uncertainty of
+// a ± value or of a physical constant such as G, in the constant's unit.
+// The constants themselves evaluate to their recommended value.
+func evalUncertaintyOf(node *ast.CallExpression, env *object.Environment) object.Object {
+	if err := checkArgs(node, 1); err != nil {
+		return err
+	}
+	if ident, ok := node.Arguments[0].(*ast.Identifier); ok {
+		if _, bound := env.Get(ident.Value); !bound {
+			if c, ok := constants.Lookup(ident.Value); ok {
+				return evalConstant(&constants.Constant{Name: c.Name, Value: c.Uncertainty, Unit: c.Unit})
+			}
+		}
+	}
+	x := Eval(node.Arguments[0], env)
+	if isError(x) {
+		return x
+	}
+	if u, ok := x.(*object.Uncertain); ok {
+		return normalizeNumber(&object.Float{Value: u.Sigma()})
+	}
+	return newError("uncertainty: expected a ± value or a constant, got %s", x.Inspect())
+}
@@ -0,0 +1,104 @@
+package evaluator
+
+import (
+	"github.com/hellracer2007/webCalc/calculator/object"
+)
+
+func isScalar(obj object.Object) bool {
+	switch obj.Type() {
+	case object.INTEGER_OBJ, object.BIGINT_OBJ, object.DECIMAL_OBJ, object.FLOAT_OBJ, object.QUANTITY_OBJ, object.INTERVAL_OBJ, object.UNCERTAIN_OBJ, object.BIGFLOAT_OBJ:
+		return true
+	}
+	return false
+}
+
+func broadcast(scalar object.Object, n int) *object.Vector {
+	elements := make([]object.Object, n)
+	for i := range elements {
+		elements[i] = scalar
+	}
+	return &object.Vector{Elements: elements}
+}
+
+// mapVector applies fn to every element, stopping at the first error.
+func mapVector(v *object.Vector, fn func(object.Object) object.Object) object.Object {
+	elements := make([]object.Object, len(v.Elements))
+	for i, el := range v.Elements {
+		res := fn(el)
+		if res == nil {
+			return newError("unsupported vector element %s", el.Inspect())
+		}
+		if isError(res) {
+			return res
+		}
+		elements[i] = res
+	}
+	return &object.Vector{Elements: elements}
+}
+
+func evalVectorInfixExpression(operator string, left, right object.Object) object.Object {
+	l := left.(*object.Vector)
+	r := right.(*object.Vector)
+	if len(l.Elements) != len(r.Elements) {
+		return newError("vector length mismatch: %d %s %d", len(l.Elements), operator, len(r.Elements))
+	}
+	elements := make([]object.Object, len(l.Elements))
+	for i := range l.Elements {
+		a, b := normalizeExpr(l.Elements[i], r.Elements[i])
+		res := evalInfixExpression(operator, a, b)
+		if res == nil {
+			return newError("unsupported operator %s for %s and %s", operator, a.Type(), b.Type())
+		}
+		if isError(res) {
+			return res
+		}
+		elements[i] = res
+	}
+	return &object.Vector{Elements: elements}
+}
+
+func dot(a, b *object.Vector) object.Object {
+	if len(a.Elements) != len(b.Elements) {
+		return newError("dot: vector length mismatch: %d and %d", len(a.Elements), len(b.Elements))
+	}
+	products := evalVectorInfixExpression("*", a, b)
+	if isError(products) {
+		return products
+	}
+	var sum object.Object = &object.Integer{Value: 0}
+	for _, p := range products.(*object.Vector).Elements {
+		l, r := normalizeExpr(sum, p)
+		sum = evalInfixExpression("+", l, r)
+		if isError(sum) {
+			return sum
+		}
+	}
+	return sum
+}
+
+func cross(a, b *object.Vector) object.Object {
+	if len(a.Elements) != 3 || len(b.Elements) != 3 {
+		return newError("cross: both vectors need 3 elements, got %d and %d", len(a.Elements), len(b.Elements))
+	}
+	component := func(i, j int) object.Object {
+		l, r := normalizeExpr(a.Elements[i], b.Elements[j])
+		x := evalInfixExpression("*", l, r)
+		l, r = normalizeExpr(a.Elements[j], b.Elements[i])
+		y := evalInfixExpression("*", l, r)
+		if isError(x) {
+			return x
+		}
+		if isError(y) {
+			return y
+		}
+		l, r = normalizeExpr(x, y)
+		return evalInfixExpression("-", l, r)
+	}
+	elements := []object.Object{component(1, 2), component(2, 0), component(0, 1)}
+	for _, el := range elements {
+		if isError(el) {
+			return el
+		}
+	}
+	return &object.Vector{Elements: elements}
+}
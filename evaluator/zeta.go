@@ -0,0 +1,310 @@
+package evaluator
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/hellracer2007/webCalc/calculator/object"
+)
+
+const (
+	// maxBernoulliZeta bounds the m for which ζ(-m) is found from the
+	// Bernoulli numbers, beyond it the values overflow floats anyway.
+	maxBernoulliZeta = 300
+	// borweinTerms is the n of Borwein's algorithm for floats, whose
+	// error falls like (3 + √8)^-n.
+	borweinTerms = 24
+	// maxPolylogOrder bounds the order n of polylog(n, z).
+	maxPolylogOrder = 100
+)
+
+func init() {
+	builtins["zeta"] = numericBuiltin("zeta", 1, func(xs []float64) object.Object {
+		if xs[0] == 1 {
+			return newError("zeta: pole at s = 1")
+		}
+		return finite("zeta", zeta(xs[0]))
+	})
+	preciseBuiltins["zeta"] = preciseBuiltin("zeta", 1, func(prec uint, xs []*big.Float) (*big.Float, *object.Error) {
+		if xs[0].Cmp(big.NewFloat(1)) == 0 {
+			return nil, newError("zeta: pole at s = 1")
+		}
+		return bigZeta(xs[0], prec), nil
+	})
+	polylog := preciseBuiltin("polylog", 2, func(prec uint, xs []*big.Float) (*big.Float, *object.Error) {
+		if !xs[0].IsInt() {
+			return nil, orderError("polylog", maxPolylogOrder, xs[0].Text('g', 10))
+		}
+		order, _ := xs[0].Float64()
+		n, err := polylogArgs(order, xs[1])
+		if err != nil {
+			return nil, err
+		}
+		return bigPolylog(n, xs[1], prec), nil
+	})
+	builtins["polylog"] = floatBuiltin("polylog", polylog)
+	preciseBuiltins["polylog"] = polylog
+}
+
+// zeta is the Riemann zeta function ζ(s) for s ≠ 1, by Borwein's
+// algorithm for s ≥ 0 and the reflection formula below.
+func zeta(s float64) float64 {
+	if s <= 0 && s == math.Trunc(s) && s >= -maxBernoulliZeta {
+		z, _ := zetaNegative(int(-s)).Float64()
+		return z
+	}
+	if s < 0 {
+		// ζ(s) = 2^s π^(s-1) sin(πs/2) Γ(1-s) ζ(1-s)
+		lg, _ := math.Lgamma(1 - s)
+		return math.Exp(s*math.Ln2+(s-1)*math.Log(math.Pi)+lg) * math.Sin(math.Pi*s/2) * zeta(1-s)
+	}
+	n := borweinTerms
+	d := make([]float64, n+1)
+	term, sum := 1.0, 1.0
+	d[0] = 1
+	for i := 0; i < n; i++ {
+		term *= 2 * float64((n+i)*(n-i)) / float64((2*i+1)*(i+1))
+		sum += term
+		d[i+1] = sum
+	}
+	var z float64
+	for k := 0; k < n; k++ {
+		t := (d[k] - d[n]) / math.Pow(float64(k+1), s)
+		if k%2 != 0 {
+			t = -t
+		}
+		z += t
+	}
+	return z / (d[n] * math.Expm1((1-s)*math.Ln2))
+}
+
+// zetaNegative is ζ(-m) = (-1)^m B_(m+1) / (m+1), exactly.
+func zetaNegative(m int) *big.Rat {
+	z := bernoulli(m + 1)
+	z.Quo(z, big.NewRat(int64(m+1), 1))
+	if m%2 != 0 {
+		z.Neg(z)
+	}
+	return z
+}
+
+// bigZeta is ζ(s) for s ≠ 1 like zeta. Borwein's algorithm takes its
+// coefficients d_k exactly, and n to the precision.
+func bigZeta(s *big.Float, prec uint) *big.Float {
+	sf, _ := s.Float64()
+	if s.IsInt() && s.Sign() <= 0 && sf >= -maxBernoulliZeta {
+		return newBig(prec).SetRat(zetaNegative(int(-sf)))
+	}
+	work := prec + guardBits
+	if s.Sign() < 0 {
+		// ζ(s) = 2^s π^(s-1) sin(πs/2) Γ(1-s) ζ(1-s)
+		one := bigInt(1, work)
+		t := newBig(work).Sub(one, s)
+		z := bigZeta(t, work)
+		z.Mul(z, bigExp(bigLnGamma(t, work), work))
+		z.Mul(z, bigPow(bigInt(2, work), s, work))
+		z.Mul(z, bigPow(bigPi(work), t.Neg(t), work))
+		half := newBig(work).Set(s)
+		half.SetMantExp(half, -1)
+		z.Mul(z, bigSinPi(half, work))
+		return newBig(prec).Set(z)
+	}
+	// near s = 1 the sum cancels down to ζ(s) (1 - 2^(1-s))
+	if c := math.Abs(1 - math.Pow(2, 1-sf)); c < 1 {
+		work += uint(-math.Log2(c))
+	}
+	n := int64(math.Ceil(float64(work)/math.Log2(3+math.Sqrt(8)))) + 1
+	d := make([]*big.Int, n+1)
+	term, sum := big.NewInt(1), big.NewInt(1)
+	d[0] = new(big.Int).Set(sum)
+	for i := int64(0); i < n; i++ {
+		// the terms n (n+i-1)! 4^i / ((n-i)! (2i)!) are whole
+		term.Mul(term, big.NewInt(2*(n+i)*(n-i)))
+		term.Quo(term, big.NewInt((2*i+1)*(i+1)))
+		sum.Add(sum, term)
+		d[i+1] = new(big.Int).Set(sum)
+	}
+	z := newBig(work)
+	for k := int64(0); k < n; k++ {
+		t := newBig(work).SetInt(new(big.Int).Sub(d[k], d[n]))
+		t.Quo(t, bigPow(bigInt(k+1, work), s, work))
+		if k%2 != 0 {
+			t.Neg(t)
+		}
+		z.Add(z, t)
+	}
+	one := bigInt(1, work)
+	c := bigPow(bigInt(2, work), newBig(work).Sub(one, s), work)
+	c.Sub(one, c)
+	c.Mul(c, newBig(work).SetInt(d[n]))
+	z.Quo(z, c)
+	return newBig(prec).Neg(z)
+}
+
+// polylogArgs checks the order n and the argument z of polylog(n, z). The
+// values for n ≥ 1 and z > 1 are complex; for n ≤ 0 the function is
+// rational in z with a pole at 1.
+func polylogArgs(order float64, z *big.Float) (int, *object.Error) {
+	if order != math.Trunc(order) || math.Abs(order) > maxPolylogOrder {
+		return 0, orderError("polylog", maxPolylogOrder, fmt.Sprint(order))
+	}
+	n := int(order)
+	switch c := z.Cmp(big.NewFloat(1)); {
+	case c > 0 && n >= 1:
+		return 0, newError("polylog: z must be at most 1 for n ≥ 1, got %s", z.Text('g', 10))
+	case c == 0 && n <= 1:
+		return 0, newError("polylog: pole at z = 1 for n = %d", n)
+	}
+	return n, nil
+}
+
+// bigPolylog is the polylogarithm Li_n(z) = Σ z^k / k^n, for n ≤ 0 exactly
+// and for n ≥ 1 by its series for |z| ≤ 1/2 and by identities beyond.
+func bigPolylog(n int, z *big.Float, prec uint) *big.Float {
+	work := prec + guardBits
+	switch {
+	case n <= 0:
+		return newBig(prec).SetRat(polylogRational(-n, z))
+	case n == 1:
+		// -ln(1 - z)
+		l := bigLog(newBig(work).Sub(bigInt(1, work), z), work)
+		return newBig(prec).Neg(l)
+	case z.Sign() == 0:
+		return newBig(prec)
+	case z.Cmp(big.NewFloat(1)) == 0:
+		return bigZeta(bigInt(int64(n), work), prec)
+	}
+	zf, _ := z.Float64()
+	var li *big.Float
+	switch {
+	case math.Abs(zf) <= 0.5:
+		li = polylogSeries(n, z, work)
+	case zf > 0:
+		li = polylogNearOne(n, z, work)
+	case zf >= -1:
+		// Li_n(z) = 2^(1-n) Li_n(z²) - Li_n(-z)
+		li = bigPolylog(n, newBig(work).Mul(z, z), work)
+		li.SetMantExp(li, 1-n)
+		li.Sub(li, bigPolylog(n, newBig(work).Neg(z), work))
+	default:
+		li = polylogInversion(n, z, work)
+	}
+	return newBig(prec).Set(li)
+}
+
+// polylogRational is Li_-m(z) = Σ_{k≤m} k! S(m+1, k+1) w^(k+1) with
+// w = z / (1 - z) and the Stirling numbers of the second kind S.
+func polylogRational(m int, z *big.Float) *big.Rat {
+	zr, _ := z.Rat(nil)
+	w := new(big.Rat).Sub(big.NewRat(1, 1), zr)
+	w.Quo(zr, w)
+	// row m+1 of S(j, i) = i S(j-1, i) + S(j-1, i-1)
+	s := []*big.Int{big.NewInt(1)}
+	for j := 1; j <= m+1; j++ {
+		row := make([]*big.Int, j+1)
+		row[0] = new(big.Int)
+		for i := 1; i <= j; i++ {
+			row[i] = new(big.Int)
+			if i < j {
+				row[i].Mul(big.NewInt(int64(i)), s[i])
+			}
+			row[i].Add(row[i], s[i-1])
+		}
+		s = row
+	}
+	li := new(big.Rat)
+	power := new(big.Rat).Set(w)
+	factorial := big.NewInt(1)
+	for k := 0; k <= m; k++ {
+		if k > 0 {
+			factorial.Mul(factorial, big.NewInt(int64(k)))
+			power.Mul(power, w)
+		}
+		c := new(big.Int).Mul(factorial, s[k+1])
+		li.Add(li, new(big.Rat).Mul(new(big.Rat).SetInt(c), power))
+	}
+	return li
+}
+
+// polylogSeries sums Li_n(z) = Σ z^k / k^n for |z| ≤ 1/2.
+func polylogSeries(n int, z *big.Float, prec uint) *big.Float {
+	power := newBig(prec).Set(z)
+	sum := newBig(prec).Set(z)
+	for k := int64(2); ; k++ {
+		power.Mul(power, z)
+		term := newBig(prec).Quo(power, bigPowInt(bigInt(k, prec), int64(n), prec))
+		if negligible(term, sum, prec) {
+			break
+		}
+		sum.Add(sum, term)
+	}
+	return sum
+}
+
+// polylogNearOne is Li_n(z) for 1/2 < z < 1 from the expansion in
+// μ = ln z, which converges for |μ| < 2π:
+// Li_n(e^μ) = Σ_{k≠n-1} ζ(n-k) μ^k/k! + μ^(n-1)/(n-1)! (H_(n-1) - ln(-μ)).
+func polylogNearOne(n int, z *big.Float, prec uint) *big.Float {
+	mu := bigLog(z, prec)
+	sum := newBig(prec)
+	power := bigInt(1, prec) // μ^k / k!
+	for k := 0; ; k++ {
+		if k > 0 {
+			power.Mul(power, mu)
+			power.Quo(power, bigInt(int64(k), prec))
+		}
+		var term *big.Float
+		switch {
+		case k == n-1:
+			// H_(n-1) - ln(-μ)
+			h := bigLog(newBig(prec).Neg(mu), prec)
+			h.Neg(h)
+			for i := 1; i < n; i++ {
+				h.Add(h, newBig(prec).Quo(bigInt(1, prec), bigInt(int64(i), prec)))
+			}
+			term = h.Mul(h, power)
+		case k < n-1:
+			term = bigZeta(bigInt(int64(n-k), prec), prec)
+			term.Mul(term, power)
+		default:
+			term = newBig(prec).SetRat(zetaNegative(k - n))
+			term.Mul(term, power)
+		}
+		sum.Add(sum, term)
+		// ζ(n-k) vanishes for every other k past n, so look at pairs
+		if k > n && (k-n)%2 == 1 && negligible(term, sum, prec) {
+			break
+		}
+	}
+	return sum
+}
+
+// polylogInversion is Li_n(z) for z < -1 from Li_n(1/z), with y = -z:
+// Li_n(z) = -(-1)^n Li_n(1/z) - ln^n(y)/n!
+// + 2 Σ_{k≤n/2} ln^(n-2k)(y)/(n-2k)! Li_2k(-1), Li_2k(-1) = -(1 - 2^(1-2k)) ζ(2k).
+func polylogInversion(n int, z *big.Float, prec uint) *big.Float {
+	l := bigLog(newBig(prec).Neg(z), prec)
+	// powers[j] = ln^j(y) / j!
+	powers := make([]*big.Float, n+1)
+	powers[0] = bigInt(1, prec)
+	for j := 1; j <= n; j++ {
+		powers[j] = newBig(prec).Mul(powers[j-1], l)
+		powers[j].Quo(powers[j], bigInt(int64(j), prec))
+	}
+	li := bigPolylog(n, newBig(prec).Quo(bigInt(1, prec), z), prec)
+	if n%2 == 0 {
+		li.Neg(li)
+	}
+	li.Sub(li, powers[n])
+	for k := 1; 2*k <= n; k++ {
+		c := bigInt(1, prec)
+		c.SetMantExp(c, 1-2*k)
+		c.Sub(bigInt(1, prec), c)
+		c.Mul(c, bigZeta(bigInt(int64(2*k), prec), prec))
+		c.Mul(c, powers[n-2*k])
+		c.SetMantExp(c, 1)
+		li.Sub(li, c)
+	}
+	return li
+}
@@ -1,11 +1,22 @@
 package lexer
 
 import (
-	"fmt"
+	"regexp"
+	"strings"
 	"unicode"
 	"unicode/utf8"
 
 	"github.com/hellracer2007/webCalc/calculator/token"
+	"github.com/hellracer2007/webCalc/calculator/units"
+)
+
+var (
+	// dateLiteral is an ISO 8601 calendar date, optionally with the time
+	// of day. Only valid months and days are dates, so 2026-13-01 is
+	// still a subtraction.
+	dateLiteral = regexp.MustCompile(`^\d{4}-(0[1-9]|1[0-2])-(0[1-9]|[12]\d|3[01])(T([01]\d|2[0-3]):[0-5]\d(:[0-5]\d(\.\d+)?)?)?`)
+	// durationLiteral is an ISO 8601 duration such as P1Y2M10DT2H30M.
+	durationLiteral = regexp.MustCompile(`^P(\d+Y)?(\d+M)?(\d+W)?(\d+D)?(T(\d+H)?(\d+M)?(\d+(\.\d+)?S)?)?`)
 )
 
 type Lexer struct {
@@ -13,6 +24,9 @@ type Lexer struct {
 	position	 int
 	readPosition int
 	ch			 byte
+	// prev is the type of the last token read, a word after a number is
+	// read as a unit
+	prev		 token.TokenType
 }
 
 func New(input string) *Lexer{
@@ -32,22 +46,57 @@ func (l *Lexer) readChar(){
 }
 
 func (l *Lexer) NextToken() token.Token {
+	tok := l.nextToken()
+	l.prev = tok.Type
+	return tok
+}
+
+// quantityFollows reports whether a unit read now belongs to a quantity or
+// a conversion, as in 3 m/s^2, 3 N m, (1 ± 0.1) m and 60 km/h to m/s. Elsewhere
+// a word such as the h of h^2 or the t of integrate(t^2, t, 0, 1) is a
+// name, looked up in the environment and the constants before the units.
+func (l *Lexer) quantityFollows() bool {
+	switch l.prev {
+	case token.INT, token.FLOAT, token.RPAREN, token.TO, token.UNIT:
+		return true
+	}
+	return false
+}
+
+func (l *Lexer) nextToken() token.Token {
 	var tok token.Token
+	l.skipWhitespace()
 	switch l.ch {
 	case '+' :
 		tok = token.Token{Type: token.PLUS, Literal: string(l.ch)}
+		// +/- is ± for keyboards without it
+		if strings.HasPrefix(l.input[l.position:], "+/-") {
+			l.readChar()
+			l.readChar()
+			tok = token.Token{Type: token.PLUSMINUS, Literal: "±"}
+		}
 	case '-' :
 		tok = token.Token{Type: token.MINUS, Literal: string(l.ch)}
 	case '*' :
 		tok = token.Token{Type: token.AST, Literal: string(l.ch)}
 	case '/' :
 		tok = token.Token{Type: token.DIV, Literal: string(l.ch)}
-	case 'e' :
-		tok = token.Token{Type: token.EULER, Literal: string(l.ch)}
 	case '(' :
 		tok = token.Token{Type: token.LPAREN, Literal: string(l.ch)}
 	case ')' :
 		tok = token.Token{Type: token.RPAREN, Literal: string(l.ch)}
+	case '[' :
+		tok = token.Token{Type: token.LBRACKET, Literal: string(l.ch)}
+	case ']' :
+		tok = token.Token{Type: token.RBRACKET, Literal: string(l.ch)}
+	case '{' :
+		tok = token.Token{Type: token.LBRACE, Literal: string(l.ch)}
+	case '}' :
+		tok = token.Token{Type: token.RBRACE, Literal: string(l.ch)}
+	case ',' :
+		tok = token.Token{Type: token.COMMA, Literal: string(l.ch)}
+	case '=' :
+		tok = token.Token{Type: token.EQ, Literal: string(l.ch)}
 	case '!' :
 		tok = token.Token{Type: token.FACTORIAL, Literal: string(l.ch)}
 	case 'E' :
@@ -57,7 +106,11 @@ func (l *Lexer) NextToken() token.Token {
 	case 0:
 		tok = token.Token{Type: token.EOF, Literal: string(l.ch)}
 	default :
-		if isDigit(l.ch) {
+		if lit := l.readLiteral(dateLiteral); lit != "" {
+			return token.Token{Type: token.DATE, Literal: lit}
+		} else if lit := l.readLiteral(durationLiteral); lit != "" {
+			return token.Token{Type: token.DURATION, Literal: lit}
+		} else if isDigit(l.ch) {
 			lit, tp := l.readNumber()
 			tok = token.Token{Type: tp, Literal: lit}
 			return tok
@@ -66,11 +119,29 @@ func (l *Lexer) NextToken() token.Token {
 				tok = token.Token{Type: token.PROC, Literal: string(proc)}
 				return tok
 			}
-		}else if isLetter(l.ch) {
+		}else if l.currentRune() == '±' {
+			l.readRune()
+			return token.Token{Type: token.PLUSMINUS, Literal: "±"}
+		}else if isLetter(l.currentRune()) {
 			word := l.readWord()
-			i, _ := token.Keywords[word]
+			i, ok := token.Keywords[word]
+			// the in of 1 in to cm and of 1 cm to in is the inch, not a
+			// conversion
+			if word == "in" && (l.prev == token.INT || l.prev == token.FLOAT || l.prev == token.TO) {
+				ok = false
+			}
+			if !ok {
+				i = token.IDENT
+			}
+			// a lone e is Euler's number, longer words such as e_charge
+			// are names
+			if word == "e" {
+				i = token.EULER
+			} else if !ok && l.quantityFollows() && units.IsUnit(word) {
+				i = token.UNIT
+				word += l.readUnitTail()
+			}
 			tok = token.Token{Type: i, Literal: word}
-			fmt.Println("Parsing Token: ", tok.Literal)
 			return tok
 		}
 	}
@@ -83,8 +154,29 @@ func isDigit(ch byte) bool {
 	return '0' <= ch && ch <= '9'
 }
 
-func isLetter(ch byte) bool {
-	return unicode.IsLetter(rune(ch))
+// isLetter reports whether r may start a word. ∞, °, ∑ and ∏ are not
+// letters to unicode but are written like names.
+func isLetter(r rune) bool {
+	return unicode.IsLetter(r) || r == '∞' || r == '°' || r == '∑' || r == '∏'
+}
+
+func (l *Lexer) skipWhitespace() {
+	for l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r' {
+		l.readChar()
+	}
+}
+
+func (l *Lexer) currentRune() rune {
+	r, _ := utf8.DecodeRuneInString(l.input[l.position:])
+	return r
+}
+
+// readRune advances past the whole UTF-8 sequence of the current rune.
+func (l *Lexer) readRune() {
+	_, size := utf8.DecodeRuneInString(l.input[l.position:])
+	for i := 0; i < size; i++ {
+		l.readChar()
+	}
 }
 
 func (l *Lexer) isProcedure() rune{
@@ -98,6 +190,23 @@ func (l *Lexer) isProcedure() rune{
 	return 0
 }
 
+// readLiteral reads a date or a duration matching re. It reads nothing
+// when the match is only the start of a longer word or number, or when a
+// duration has no components, as P and PT alone are names.
+func (l *Lexer) readLiteral(re *regexp.Regexp) string {
+	lit := re.FindString(l.input[l.position:])
+	if lit == "" || strings.TrimRight(lit, "PT") == "" || strings.HasSuffix(lit, "T") {
+		return ""
+	}
+	if r, _ := utf8.DecodeRuneInString(l.input[l.position+len(lit):]); isLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.' {
+		return ""
+	}
+	for i := 0; i < len(lit); i++ {
+		l.readChar()
+	}
+	return lit
+}
+
 func (l *Lexer) readNumber() (string, token.TokenType){
 	float := false
 	position := l.position
@@ -116,10 +225,48 @@ func (l *Lexer) readNumber() (string, token.TokenType){
 	return l.input[position:l.position], tokenType
 }
 
+// readUnitTail reads the rest of a compound unit written without spaces,
+// such as the "/s^2" of "m/s^2".
+func (l *Lexer) readUnitTail() string {
+	position := l.position
+	for {
+		savedPosition, savedRead, savedCh := l.position, l.readPosition, l.ch
+		if l.ch == '^' {
+			l.readChar()
+			if l.ch == '-' {
+				l.readChar()
+			}
+			if !isDigit(l.ch) {
+				l.position, l.readPosition, l.ch = savedPosition, savedRead, savedCh
+				break
+			}
+			for isDigit(l.ch) {
+				l.readChar()
+			}
+			continue
+		}
+		if l.ch != '/' && l.ch != '*' && l.currentRune() != '·' {
+			break
+		}
+		l.readRune()
+		if !isLetter(l.currentRune()) || !units.IsUnit(l.readWord()) {
+			l.position, l.readPosition, l.ch = savedPosition, savedRead, savedCh
+			break
+		}
+	}
+	return l.input[position:l.position]
+}
+
 func (l *Lexer) readWord() string {
 	position := l.position
-	for isLetter(l.ch) {
-		l.readChar()
+	for l.position < len(l.input) {
+		if isLetter(l.currentRune()) {
+			l.readRune()
+		} else if isDigit(l.ch) || l.ch == '_' {
+			l.readChar()
+		} else {
+			break
+		}
 	}
 	return l.input[position:l.position]
 }
@@ -23,3 +23,164 @@ func TestNextToken(t *testing.T) {
 		t.Fatalf("expected literal %s got %s", floatTest.expectedLiteral, tok.Literal)
 	}
 }
+
+func TestIdentifiers(t *testing.T) {
+	input := `2 * π + sqrt2 - ∞ ∑ ∏`
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.INT, "2"},
+		{token.AST, "*"},
+		{token.IDENT, "π"},
+		{token.PLUS, "+"},
+		{token.IDENT, "sqrt2"},
+		{token.MINUS, "-"},
+		{token.IDENT, "∞"},
+		{token.IDENT, "∑"},
+		{token.IDENT, "∏"},
+		{token.EOF, "\x00"},
+	}
+
+	lex := New(input)
+	for i, tt := range tests {
+		tok := lex.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - expected type %s got %s", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - expected literal %q got %q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestEulerAndNames(t *testing.T) {
+	input := `2e*e_charge/eps0`
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.INT, "2"},
+		{token.EULER, "e"},
+		{token.AST, "*"},
+		{token.IDENT, "e_charge"},
+		{token.DIV, "/"},
+		{token.IDENT, "eps0"},
+	}
+
+	lex := New(input)
+	for i, tt := range tests {
+		tok := lex.NextToken()
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - expected %s %q got %s %q", i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+	}
+}
+
+func TestUnits(t *testing.T) {
+	input := `60 km/h to m/s^2*h`
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.INT, "60"},
+		{token.UNIT, "km/h"},
+		{token.TO, "to"},
+		{token.UNIT, "m/s^2*h"},
+		{token.EOF, "\x00"},
+	}
+
+	lex := New(input)
+	for i, tt := range tests {
+		tok := lex.NextToken()
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - expected %s %q got %s %q", i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+	}
+}
+
+func TestUnitsAfterNumbers(t *testing.T) {
+	input := `h^2 3 h^2 (t) s N m 1 in in cm`
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "h"},
+		{token.ELEVATE, "^"},
+		{token.INT, "2"},
+		{token.INT, "3"},
+		{token.UNIT, "h^2"},
+		{token.LPAREN, "("},
+		{token.IDENT, "t"},
+		{token.RPAREN, ")"},
+		{token.UNIT, "s"},
+		{token.UNIT, "N"},
+		{token.UNIT, "m"},
+		{token.INT, "1"},
+		{token.UNIT, "in"},
+		{token.TO, "in"},
+		{token.UNIT, "cm"},
+		{token.EOF, "\x00"},
+	}
+
+	lex := New(input)
+	for i, tt := range tests {
+		tok := lex.NextToken()
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - expected %s %q got %s %q", i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+	}
+}
+
+func TestDates(t *testing.T) {
+	input := `2026-10-18T14:30 + P1Y2M10DT2H30M - 2026-13-01 P PT1 PT0.5S`
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.DATE, "2026-10-18T14:30"},
+		{token.PLUS, "+"},
+		{token.DURATION, "P1Y2M10DT2H30M"},
+		{token.MINUS, "-"},
+		{token.INT, "2026"},
+		{token.MINUS, "-"},
+		{token.INT, "13"},
+		{token.MINUS, "-"},
+		{token.INT, "01"},
+		{token.IDENT, "P"},
+		{token.IDENT, "PT1"},
+		{token.DURATION, "PT0.5S"},
+		{token.EOF, "\x00"},
+	}
+
+	lex := New(input)
+	for i, tt := range tests {
+		tok := lex.NextToken()
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - expected %s %q got %s %q", i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+	}
+}
+
+func TestPlusMinus(t *testing.T) {
+	input := `9.81 ± 0.02 +/- 1`
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.FLOAT, "9.81"},
+		{token.PLUSMINUS, "±"},
+		{token.FLOAT, "0.02"},
+		{token.PLUSMINUS, "±"},
+		{token.INT, "1"},
+		{token.EOF, "\x00"},
+	}
+
+	lex := New(input)
+	for i, tt := range tests {
+		tok := lex.NextToken()
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - expected %s %q got %s %q", i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package object
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Environment binds names to values, e.g. the integration variable of
+// integrate(x^2, x, 0, 1). Lookups fall back to the outer environment.
+// The outermost environment also holds the random number generator of a
+// calculator session.
+type Environment struct {
+	store     map[string]Object
+	outer     *Environment
+	rng       *rand.Rand
+	intervals bool
+	sampling  bool
+	precision int
+}
+
+func NewEnvironment() *Environment {
+	return &Environment{store: make(map[string]Object)}
+}
+
+func NewEnclosedEnvironment(outer *Environment) *Environment {
+	env := NewEnvironment()
+	env.outer = outer
+	return env
+}
+
+func (e *Environment) Get(name string) (Object, bool) {
+	obj, ok := e.store[name]
+	if !ok && e.outer != nil {
+		obj, ok = e.outer.Get(name)
+	}
+	return obj, ok
+}
+
+func (e *Environment) Set(name string, val Object) Object {
+	e.store[name] = val
+	return val
+}
+
+// Rand returns the random number generator of the session, seeded from
+// the clock unless Seed was called.
+func (e *Environment) Rand() *rand.Rand {
+	if e.outer != nil {
+		return e.outer.Rand()
+	}
+	if e.rng == nil {
+		e.rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return e.rng
+}
+
+// Seed restarts the random number generator of the session, the same seed
+// giving the same sequence of random numbers.
+func (e *Environment) Seed(seed int64) {
+	if e.outer != nil {
+		e.outer.Seed(seed)
+		return
+	}
+	e.rng = rand.New(rand.NewSource(seed))
+}
+
+// Precision is the number of significant digits the session computes
+// with where high precision is supported, 0 when it computes in floating
+// point.
+func (e *Environment) Precision() int {
+	if e.outer != nil {
+		return e.outer.Precision()
+	}
+	return e.precision
+}
+
+// SetPrecision sets the precision of the session, 0 turning high precision
+// off.
+func (e *Environment) SetPrecision(digits int) {
+	if e.outer != nil {
+		e.outer.SetPrecision(digits)
+		return
+	}
+	e.precision = digits
+}
+
+// IntervalMode reports whether numbers are evaluated as intervals, that is
+// whether the mode is set on e or on an environment enclosing it.
+func (e *Environment) IntervalMode() bool {
+	if e.intervals || e.outer == nil {
+		return e.intervals
+	}
+	return e.outer.IntervalMode()
+}
+
+// SetIntervalMode sets the interval mode of e and of the environments it
+// encloses.
+func (e *Environment) SetIntervalMode(on bool) {
+	e.intervals = on
+}
+
+// SamplingMode reports whether every x ± s draws a random value around x,
+// the way Monte Carlo propagation of uncertainties evaluates expressions.
+func (e *Environment) SamplingMode() bool {
+	if e.sampling || e.outer == nil {
+		return e.sampling
+	}
+	return e.outer.SamplingMode()
+}
+
+// SetSamplingMode sets the sampling mode of e and of the environments it
+// encloses.
+func (e *Environment) SetSamplingMode(on bool) {
+	e.sampling = on
+}
@@ -1,11 +1,37 @@
 package object
 
-import "fmt"
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hellracer2007/webCalc/calculator/ast"
+	"github.com/hellracer2007/webCalc/calculator/units"
+)
 
 const (
 	INTEGER_OBJ = "integer"
 	ERROR_OBJ = "error"
 	FLOAT_OBJ = "float"
+	QUANTITY_OBJ = "quantity"
+	VECTOR_OBJ = "vector"
+	MATRIX_OBJ = "matrix"
+	COMPLEX_OBJ = "complex"
+	TUPLE_OBJ = "tuple"
+	BUILTIN_OBJ = "builtin"
+	EXPRESSION_OBJ = "expression"
+	BIGINT_OBJ = "bigint"
+	LIST_OBJ = "list"
+	DECIMAL_OBJ = "decimal"
+	DATE_OBJ = "date"
+	DURATION_OBJ = "duration"
+	INTERVAL_OBJ = "interval"
+	UNCERTAIN_OBJ = "uncertain"
+	BIGFLOAT_OBJ = "bigfloat"
 )
 
 type ObjectType string
@@ -23,6 +49,87 @@ type Integer struct{
 func (i *Integer) Type() ObjectType {return INTEGER_OBJ}
 func (i *Integer) Inspect() string {return fmt.Sprintf("%d", i.Value)}
 
+// BigInt is an integer that does not fit into an Integer, arithmetic on
+// Integers promotes to it on overflow.
+type BigInt struct {
+	Value	*big.Int
+}
+
+func (b *BigInt) Type() ObjectType {return BIGINT_OBJ}
+func (b *BigInt) Inspect() string {return b.Value.String()}
+
+// Decimal is the exact decimal number Unscaled / 10^Scale, used for
+// amounts of money so that 0.10 + 0.20 is 0.30 and cents are rounded by
+// decimal rules.
+type Decimal struct {
+	Unscaled	*big.Int
+	Scale		int
+}
+
+func (d *Decimal) Type() ObjectType {return DECIMAL_OBJ}
+func (d *Decimal) Inspect() string {
+	digits := new(big.Int).Abs(d.Unscaled).String()
+	if d.Scale > 0 {
+		if len(digits) <= d.Scale {
+			digits = strings.Repeat("0", d.Scale-len(digits)+1) + digits
+		}
+		digits = digits[:len(digits)-d.Scale] + "." + digits[len(digits)-d.Scale:]
+	}
+	if d.Unscaled.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+// BigFloat is a number carried to more digits than a Float, the result of
+// a computation in high precision. Digits is the number of significant
+// digits shown, Value carries a few more bits.
+type BigFloat struct {
+	Value	*big.Float
+	Digits	int
+}
+
+func (b *BigFloat) Type() ObjectType {return BIGFLOAT_OBJ}
+func (b *BigFloat) Inspect() string {
+	// Text takes time growing with the exponent, far out the mantissa is
+	// scaled by a power of ten and printed on its own
+	if exp := b.Value.MantExp(nil); b.Digits > 0 && (exp > 4096 || exp < -4096) {
+		return scientific(b.Value, b.Digits)
+	}
+	return b.Value.Text('g', b.Digits)
+}
+
+// scientific prints x as Text('g', digits) does for large exponents, for
+// instance 7.5638913231040998047e-478.
+func scientific(x *big.Float, digits int) string {
+	e10 := int(math.Floor(float64(x.MantExp(nil)) * math.Log10(2)))
+	prec := x.Prec() + 64
+	pow := new(big.Float).SetPrec(prec).SetInt64(1)
+	ten := new(big.Float).SetPrec(prec).SetInt64(10)
+	for n := e10; n != 0; n /= 2 {
+		if n%2 != 0 {
+			pow.Mul(pow, ten)
+		}
+		if n/2 != 0 {
+			ten.Mul(ten, ten)
+		}
+	}
+	m := new(big.Float).SetPrec(prec)
+	if e10 > 0 {
+		m.Quo(x, pow)
+	} else {
+		m.Mul(x, pow)
+	}
+	s := m.Text('e', digits-1)
+	i := strings.LastIndexByte(s, 'e')
+	mant := s[:i]
+	e, _ := strconv.Atoi(s[i+1:])
+	if strings.Contains(mant, ".") {
+		mant = strings.TrimRight(strings.TrimRight(mant, "0"), ".")
+	}
+	return fmt.Sprintf("%se%+03d", mant, e+e10)
+}
+
 type Error struct {
 	Message string
 }
@@ -35,3 +142,236 @@ type Float struct {
 }
 func (f *Float) Type()ObjectType { return FLOAT_OBJ }
 func (f *Float) Inspect() string { return fmt.Sprintf("%v", f.Value) }
+
+// Interval is the set of reals from Lo to Hi. Intervals are rounded
+// outward, so the exact value of a computation always lies inside.
+type Interval struct {
+	Lo	float64
+	Hi	float64
+}
+
+func (i *Interval) Type() ObjectType {return INTERVAL_OBJ}
+func (i *Interval) Inspect() string {return fmt.Sprintf("[%v .. %v]", i.Lo, i.Hi)}
+
+// Uncertain is a measured value with its standard uncertainty. Terms
+// holds the share of every independent source of uncertainty, the
+// derivative of the value by the source times the source's uncertainty,
+// so that correlations are kept and x - x is 0 ± 0.
+type Uncertain struct {
+	Value	float64
+	Terms	map[int64]float64
+}
+
+func (u *Uncertain) Type() ObjectType {return UNCERTAIN_OBJ}
+
+// Sigma is the standard uncertainty of the value.
+func (u *Uncertain) Sigma() float64 {
+	sum := 0.0
+	for _, t := range u.Terms {
+		sum += t * t
+	}
+	return math.Sqrt(sum)
+}
+
+// Inspect rounds the uncertainty to two significant digits and the value
+// to the same decimal place, dropping zeros both end in: 9.81 ± 0.02.
+func (u *Uncertain) Inspect() string {
+	sigma := u.Sigma()
+	if sigma == 0 || math.IsInf(sigma, 0) || math.IsNaN(sigma) {
+		return fmt.Sprintf("%v ± %v", u.Value, sigma)
+	}
+	places := max(1-int(math.Floor(math.Log10(sigma))), 0)
+	v := strconv.FormatFloat(u.Value, 'f', places, 64)
+	s := strconv.FormatFloat(sigma, 'f', places, 64)
+	for places > 0 && strings.HasSuffix(v, "0") && strings.HasSuffix(s, "0") {
+		v, s = v[:len(v)-1], s[:len(s)-1]
+		places--
+	}
+	return strings.TrimSuffix(v, ".") + " ± " + strings.TrimSuffix(s, ".")
+}
+
+// Date is a point in time in UTC, written as an ISO 8601 date.
+type Date struct {
+	Value	time.Time
+}
+
+func (d *Date) Type() ObjectType {return DATE_OBJ}
+func (d *Date) Inspect() string {
+	t := d.Value
+	switch {
+	case t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0:
+		return t.Format("2006-01-02")
+	case t.Second() == 0 && t.Nanosecond() == 0:
+		return t.Format("2006-01-02T15:04")
+	}
+	return t.Format("2006-01-02T15:04:05.999999999")
+}
+
+// Duration is a span of calendar months, days and seconds. The parts are
+// kept apart because a month has no fixed number of days; a day is
+// always 86400 seconds since dates are in UTC.
+type Duration struct {
+	Months	int64
+	Days	int64
+	Seconds	float64
+}
+
+func (d *Duration) Type() ObjectType {return DURATION_OBJ}
+
+// Inspect writes the duration in ISO 8601 form, e.g. P1Y2M10DT2H30M.
+func (d *Duration) Inspect() string {
+	months, days, seconds := d.Months, d.Days, d.Seconds
+	var out bytes.Buffer
+	if months <= 0 && days <= 0 && seconds <= 0 {
+		out.WriteString("-")
+		months, days, seconds = -months, -days, -seconds
+	}
+	out.WriteString("P")
+	part := func(n int64, designator string) {
+		if n != 0 {
+			out.WriteString(strconv.FormatInt(n, 10) + designator)
+		}
+	}
+	part(months/12, "Y")
+	part(months%12, "M")
+	part(days, "D")
+	if seconds != 0 {
+		out.WriteString("T")
+		hours := math.Trunc(seconds / 3600)
+		minutes := math.Trunc((seconds - hours*3600) / 60)
+		part(int64(hours), "H")
+		part(int64(minutes), "M")
+		if s := seconds - hours*3600 - minutes*60; s != 0 {
+			out.WriteString(strconv.FormatFloat(s, 'f', -1, 64) + "S")
+		}
+	}
+	if out.String() == "-P" {
+		return "PT0S"
+	}
+	return out.String()
+}
+
+// Quantity is a number measured in a unit, e.g. 9.81 m/s^2.
+type Quantity struct {
+	Value	float64
+	Unit	*units.Unit
+}
+func (q *Quantity) Type() ObjectType { return QUANTITY_OBJ }
+func (q *Quantity) Inspect() string { return fmt.Sprintf("%v %s", q.Value, q.Unit) }
+
+type Vector struct {
+	Elements	[]Object
+}
+func (v *Vector) Type() ObjectType { return VECTOR_OBJ }
+func (v *Vector) Inspect() string {
+	var out bytes.Buffer
+	elements := []string{}
+	for _, e := range v.Elements {
+		elements = append(elements, e.Inspect())
+	}
+	out.WriteString("[")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("]")
+	return out.String()
+}
+
+// List is a sequence of values such as a data sample, {1, 2, 3}. Unlike
+// a Vector it takes part in no arithmetic.
+type List struct {
+	Elements	[]Object
+}
+func (l *List) Type() ObjectType { return LIST_OBJ }
+func (l *List) Inspect() string {
+	elements := []string{}
+	for _, e := range l.Elements {
+		elements = append(elements, e.Inspect())
+	}
+	return "{" + strings.Join(elements, ", ") + "}"
+}
+
+// Matrix stores its elements row by row.
+type Matrix struct {
+	Rows	int
+	Cols	int
+	Data	[]float64
+}
+func (m *Matrix) Type() ObjectType { return MATRIX_OBJ }
+func (m *Matrix) At(i, j int) float64 { return m.Data[i*m.Cols+j] }
+func (m *Matrix) Set(i, j int, v float64) { m.Data[i*m.Cols+j] = v }
+func (m *Matrix) Inspect() string {
+	var out bytes.Buffer
+	rows := []string{}
+	for i := 0; i < m.Rows; i++ {
+		row := []string{}
+		for j := 0; j < m.Cols; j++ {
+			row = append(row, fmt.Sprintf("%v", m.At(i, j)))
+		}
+		rows = append(rows, "["+strings.Join(row, ", ")+"]")
+	}
+	out.WriteString("[")
+	out.WriteString(strings.Join(rows, ", "))
+	out.WriteString("]")
+	return out.String()
+}
+
+func NewMatrix(rows, cols int) *Matrix {
+	return &Matrix{Rows: rows, Cols: cols, Data: make([]float64, rows*cols)}
+}
+
+type Complex struct {
+	Value	complex128
+}
+func (c *Complex) Type() ObjectType { return COMPLEX_OBJ }
+func (c *Complex) Inspect() string {
+	re, im := real(c.Value), imag(c.Value)
+	switch {
+	case re == 0:
+		return fmt.Sprintf("%vi", im)
+	case im < 0:
+		return fmt.Sprintf("%v-%vi", re, -im)
+	}
+	return fmt.Sprintf("%v+%vi", re, im)
+}
+
+// Tuple groups several named results, e.g. the L, U and P factors of a
+// decomposition, so callers can pick them apart by name.
+type Tuple struct {
+	Names		[]string
+	Elements	[]Object
+}
+func (t *Tuple) Type() ObjectType { return TUPLE_OBJ }
+func (t *Tuple) Get(name string) Object {
+	for i, n := range t.Names {
+		if n == name {
+			return t.Elements[i]
+		}
+	}
+	return nil
+}
+func (t *Tuple) Inspect() string {
+	var out bytes.Buffer
+	elements := []string{}
+	for i, e := range t.Elements {
+		elements = append(elements, t.Names[i]+" = "+e.Inspect())
+	}
+	out.WriteString("(")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString(")")
+	return out.String()
+}
+
+type BuiltinFunction func(args ...Object) Object
+
+type Builtin struct {
+	Fn	BuiltinFunction
+}
+func (b *Builtin) Type() ObjectType { return BUILTIN_OBJ }
+func (b *Builtin) Inspect() string { return "builtin function" }
+
+// Expression is an unevaluated expression tree, e.g. the result of a
+// symbolic derivative.
+type Expression struct {
+	Node	ast.Expression
+}
+func (e *Expression) Type() ObjectType { return EXPRESSION_OBJ }
+func (e *Expression) Inspect() string { return e.Node.String() }
@@ -3,6 +3,8 @@ package parser
 import (
 	"fmt"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/hellracer2007/webCalc/calculator/ast"
 	"github.com/hellracer2007/webCalc/calculator/lexer"
@@ -16,6 +18,7 @@ const (
 	SUM
 	MULT
 	PRODUCT
+	POWER
 	EULER
 	LPAR
 	PROC
@@ -24,6 +27,7 @@ const (
 var precedences = map[token.TokenType]int {
 
 	token.PLUS:		SUM,
+	token.PLUSMINUS:SUM,
 	token.MINUS:	SUM,
 	token.AST:		MULT,	
 	token.DIV:		MULT,
@@ -33,7 +37,11 @@ var precedences = map[token.TokenType]int {
 	token.PROC:		PROC,
 	token.FACTORIAL:PROC,
 	token.EXP:		MULT,
-	token.ELEVATE:	MULT,
+	token.CHOOSE:	MULT,
+	token.ELEVATE:	POWER,
+	token.UNIT:		EULER,
+	token.TO:		EQUALS,
+	token.EQ:		EQUALS,
 }
 
 type Parser struct {
@@ -75,6 +83,12 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
 	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
 	p.registerPrefix(token.EULER, p.parseEulerLiteral)
+	p.registerPrefix(token.DATE, p.parseDateLiteral)
+	p.registerPrefix(token.DURATION, p.parseDurationLiteral)
+	p.registerPrefix(token.IDENT, p.parseIdentifier)
+	p.registerPrefix(token.UNIT, p.parseIdentifier)
+	p.registerPrefix(token.LBRACKET, p.parseVectorLiteral)
+	p.registerPrefix(token.LBRACE, p.parseListLiteral)
 	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
 	p.registerPrefix(token.PROC, p.parseProcedure)
 	p.registerPrefix(token.PLUS, p.parsePrefixExpression)
@@ -82,6 +96,7 @@ func New(l *lexer.Lexer) *Parser {
 
 	p.infixParseFns = make(map[token.TokenType]infixParseFn)
 	p.registerInfix(token.PLUS, p.parseInfixExpression)
+	p.registerInfix(token.PLUSMINUS, p.parseInfixExpression)
 	p.registerInfix(token.AST, p.parseInfixExpression)
 	p.registerInfix(token.DIV, p.parseInfixExpression)
 	p.registerInfix(token.MINUS, p.parseInfixExpression)
@@ -89,7 +104,11 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.EULER, p.parseEulerExp)
 	p.registerInfix(token.EXP, p.parseInfixExpression)
 	p.registerInfix(token.ELEVATE, p.parseInfixExpression)
+	p.registerInfix(token.CHOOSE, p.parseInfixExpression)
 	p.registerInfix(token.PROC, p.parseInfixExpression)
+	p.registerInfix(token.UNIT, p.parseUnitExp)
+	p.registerInfix(token.TO, p.parseConversion)
+	p.registerInfix(token.EQ, p.parseEquation)
 	
 	p.postfixParseFns = make(map[token.TokenType]postfixParseFn)
 	p.registerPostfix(token.FACTORIAL, p.parsePostfixExpression)
@@ -157,6 +176,10 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 		}
 		p.nextToken()
 		leftExp = infix(leftExp)
+		if leftExp == nil {
+			// the error is recorded, operators after it have no left side
+			return nil
+		}
 	}
 	return leftExp
 }
@@ -167,18 +190,36 @@ func (p *Parser) noPrefixParseFnError(t token.TokenType) {
 }
 
 func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
-	fmt.Println("parsing infix")
 	expression := &ast.InfixExpression{
 		Token: p.curToken,
 		Operator: p.curToken.Literal,
 		Left: left,
 	}
 	precedence := p.curPrecedence()
+	// powers group to the right, 2^3^2 is 2^9
+	if p.curToken.Type == token.ELEVATE {
+		precedence--
+	}
 	p.nextToken()
 	expression.Right = p.parseExpression(precedence)
 	return expression
 }
 
+func (p *Parser) parseEquation(left ast.Expression) ast.Expression {
+	expression := &ast.Equation{
+		Token: p.curToken,
+		Left: left,
+	}
+	precedence := p.curPrecedence()
+	p.nextToken()
+	expression.Right = p.parseExpression(precedence)
+	if expression.Right == nil {
+		p.errors = append(p.errors, "expected an expression after =")
+		return nil
+	}
+	return expression
+}
+
 func (p *Parser) parsePrefixExpression() ast.Expression {
 	expression := &ast.PrefixExpression{
 		Token: p.curToken,
@@ -214,6 +255,64 @@ func (p *Parser) parseFloatLiteral() ast.Expression {
 	return lit
 }
 
+func (p *Parser) parseDateLiteral() ast.Expression {
+	lit := &ast.DateLiteral{Token: p.curToken}
+	layout := "2006-01-02"
+	if strings.Contains(lit.Token.Literal, "T") {
+		layout = "2006-01-02T15:04"
+		if strings.Count(lit.Token.Literal, ":") == 2 {
+			layout = "2006-01-02T15:04:05"
+		}
+	}
+	value, err := time.Parse(layout, lit.Token.Literal)
+	if err != nil {
+		msg := fmt.Sprintf("could not parse %q as date", lit.Token.Literal)
+		p.errors = append(p.errors, msg)
+		lit.Invalid = true
+		return lit
+	}
+	lit.Value = value
+	return lit
+}
+
+// parseDurationLiteral splits an ISO 8601 duration into its components,
+// the lexer has already checked the order of the designators.
+func (p *Parser) parseDurationLiteral() ast.Expression {
+	lit := &ast.DurationLiteral{Token: p.curToken}
+	seconds := map[byte]float64{'H': 3600, 'M': 60, 'S': 1}
+	inTime, start := false, 1
+	for i := 1; i < len(lit.Token.Literal); i++ {
+		c := lit.Token.Literal[i]
+		if c == 'T' {
+			inTime, start = true, i+1
+			continue
+		}
+		if '0' <= c && c <= '9' || c == '.' {
+			continue
+		}
+		value, err := strconv.ParseFloat(lit.Token.Literal[start:i], 64)
+		if err != nil || value > 1e15 {
+			msg := fmt.Sprintf("could not parse %q as duration", lit.Token.Literal)
+			p.errors = append(p.errors, msg)
+			return nil
+		}
+		switch {
+		case inTime:
+			lit.Seconds += value * seconds[c]
+		case c == 'Y':
+			lit.Months += int64(value) * 12
+		case c == 'M':
+			lit.Months += int64(value)
+		case c == 'W':
+			lit.Days += int64(value) * 7
+		case c == 'D':
+			lit.Days += int64(value)
+		}
+		start = i + 1
+	}
+	return lit
+}
+
 func (p *Parser) parseEulerExp(left ast.Expression) ast.Expression {
 	expression := &ast.InfixExpression{
 		Token: token.Token{Type: token.AST, Literal: "*"},
@@ -224,12 +323,94 @@ func (p *Parser) parseEulerExp(left ast.Expression) ast.Expression {
 	return expression
 }
 
+// parseUnitExp attaches a unit to the number before it, 3 m is 3 * m.
+// A unit on its own is parsed as an identifier instead, so names such
+// as h keep meaning the Planck constant outside of a measurement.
+func (p *Parser) parseUnitExp(left ast.Expression) ast.Expression {
+	expression := &ast.InfixExpression{
+		Token: token.Token{Type: token.AST, Literal: "*"},
+		Operator: "*",
+		Left: left,
+		Right: &ast.UnitLiteral{Token: p.curToken, Value: p.curToken.Literal},
+	}
+	return expression
+}
+
+func (p *Parser) parseConversion(left ast.Expression) ast.Expression {
+	expression := &ast.ConversionExpression{
+		Token: p.curToken,
+		Left: left,
+	}
+	if !p.expectPeek(token.UNIT) {
+		p.peekError(token.UNIT)
+		return nil
+	}
+	expression.Unit = &ast.UnitLiteral{Token: p.curToken, Value: p.curToken.Literal}
+	return expression
+}
+
 func (p *Parser) parseEulerLiteral() ast.Expression {
 	return ast.Euler
 }
 
+func (p *Parser) parseIdentifier() ast.Expression {
+	if p.peekTokenIs(token.LPAREN) {
+		return p.parseCallExpression()
+	}
+	return &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+}
+
+func (p *Parser) parseCallExpression() ast.Expression {
+	call := &ast.CallExpression{Token: p.curToken, Func: p.curToken.Literal}
+	p.nextToken()
+	call.Arguments = p.parseExpressionList(token.RPAREN)
+	if call.Arguments == nil {
+		return nil
+	}
+	return call
+}
+
+func (p *Parser) parseVectorLiteral() ast.Expression {
+	vector := &ast.VectorLiteral{Token: p.curToken}
+	vector.Elements = p.parseExpressionList(token.RBRACKET)
+	if vector.Elements == nil {
+		return nil
+	}
+	return vector
+}
+
+func (p *Parser) parseListLiteral() ast.Expression {
+	list := &ast.ListLiteral{Token: p.curToken}
+	list.Elements = p.parseExpressionList(token.RBRACE)
+	if list.Elements == nil {
+		return nil
+	}
+	return list
+}
+
+// parseExpressionList parses comma separated expressions up to end, the
+// current token being the opening bracket.
+func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
+	list := []ast.Expression{}
+	if p.peekTokenIs(end) {
+		p.nextToken()
+		return list
+	}
+	p.nextToken()
+	list = append(list, p.parseExpression(LOWEST))
+	for p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		p.nextToken()
+		list = append(list, p.parseExpression(LOWEST))
+	}
+	if !p.expectPeek(end) {
+		p.peekError(end)
+		return nil
+	}
+	return list
+}
+
 func (p *Parser) parseGroupedExpression() ast.Expression {
-	fmt.Println("Parsing grouped expression")
 	p.nextToken()
 	result := p.parseExpression(LOWEST)
 	if !p.expectPeek(token.RPAREN) {
@@ -244,6 +425,11 @@ func (p *Parser) parseProcedure() ast.Expression {
 		Func: p.curToken.Literal,
 	}
 	p.nextToken()
+	if p.curToken.Type != token.LPAREN {
+		// bare operand, e.g. √2
+		result.Body = p.parseExpression(PROC)
+		return result
+	}
 	result.Body = p.parseGroupedExpression()
 	return result
 }
@@ -0,0 +1,163 @@
+// Package symbolic rewrites expression trees: exact derivatives and
+// algebraic simplification. The results are ordinary ast nodes, so they
+// print through String() and evaluate like anything the parser produced.
+package symbolic
+
+import (
+	"fmt"
+
+	"github.com/hellracer2007/webCalc/calculator/ast"
+)
+
+// degree converts between degrees and radians, the trigonometric
+// procedures work in degrees so their derivatives carry this factor.
+func degree() ast.Expression { return div(ident("π"), number(180)) }
+
+// Derivative returns d/dx of expr, simplified.
+func Derivative(expr ast.Expression, x string) (ast.Expression, error) {
+	d, err := derive(expr, x)
+	if err != nil {
+		return nil, err
+	}
+	return Simplify(d), nil
+}
+
+func derive(expr ast.Expression, x string) (ast.Expression, error) {
+	if !dependsOn(expr, x) {
+		if _, ok := expr.(*ast.VectorLiteral); !ok {
+			return number(0), nil
+		}
+	}
+	switch node := expr.(type) {
+	case *ast.Identifier:
+		return number(1), nil
+	case *ast.PrefixExpression:
+		d, err := derive(node.Right, x)
+		if err != nil {
+			return nil, err
+		}
+		if node.Operator == "-" {
+			return neg(d), nil
+		}
+		return d, nil
+	case *ast.InfixExpression:
+		return deriveInfix(node, x)
+	case *ast.Procedure:
+		return deriveProcedure(node, x)
+	case *ast.VectorLiteral:
+		elements := make([]ast.Expression, len(node.Elements))
+		for i, el := range node.Elements {
+			d, err := derive(el, x)
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = d
+		}
+		return &ast.VectorLiteral{Token: node.Token, Elements: elements}, nil
+	}
+	return nil, fmt.Errorf("cannot differentiate %s", expr.String())
+}
+
+func deriveInfix(node *ast.InfixExpression, x string) (ast.Expression, error) {
+	u, v := node.Left, node.Right
+	if node.Operator == "E" {
+		// 2E3 is 2 * 10^3
+		return derive(mul(u, pow(number(10), v)), x)
+	}
+	du, err := derive(u, x)
+	if err != nil {
+		return nil, err
+	}
+	dv, err := derive(v, x)
+	if err != nil {
+		return nil, err
+	}
+	switch node.Operator {
+	case "+":
+		return add(du, dv), nil
+	case "-":
+		return sub(du, dv), nil
+	case "*":
+		return add(mul(du, v), mul(u, dv)), nil
+	case "/":
+		return div(sub(mul(du, v), mul(u, dv)), pow(v, number(2))), nil
+	case "^":
+		switch {
+		case !dependsOn(v, x):
+			return mul(mul(v, pow(u, sub(v, number(1)))), du), nil
+		case !dependsOn(u, x):
+			return mul(mul(node, proc("ln", u)), dv), nil
+		}
+		return mul(node, add(mul(dv, proc("ln", u)), div(mul(v, du), u))), nil
+	}
+	return nil, fmt.Errorf("cannot differentiate the %s operator", node.Operator)
+}
+
+func deriveProcedure(node *ast.Procedure, x string) (ast.Expression, error) {
+	u := node.Body
+	du, err := derive(u, x)
+	if err != nil {
+		return nil, err
+	}
+	var outer ast.Expression
+	switch node.Func {
+	case "sin":
+		outer = mul(degree(), proc("cos", u))
+	case "cos":
+		outer = neg(mul(degree(), proc("sin", u)))
+	case "tan":
+		outer = div(degree(), pow(proc("cos", u), number(2)))
+	case "ln":
+		outer = div(number(1), u)
+	case "log":
+		outer = div(number(1), mul(u, proc("ln", number(10))))
+	case "√":
+		outer = div(number(1), mul(number(2), proc("√", u)))
+	case "arcsin":
+		outer = div(number(1), mul(degree(), proc("√", sub(number(1), pow(u, number(2))))))
+	case "arccos":
+		outer = neg(div(number(1), mul(degree(), proc("√", sub(number(1), pow(u, number(2)))))))
+	case "arctan":
+		outer = div(number(1), mul(degree(), add(number(1), pow(u, number(2)))))
+	case "sinh":
+		outer = proc("cosh", u)
+	case "cosh":
+		outer = proc("sinh", u)
+	case "tanh":
+		outer = div(number(1), pow(proc("cosh", u), number(2)))
+	case "arcsinh":
+		outer = div(number(1), proc("√", add(pow(u, number(2)), number(1))))
+	case "arccosh":
+		outer = div(number(1), proc("√", sub(pow(u, number(2)), number(1))))
+	case "arctanh":
+		outer = div(number(1), sub(number(1), pow(u, number(2))))
+	case "sec":
+		outer = mul(degree(), mul(proc("sec", u), proc("tan", u)))
+	case "csc":
+		outer = neg(mul(degree(), mul(proc("csc", u), proc("cot", u))))
+	case "cot":
+		outer = neg(div(degree(), pow(proc("sin", u), number(2))))
+	case "arcsec":
+		// √(u²) is |u|
+		outer = div(number(1), mul(degree(), mul(proc("√", pow(u, number(2))), proc("√", sub(pow(u, number(2)), number(1))))))
+	case "arccsc":
+		outer = neg(div(number(1), mul(degree(), mul(proc("√", pow(u, number(2))), proc("√", sub(pow(u, number(2)), number(1)))))))
+	case "arccot":
+		outer = neg(div(number(1), mul(degree(), add(number(1), pow(u, number(2))))))
+	case "exp", "expm1":
+		outer = proc("exp", u)
+	case "log2":
+		outer = div(number(1), mul(u, proc("ln", number(2))))
+	case "log1p":
+		outer = div(number(1), add(number(1), u))
+	case "cbrt":
+		outer = div(number(1), mul(number(3), pow(proc("cbrt", u), number(2))))
+	case "erf":
+		outer = mul(div(number(2), proc("√", ident("π"))), proc("exp", neg(pow(u, number(2)))))
+	case "erfc":
+		outer = neg(mul(div(number(2), proc("√", ident("π"))), proc("exp", neg(pow(u, number(2))))))
+	default:
+		return nil, fmt.Errorf("cannot differentiate %s", node.Func)
+	}
+	return mul(outer, du), nil
+}
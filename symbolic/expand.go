@@ -0,0 +1,153 @@
+package symbolic
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/hellracer2007/webCalc/calculator/ast"
+)
+
+// maxExpandPower bounds the integer powers of sums Expand multiplies out.
+const maxExpandPower = 64
+
+// Expand multiplies out products and integer powers of sums, so
+// (x + 1)^2 becomes x^2 + 2 x + 1, and simplifies the result. Powers of
+// sums above maxExpandPower are an error.
+func Expand(expr ast.Expression) (ast.Expression, error) {
+	switch node := expr.(type) {
+	case *ast.VectorLiteral:
+		elements := make([]ast.Expression, len(node.Elements))
+		for i, el := range node.Elements {
+			e, err := Expand(el)
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = e
+		}
+		return &ast.VectorLiteral{Token: node.Token, Elements: elements}, nil
+	case *ast.Equation:
+		left, err := Expand(node.Left)
+		if err != nil {
+			return nil, err
+		}
+		right, err := Expand(node.Right)
+		if err != nil {
+			return nil, err
+		}
+		return &ast.Equation{Token: node.Token, Left: left, Right: right}, nil
+	}
+	ts, err := expandTerms(expr)
+	if err != nil {
+		return nil, err
+	}
+	return sum(ts), nil
+}
+
+func expandTerms(expr ast.Expression) ([]term, error) {
+	switch node := expr.(type) {
+	case *ast.PrefixExpression:
+		ts, err := expandTerms(node.Right)
+		if err != nil || node.Operator != "-" {
+			return ts, err
+		}
+		return negateTerms(ts), nil
+	case *ast.InfixExpression:
+		switch node.Operator {
+		case "+", "-", "*", "/":
+			return expandInfix(node)
+		case "^":
+			return expandPower(node)
+		}
+	}
+	return []term{product(expr)}, nil
+}
+
+func expandInfix(node *ast.InfixExpression) ([]term, error) {
+	left, err := expandTerms(node.Left)
+	if err != nil {
+		return nil, err
+	}
+	right, err := expandTerms(node.Right)
+	if err != nil {
+		return nil, err
+	}
+	switch node.Operator {
+	case "+":
+		return append(left, right...), nil
+	case "-":
+		return append(left, negateTerms(right)...), nil
+	case "*":
+		return distribute(left, right), nil
+	}
+	if len(right) == 1 && right[0].coef.Sign() != 0 {
+		return distribute(left, []term{reciprocal(right[0])}), nil
+	}
+	// only monomial denominators are taken apart, x / (x + 1) stays a
+	// quotient
+	inverse := term{coef: big.NewRat(1, 1), factors: []factor{{sum(right), number(-1)}}}
+	if len(right) == 0 {
+		inverse.factors[0].base = number(0)
+	}
+	return distribute(left, []term{inverse}), nil
+}
+
+// expandPower multiplies out whole powers from 2 to maxExpandPower, other
+// powers stay as they are.
+func expandPower(node *ast.InfixExpression) ([]term, error) {
+	n, ok := numberValue(Simplify(node.Right))
+	if !ok || n != math.Trunc(n) || n < 2 {
+		return []term{product(node)}, nil
+	}
+	base, err := expandTerms(node.Left)
+	if err != nil {
+		return nil, err
+	}
+	if n > maxExpandPower {
+		if len(base) > 1 {
+			return nil, fmt.Errorf("%s has a power above %d, too large to multiply out", node.String(), maxExpandPower)
+		}
+		return []term{product(node)}, nil
+	}
+	result := base
+	for i := 1; i < int(n); i++ {
+		result = combine(distribute(result, base))
+	}
+	return result, nil
+}
+
+// distribute multiplies every term of a with every term of b.
+func distribute(a, b []term) []term {
+	result := make([]term, 0, len(a)*len(b))
+	for _, s := range a {
+		for _, t := range b {
+			result = append(result, multiply(s, t))
+		}
+	}
+	return result
+}
+
+// combine merges like terms, keeping repeated multiplication in Expand
+// from growing the number of terms exponentially.
+func combine(ts []term) []term {
+	var combined []term
+	keys := map[string]int{}
+	for _, t := range ts {
+		sortFactors(t.factors)
+		key := monomial(t.factors).String()
+		if i, ok := keys[key]; ok {
+			combined[i].coef = new(big.Rat).Add(combined[i].coef, t.coef)
+			combined[i].decimal = combined[i].decimal || t.decimal
+			continue
+		}
+		keys[key] = len(combined)
+		combined = append(combined, term{coef: new(big.Rat).Set(t.coef), decimal: t.decimal, factors: t.factors})
+	}
+	nonzero := combined[:0]
+	for _, t := range combined {
+		if t.coef.Sign() != 0 {
+			nonzero = append(nonzero, t)
+		}
+	}
+	return nonzero
+}
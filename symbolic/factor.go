@@ -0,0 +1,271 @@
+package symbolic
+
+import (
+	"math/big"
+	"sort"
+
+	"github.com/hellracer2007/webCalc/calculator/ast"
+)
+
+const (
+	// maxDivisorBits bounds the integers whose divisors are enumerated
+	// when looking for rational roots and Kronecker factors.
+	maxDivisorBits = 48
+	// maxKroneckerTrials bounds the interpolations tried per degree.
+	maxKroneckerTrials = 200000
+)
+
+// Factor is a factor of a polynomial with its multiplicity.
+type Factor struct {
+	Poly         *Polynomial
+	Multiplicity int
+}
+
+// Factor splits p over the integers: p is the returned constant times
+// the product of the factors, each primitive with integer coefficients
+// and a positive leading coefficient. Rational roots give the linear
+// factors, Kronecker's method finds the others for moderate coefficients.
+func (p *Polynomial) Factor() (*big.Rat, []Factor) {
+	if p.Degree() < 1 {
+		return new(big.Rat).Set(p.Leading()), nil
+	}
+	var factors []Factor
+	for i, s := range p.SquareFree() {
+		if s.Degree() < 1 {
+			continue
+		}
+		for _, f := range factorSquareFree(primitive(s)) {
+			factors = append(factors, Factor{f, i + 1})
+		}
+	}
+	sort.SliceStable(factors, func(i, j int) bool {
+		if factors[i].Poly.Degree() != factors[j].Poly.Degree() {
+			return factors[i].Poly.Degree() < factors[j].Poly.Degree()
+		}
+		return factors[i].Poly.String() < factors[j].Poly.String()
+	})
+	c := new(big.Rat).Set(p.Leading())
+	for _, f := range factors {
+		for k := 0; k < f.Multiplicity; k++ {
+			c.Quo(c, f.Poly.Leading())
+		}
+	}
+	return c, factors
+}
+
+// FactorExpression returns p written as a product of its factors.
+func (p *Polynomial) FactorExpression() ast.Expression {
+	c, factors := p.Factor()
+	if len(factors) == 0 {
+		return p.Expression()
+	}
+	var result ast.Expression
+	for _, f := range factors {
+		e := f.Poly.Expression()
+		if f.Multiplicity > 1 {
+			e = pow(e, number(float64(f.Multiplicity)))
+		}
+		if result == nil {
+			result = e
+		} else {
+			result = mul(result, e)
+		}
+	}
+	abs := new(big.Rat).Abs(c)
+	switch {
+	case abs.IsInt() && abs.Num().Cmp(big.NewInt(1)) == 0:
+	case abs.IsInt():
+		result = mul(integer(abs.Num()), result)
+	default:
+		result = mul(div(integer(abs.Num()), integer(abs.Denom())), result)
+	}
+	if c.Sign() < 0 {
+		result = neg(result)
+	}
+	return result
+}
+
+// primitive scales p to integer coefficients without a common divisor and
+// a positive leading coefficient.
+func primitive(p *Polynomial) *Polynomial {
+	lcm := big.NewInt(1)
+	for _, c := range p.Coeffs {
+		d := c.Denom()
+		g := new(big.Int).GCD(nil, nil, lcm, d)
+		lcm.Mul(lcm, new(big.Int).Quo(d, g))
+	}
+	content := new(big.Int)
+	for _, c := range p.Coeffs {
+		n := new(big.Int).Mul(c.Num(), new(big.Int).Quo(lcm, c.Denom()))
+		content.GCD(nil, nil, content, new(big.Int).Abs(n))
+	}
+	scale := new(big.Rat).SetFrac(lcm, content)
+	if p.Leading().Sign() < 0 {
+		scale.Neg(scale)
+	}
+	return p.scale(scale)
+}
+
+// factorSquareFree factors a primitive square-free integer polynomial.
+func factorSquareFree(p *Polynomial) []*Polynomial {
+	var factors []*Polynomial
+	for _, r := range rationalRoots(p) {
+		// q x - p for the root p/q
+		linear := newPolynomial(p.Var, new(big.Rat).SetInt(new(big.Int).Neg(r.Num())), new(big.Rat).SetInt(r.Denom()))
+		factors = append(factors, linear)
+		p, _, _ = p.Div(linear)
+	}
+	if p.Degree() >= 1 {
+		factors = append(factors, kronecker(p)...)
+	}
+	return factors
+}
+
+// rationalRoots returns the rational roots of an integer polynomial, the
+// candidates being ±a/b with a dividing the constant and b the leading
+// coefficient.
+func rationalRoots(p *Polynomial) []*big.Rat {
+	var roots []*big.Rat
+	if p.Coeffs[0].Sign() == 0 {
+		roots = append(roots, new(big.Rat))
+		p, _, _ = p.Div(newPolynomial(p.Var, new(big.Rat), big.NewRat(1, 1)))
+	}
+	if p.Degree() < 1 {
+		return roots
+	}
+	nums := divisors(p.Coeffs[0].Num())
+	dens := divisors(p.Leading().Num())
+	if nums == nil || dens == nil {
+		return roots
+	}
+	seen := map[string]bool{}
+	for _, a := range nums {
+		for _, b := range dens {
+			for _, sign := range []int64{1, -1} {
+				r := new(big.Rat).SetFrac(new(big.Int).Mul(a, big.NewInt(sign)), b)
+				if seen[r.String()] {
+					continue
+				}
+				seen[r.String()] = true
+				if p.Eval(r).Sign() == 0 {
+					roots = append(roots, r)
+				}
+			}
+		}
+	}
+	sort.Slice(roots, func(i, j int) bool { return roots[i].Cmp(roots[j]) < 0 })
+	return roots
+}
+
+// divisors lists the positive divisors of n, nil when n is too large to
+// factor by trial division.
+func divisors(n *big.Int) []*big.Int {
+	n = new(big.Int).Abs(n)
+	if n.BitLen() > maxDivisorBits || n.Sign() == 0 {
+		return nil
+	}
+	m := n.Int64()
+	var small, large []*big.Int
+	for d := int64(1); d*d <= m; d++ {
+		if m%d == 0 {
+			small = append(small, big.NewInt(d))
+			if d*d != m {
+				large = append([]*big.Int{big.NewInt(m / d)}, large...)
+			}
+		}
+	}
+	return append(small, large...)
+}
+
+// kronecker factors an integer polynomial without rational roots: a
+// factor of degree d is fixed by its values at d+1 points, which must
+// divide the values of p there, so every combination of divisors is
+// interpolated and tried.
+func kronecker(p *Polynomial) []*Polynomial {
+	n := p.Degree()
+	for d := 2; d <= n/2; d++ {
+		points := make([]*big.Rat, d+1)
+		choices := make([][]*big.Int, d+1)
+		for i := range points {
+			// 0, 1, -1, 2, -2, ...
+			x := int64((i + 1) / 2)
+			if i%2 == 0 {
+				x = -x
+			}
+			points[i] = big.NewRat(x, 1)
+			divs := divisors(p.Eval(points[i]).Num())
+			if divs == nil {
+				return []*Polynomial{p}
+			}
+			choices[i] = divs
+			if i > 0 {
+				// the sign of the first value is free, a factor is only
+				// fixed up to sign
+				for _, dv := range divs {
+					choices[i] = append(choices[i], new(big.Int).Neg(dv))
+				}
+			}
+		}
+		basis := lagrangeBasis(p.Var, points)
+		index := make([]int, d+1)
+		for trial := 0; trial < maxKroneckerTrials; trial++ {
+			g := newPolynomial(p.Var)
+			for i, b := range basis {
+				g = g.add(b.scale(new(big.Rat).SetInt(choices[i][index[i]])))
+			}
+			if g.Degree() == d && isIntegral(g) {
+				if q, r, _ := p.Div(g); r.IsZero() && isIntegral(q) {
+					g = primitive(g)
+					q, _, _ = p.Div(g)
+					return append(kronecker(g), kronecker(q)...)
+				}
+			}
+			// advance the odometer
+			k := 0
+			for k <= d {
+				index[k]++
+				if index[k] < len(choices[k]) {
+					break
+				}
+				index[k] = 0
+				k++
+			}
+			if k > d {
+				break
+			}
+		}
+	}
+	return []*Polynomial{p}
+}
+
+// lagrangeBasis returns the polynomials that are 1 at one of the points
+// and 0 at all others.
+func lagrangeBasis(x string, points []*big.Rat) []*Polynomial {
+	basis := make([]*Polynomial, len(points))
+	for i, xi := range points {
+		b := newPolynomial(x, big.NewRat(1, 1))
+		for j, xj := range points {
+			if i == j {
+				continue
+			}
+			denom := new(big.Rat).Sub(xi, xj)
+			linear := newPolynomial(x, new(big.Rat).Quo(new(big.Rat).Neg(xj), denom), new(big.Rat).Inv(denom))
+			b = b.Mul(linear)
+		}
+		basis[i] = b
+	}
+	return basis
+}
+
+func isIntegral(p *Polynomial) bool {
+	for _, c := range p.Coeffs {
+		if !c.IsInt() {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *Polynomial) add(q *Polynomial) *Polynomial {
+	return p.sub(q.scale(big.NewRat(-1, 1)))
+}
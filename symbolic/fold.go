@@ -0,0 +1,62 @@
+package symbolic
+
+import "github.com/hellracer2007/webCalc/calculator/ast"
+
+// Fold replaces every constant subtree of expr by its value. value is
+// asked for the value of a subtree and reports false when it has none,
+// because the subtree depends on a variable or is not a plain number.
+// Bare identifiers are left alone, they may name a bound variable.
+func Fold(expr ast.Expression, value func(ast.Expression) (float64, bool)) ast.Expression {
+	return Replace(expr, func(e ast.Expression) ast.Expression {
+		switch e.(type) {
+		case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.Identifier, *ast.UnitLiteral:
+			return e
+		}
+		if v, ok := value(e); ok {
+			return number(v)
+		}
+		return nil
+	})
+}
+
+// Replace rebuilds expr with every subtree for which replace returns an
+// expression swapped for it. replace is asked from the root down and
+// returns nil for subtrees it keeps, whose parts are asked next.
+func Replace(expr ast.Expression, replace func(ast.Expression) ast.Expression) ast.Expression {
+	if r := replace(expr); r != nil {
+		return r
+	}
+	fold := func(e ast.Expression) ast.Expression { return Replace(e, replace) }
+	switch node := expr.(type) {
+	case *ast.PrefixExpression:
+		return &ast.PrefixExpression{Token: node.Token, Operator: node.Operator, Right: fold(node.Right)}
+	case *ast.InfixExpression:
+		return &ast.InfixExpression{Token: node.Token, Left: fold(node.Left), Operator: node.Operator, Right: fold(node.Right)}
+	case *ast.Procedure:
+		return &ast.Procedure{Token: node.Token, Func: node.Func, Body: fold(node.Body)}
+	case *ast.PostfixExpression:
+		return &ast.PostfixExpression{Token: node.Token, Operator: node.Operator, Left: fold(node.Left)}
+	case *ast.ConversionExpression:
+		return &ast.ConversionExpression{Token: node.Token, Left: fold(node.Left), Unit: node.Unit}
+	case *ast.VectorLiteral:
+		elements := make([]ast.Expression, len(node.Elements))
+		for i, el := range node.Elements {
+			elements[i] = fold(el)
+		}
+		return &ast.VectorLiteral{Token: node.Token, Elements: elements}
+	case *ast.CallExpression:
+		args := make([]ast.Expression, len(node.Arguments))
+		for i, a := range node.Arguments {
+			args[i] = fold(a)
+		}
+		return &ast.CallExpression{Token: node.Token, Func: node.Func, Arguments: args}
+	case *ast.Equation:
+		return &ast.Equation{Token: node.Token, Left: fold(node.Left), Right: fold(node.Right)}
+	}
+	return expr
+}
+
+// DependsOn reports whether the variable x occurs in expr.
+func DependsOn(expr ast.Expression, x string) bool {
+	return dependsOn(expr, x)
+}
@@ -0,0 +1,91 @@
+package symbolic
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/hellracer2007/webCalc/calculator/ast"
+	"github.com/hellracer2007/webCalc/calculator/token"
+)
+
+// The evaluator picks the operation from the token literal, so every node
+// built here carries the token the parser would have produced for it.
+
+var operatorTokens = map[string]token.TokenType{
+	"+": token.PLUS,
+	"-": token.MINUS,
+	"*": token.AST,
+	"/": token.DIV,
+	"^": token.ELEVATE,
+}
+
+func number(v float64) ast.Expression {
+	if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+		lit := strconv.FormatInt(int64(v), 10)
+		return &ast.IntegerLiteral{Token: token.Token{Type: token.INT, Literal: lit}, Value: int64(v)}
+	}
+	lit := strconv.FormatFloat(v, 'g', -1, 64)
+	return &ast.FloatLiteral{Token: token.Token{Type: token.FLOAT, Literal: lit}, Value: v}
+}
+
+func infix(op string, left, right ast.Expression) ast.Expression {
+	return &ast.InfixExpression{
+		Token:    token.Token{Type: operatorTokens[op], Literal: op},
+		Left:     left,
+		Operator: op,
+		Right:    right,
+	}
+}
+
+func add(left, right ast.Expression) ast.Expression { return infix("+", left, right) }
+func sub(left, right ast.Expression) ast.Expression { return infix("-", left, right) }
+func mul(left, right ast.Expression) ast.Expression { return infix("*", left, right) }
+func div(left, right ast.Expression) ast.Expression { return infix("/", left, right) }
+func pow(left, right ast.Expression) ast.Expression { return infix("^", left, right) }
+
+func neg(right ast.Expression) ast.Expression {
+	return &ast.PrefixExpression{
+		Token:    token.Token{Type: token.MINUS, Literal: "-"},
+		Operator: "-",
+		Right:    right,
+	}
+}
+
+func proc(name string, body ast.Expression) ast.Expression {
+	return &ast.Procedure{Token: token.Token{Type: token.PROC, Literal: name}, Func: name, Body: body}
+}
+
+func ident(name string) ast.Expression {
+	return &ast.Identifier{Token: token.Token{Type: token.IDENT, Literal: name}, Value: name}
+}
+
+// numberValue reports the value of a numeric literal.
+func numberValue(expr ast.Expression) (float64, bool) {
+	switch n := expr.(type) {
+	case *ast.IntegerLiteral:
+		return float64(n.Value), true
+	case *ast.FloatLiteral:
+		if n == ast.Euler {
+			return 0, false
+		}
+		return n.Value, true
+	}
+	return 0, false
+}
+
+func isNumber(expr ast.Expression, v float64) bool {
+	n, ok := numberValue(expr)
+	return ok && n == v
+}
+
+// dependsOn reports whether the variable x occurs in expr.
+func dependsOn(expr ast.Expression, x string) bool {
+	found := false
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Identifier); ok && id.Value == x {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
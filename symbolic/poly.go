@@ -0,0 +1,225 @@
+package symbolic
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/hellracer2007/webCalc/calculator/ast"
+)
+
+// Polynomial is a polynomial in one variable with exact rational
+// coefficients, Coeffs[i] belongs to x^i. The zero polynomial has no
+// coefficients.
+type Polynomial struct {
+	Var    string
+	Coeffs []*big.Rat
+}
+
+// NewPolynomial expands expr and reads it as a polynomial in x.
+func NewPolynomial(expr ast.Expression, x string) (*Polynomial, error) {
+	p := &Polynomial{Var: x}
+	ts, err := expandTerms(expr)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range combine(ts) {
+		n := 0
+		for _, f := range t.factors {
+			id, ok := f.base.(*ast.Identifier)
+			e, isNum := numberValue(f.exp)
+			if !ok || id.Value != x || !isNum || e < 0 || e != float64(int(e)) {
+				return nil, fmt.Errorf("%s is not a polynomial in %s with numeric coefficients", expr.String(), x)
+			}
+			n += int(e)
+		}
+		for len(p.Coeffs) <= n {
+			p.Coeffs = append(p.Coeffs, new(big.Rat))
+		}
+		p.Coeffs[n].Add(p.Coeffs[n], t.coef)
+	}
+	return p.trim(), nil
+}
+
+func newPolynomial(x string, coeffs ...*big.Rat) *Polynomial {
+	p := &Polynomial{Var: x, Coeffs: coeffs}
+	return p.trim()
+}
+
+func (p *Polynomial) trim() *Polynomial {
+	n := len(p.Coeffs)
+	for n > 0 && p.Coeffs[n-1].Sign() == 0 {
+		n--
+	}
+	p.Coeffs = p.Coeffs[:n]
+	return p
+}
+
+// Degree is the degree of p, -1 for the zero polynomial.
+func (p *Polynomial) Degree() int { return len(p.Coeffs) - 1 }
+
+// IsZero reports whether p is the zero polynomial.
+func (p *Polynomial) IsZero() bool { return len(p.Coeffs) == 0 }
+
+// Leading is the coefficient of the highest power.
+func (p *Polynomial) Leading() *big.Rat {
+	if p.IsZero() {
+		return new(big.Rat)
+	}
+	return p.Coeffs[len(p.Coeffs)-1]
+}
+
+// Floats returns the coefficients as float64, lowest power first.
+func (p *Polynomial) Floats() []float64 {
+	fs := make([]float64, len(p.Coeffs))
+	for i, c := range p.Coeffs {
+		fs[i], _ = c.Float64()
+	}
+	return fs
+}
+
+// Expression returns p as an expression tree, highest power first.
+func (p *Polynomial) Expression() ast.Expression {
+	ts := make([]term, 0, len(p.Coeffs))
+	for i, c := range p.Coeffs {
+		if c.Sign() == 0 {
+			continue
+		}
+		t := term{coef: c}
+		if i > 0 {
+			t.factors = []factor{{ident(p.Var), number(float64(i))}}
+		}
+		ts = append(ts, t)
+	}
+	return sum(ts)
+}
+
+func (p *Polynomial) String() string { return p.Expression().String() }
+
+// Eval evaluates p at x.
+func (p *Polynomial) Eval(x *big.Rat) *big.Rat {
+	result := new(big.Rat)
+	for i := len(p.Coeffs) - 1; i >= 0; i-- {
+		result.Mul(result, x)
+		result.Add(result, p.Coeffs[i])
+	}
+	return result
+}
+
+func (p *Polynomial) scale(r *big.Rat) *Polynomial {
+	coeffs := make([]*big.Rat, len(p.Coeffs))
+	for i, c := range p.Coeffs {
+		coeffs[i] = new(big.Rat).Mul(c, r)
+	}
+	return newPolynomial(p.Var, coeffs...)
+}
+
+// Monic divides p by its leading coefficient.
+func (p *Polynomial) Monic() *Polynomial {
+	if p.IsZero() {
+		return p
+	}
+	return p.scale(new(big.Rat).Inv(p.Leading()))
+}
+
+// Mul returns p * q.
+func (p *Polynomial) Mul(q *Polynomial) *Polynomial {
+	if p.IsZero() || q.IsZero() {
+		return newPolynomial(p.Var)
+	}
+	coeffs := make([]*big.Rat, len(p.Coeffs)+len(q.Coeffs)-1)
+	for i := range coeffs {
+		coeffs[i] = new(big.Rat)
+	}
+	for i, a := range p.Coeffs {
+		for j, b := range q.Coeffs {
+			coeffs[i+j].Add(coeffs[i+j], new(big.Rat).Mul(a, b))
+		}
+	}
+	return newPolynomial(p.Var, coeffs...)
+}
+
+// Div divides p by q, returning quotient and remainder.
+func (p *Polynomial) Div(q *Polynomial) (*Polynomial, *Polynomial, error) {
+	if q.IsZero() {
+		return nil, nil, fmt.Errorf("division by the zero polynomial")
+	}
+	rem := make([]*big.Rat, len(p.Coeffs))
+	for i, c := range p.Coeffs {
+		rem[i] = new(big.Rat).Set(c)
+	}
+	n := q.Degree()
+	if p.Degree() < n {
+		return newPolynomial(p.Var), newPolynomial(p.Var, rem...), nil
+	}
+	quo := make([]*big.Rat, p.Degree()-n+1)
+	for i := len(quo) - 1; i >= 0; i-- {
+		c := new(big.Rat).Quo(rem[i+n], q.Leading())
+		quo[i] = c
+		for j, b := range q.Coeffs {
+			rem[i+j].Sub(rem[i+j], new(big.Rat).Mul(c, b))
+		}
+	}
+	return newPolynomial(p.Var, quo...), newPolynomial(p.Var, rem[:n]...), nil
+}
+
+// Derivative returns dp/dx.
+func (p *Polynomial) Derivative() *Polynomial {
+	if p.Degree() < 1 {
+		return newPolynomial(p.Var)
+	}
+	coeffs := make([]*big.Rat, p.Degree())
+	for i := range coeffs {
+		coeffs[i] = new(big.Rat).Mul(p.Coeffs[i+1], big.NewRat(int64(i+1), 1))
+	}
+	return newPolynomial(p.Var, coeffs...)
+}
+
+// GCD returns the monic greatest common divisor of p and q.
+func GCD(p, q *Polynomial) *Polynomial {
+	for !q.IsZero() {
+		_, r, _ := p.Div(q)
+		p, q = q, r
+	}
+	return p.Monic()
+}
+
+// SquareFree splits p into square-free factors with Yun's algorithm:
+// p is the leading coefficient times factors[i]^(i+1). Factors equal to
+// 1 are kept so the exponent can be read off the index.
+func (p *Polynomial) SquareFree() []*Polynomial {
+	var factors []*Polynomial
+	if p.Degree() < 1 {
+		return factors
+	}
+	dp := p.Derivative()
+	a := GCD(p, dp)
+	b, _, _ := p.Div(a)
+	c, _, _ := dp.Div(a)
+	d := c.sub(b.Derivative())
+	for b.Degree() > 0 {
+		a = GCD(b, d)
+		factors = append(factors, a)
+		b, _, _ = b.Div(a)
+		c, _, _ = d.Div(a)
+		d = c.sub(b.Derivative())
+	}
+	return factors
+}
+
+func (p *Polynomial) sub(q *Polynomial) *Polynomial {
+	n := len(p.Coeffs)
+	if len(q.Coeffs) > n {
+		n = len(q.Coeffs)
+	}
+	coeffs := make([]*big.Rat, n)
+	for i := range coeffs {
+		coeffs[i] = new(big.Rat)
+		if i < len(p.Coeffs) {
+			coeffs[i].Add(coeffs[i], p.Coeffs[i])
+		}
+		if i < len(q.Coeffs) {
+			coeffs[i].Sub(coeffs[i], q.Coeffs[i])
+		}
+	}
+	return newPolynomial(p.Var, coeffs...)
+}
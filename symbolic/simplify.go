@@ -0,0 +1,444 @@
+package symbolic
+
+import (
+	"math"
+	"math/big"
+	"sort"
+
+	"github.com/hellracer2007/webCalc/calculator/ast"
+	"github.com/hellracer2007/webCalc/calculator/constants"
+	"github.com/hellracer2007/webCalc/calculator/token"
+)
+
+// Simplify returns an equivalent expression in a canonical form: sums are
+// flattened and like terms combined, products are flattened with their
+// numeric coefficient first and equal bases merged into powers, identities
+// such as x + 0, 1 * x and x^1 disappear and arithmetic on literals is
+// folded exactly, so 1/3 stays a fraction. Multiplication is assumed to
+// commute. The tree passed in is not modified.
+func Simplify(expr ast.Expression) ast.Expression {
+	switch node := expr.(type) {
+	case *ast.PrefixExpression:
+		if node.Operator == "+" {
+			return Simplify(node.Right)
+		}
+		return sum(terms(expr))
+	case *ast.InfixExpression:
+		switch node.Operator {
+		case "+", "-", "*", "/", "^":
+			return sum(terms(expr))
+		}
+		return simplifyInfix(node.Operator, Simplify(node.Left), Simplify(node.Right))
+	case *ast.Procedure:
+		body := Simplify(node.Body)
+		if node.Func == "ln" && body == ast.Euler {
+			return number(1)
+		}
+		return &ast.Procedure{Token: node.Token, Func: node.Func, Body: body}
+	case *ast.PostfixExpression:
+		return &ast.PostfixExpression{Token: node.Token, Operator: node.Operator, Left: Simplify(node.Left)}
+	case *ast.ConversionExpression:
+		return &ast.ConversionExpression{Token: node.Token, Left: Simplify(node.Left), Unit: node.Unit}
+	case *ast.VectorLiteral:
+		return &ast.VectorLiteral{Token: node.Token, Elements: simplifyAll(node.Elements)}
+	case *ast.CallExpression:
+		return &ast.CallExpression{Token: node.Token, Func: node.Func, Arguments: simplifyAll(node.Arguments)}
+	case *ast.Equation:
+		return &ast.Equation{Token: node.Token, Left: Simplify(node.Left), Right: Simplify(node.Right)}
+	}
+	return expr
+}
+
+func simplifyAll(exprs []ast.Expression) []ast.Expression {
+	result := make([]ast.Expression, len(exprs))
+	for i, e := range exprs {
+		result[i] = Simplify(e)
+	}
+	return result
+}
+
+// simplifyInfix handles the operators that are not part of the sum and
+// product normal form.
+func simplifyInfix(op string, l, r ast.Expression) ast.Expression {
+	if op == "E" {
+		// 2E3 is 2 * 10^3
+		return Simplify(mul(l, pow(number(10), r)))
+	}
+	return infix(op, l, r)
+}
+
+// A term is a coefficient times a product of powers, the normal form of
+// everything built from *, / and ^. The coefficient prints as a decimal
+// when it came from a decimal literal.
+type term struct {
+	coef    *big.Rat
+	decimal bool
+	factors []factor
+}
+
+type factor struct {
+	base, exp ast.Expression
+}
+
+func constantTerm(r *big.Rat, decimal bool) term {
+	return term{coef: r, decimal: decimal}
+}
+
+// rat returns the exact value of a numeric literal.
+func rat(expr ast.Expression) (*big.Rat, bool, bool) {
+	switch n := expr.(type) {
+	case *ast.IntegerLiteral:
+		return new(big.Rat).SetInt64(n.Value), false, true
+	case *ast.FloatLiteral:
+		if n == ast.Euler {
+			return nil, false, false
+		}
+		if r, ok := new(big.Rat).SetString(n.Token.Literal); ok {
+			return r, true, true
+		}
+		if r := new(big.Rat).SetFloat64(n.Value); r != nil {
+			return r, true, true
+		}
+	}
+	return nil, false, false
+}
+
+// terms flattens a sum into its terms, each in product normal form.
+func terms(expr ast.Expression) []term {
+	switch node := expr.(type) {
+	case *ast.InfixExpression:
+		switch node.Operator {
+		case "+":
+			return append(terms(node.Left), terms(node.Right)...)
+		case "-":
+			return append(terms(node.Left), negateTerms(terms(node.Right))...)
+		}
+	case *ast.PrefixExpression:
+		if node.Operator == "-" {
+			return negateTerms(terms(node.Right))
+		}
+		return terms(node.Right)
+	}
+	return []term{product(expr)}
+}
+
+func negateTerms(ts []term) []term {
+	result := make([]term, len(ts))
+	for i, t := range ts {
+		t.coef = new(big.Rat).Neg(t.coef)
+		result[i] = t
+	}
+	return result
+}
+
+// product brings expr into product normal form.
+func product(expr ast.Expression) term {
+	if r, decimal, ok := rat(expr); ok {
+		return constantTerm(r, decimal)
+	}
+	switch node := expr.(type) {
+	case *ast.PrefixExpression:
+		t := product(node.Right)
+		if node.Operator == "-" {
+			t.coef = new(big.Rat).Neg(t.coef)
+		}
+		return t
+	case *ast.InfixExpression:
+		switch node.Operator {
+		case "*":
+			return multiply(product(node.Left), product(node.Right))
+		case "/":
+			denominator := product(node.Right)
+			if denominator.coef.Sign() == 0 {
+				// leave x / 0 for the evaluator to report
+				return term{coef: big.NewRat(1, 1), factors: []factor{{div(Simplify(node.Left), number(0)), number(1)}}}
+			}
+			return multiply(product(node.Left), reciprocal(denominator))
+		case "^":
+			return power(Simplify(node.Left), Simplify(node.Right))
+		}
+	}
+	s := Simplify(expr)
+	if r, decimal, ok := rat(s); ok {
+		return constantTerm(r, decimal)
+	}
+	if p, ok := s.(*ast.InfixExpression); ok && (p.Operator == "*" || p.Operator == "/" || p.Operator == "^") {
+		return product(s)
+	}
+	return term{coef: big.NewRat(1, 1), factors: []factor{{s, number(1)}}}
+}
+
+// power raises base to exp. Integer powers are distributed over products,
+// (2 * x)^2 is 4 * x^2, other powers stay a single factor.
+func power(base, exp ast.Expression) term {
+	if isNumber(exp, 0) {
+		return constantTerm(big.NewRat(1, 1), false)
+	}
+	e, isNum := numberValue(exp)
+	if !isNum || e != math.Trunc(e) || math.Abs(e) > 64 {
+		// (x^2)^0.5 is |x| rather than x, so only integer powers are
+		// taken apart
+		if r, _, ok := rat(base); ok && r.Cmp(big.NewRat(1, 1)) == 0 {
+			return constantTerm(r, false)
+		}
+		return term{coef: big.NewRat(1, 1), factors: []factor{{base, exp}}}
+	}
+	n := int(e)
+	t := product(base)
+	if n < 0 {
+		if t.coef.Sign() == 0 {
+			return term{coef: big.NewRat(1, 1), factors: []factor{{base, exp}}}
+		}
+		t = reciprocal(t)
+		n = -n
+	}
+	result := term{coef: new(big.Rat).SetInt64(1), decimal: t.decimal}
+	num := new(big.Int).Exp(t.coef.Num(), big.NewInt(int64(n)), nil)
+	den := new(big.Int).Exp(t.coef.Denom(), big.NewInt(int64(n)), nil)
+	result.coef.SetFrac(num, den)
+	for _, f := range t.factors {
+		result.factors = append(result.factors, factor{f.base, scaleExponent(f.exp, float64(n))})
+	}
+	return result
+}
+
+func scaleExponent(exp ast.Expression, n float64) ast.Expression {
+	if e, ok := numberValue(exp); ok {
+		return number(e * n)
+	}
+	return Simplify(mul(number(n), exp))
+}
+
+func reciprocal(t term) term {
+	result := term{coef: new(big.Rat).Inv(t.coef), decimal: t.decimal}
+	for _, f := range t.factors {
+		result.factors = append(result.factors, factor{f.base, negateExponent(f.exp)})
+	}
+	return result
+}
+
+func negateExponent(exp ast.Expression) ast.Expression {
+	if e, ok := numberValue(exp); ok {
+		return number(-e)
+	}
+	return Simplify(neg(exp))
+}
+
+// multiply multiplies two terms, adding the exponents of equal bases.
+func multiply(a, b term) term {
+	result := term{coef: new(big.Rat).Mul(a.coef, b.coef), decimal: a.decimal || b.decimal}
+	result.factors = append(result.factors, a.factors...)
+	for _, f := range b.factors {
+		merged := false
+		for i, g := range result.factors {
+			if equal(f.base, g.base) {
+				result.factors[i].exp = addExponents(g.exp, f.exp)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			result.factors = append(result.factors, f)
+		}
+	}
+	factors := result.factors[:0]
+	for _, f := range result.factors {
+		if !isNumber(f.exp, 0) {
+			factors = append(factors, f)
+		}
+	}
+	result.factors = factors
+	return result
+}
+
+func addExponents(a, b ast.Expression) ast.Expression {
+	x, ok1 := numberValue(a)
+	y, ok2 := numberValue(b)
+	if ok1 && ok2 {
+		return number(x + y)
+	}
+	return Simplify(add(a, b))
+}
+
+// sum combines like terms, orders them by falling degree and builds the
+// expression.
+func sum(ts []term) ast.Expression {
+	combined := combine(ts)
+	sort.SliceStable(combined, func(i, j int) bool {
+		di, dj := termDegree(combined[i]), termDegree(combined[j])
+		if di != dj {
+			return di > dj
+		}
+		return monomialLess(combined[i].factors, combined[j].factors)
+	})
+	if len(combined) == 0 {
+		return number(0)
+	}
+	var result ast.Expression
+	for i, t := range combined {
+		negative := t.coef.Sign() < 0
+		t.coef = new(big.Rat).Abs(t.coef)
+		e := build(t)
+		switch {
+		case i == 0 && negative:
+			result = negate(e)
+		case i == 0:
+			result = e
+		case negative:
+			result = sub(result, e)
+		default:
+			result = add(result, e)
+		}
+	}
+	return result
+}
+
+// termDegree is the total exponent of a term, used to write polynomials with
+// the highest power first and the constant last.
+func termDegree(t term) float64 {
+	d := 0.0
+	for _, f := range t.factors {
+		if e, ok := numberValue(f.exp); ok {
+			d += e
+		} else {
+			d++
+		}
+	}
+	return d
+}
+
+// monomialLess orders terms of equal degree lexicographically, x^3
+// before x^2 y before x y^2 before y^3.
+func monomialLess(a, b []factor) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if sa, sb := a[i].base.String(), b[i].base.String(); sa != sb {
+			return sa < sb
+		}
+		ea, okA := numberValue(a[i].exp)
+		eb, okB := numberValue(b[i].exp)
+		if okA && okB && ea != eb {
+			return ea > eb
+		}
+		if sa, sb := a[i].exp.String(), b[i].exp.String(); sa != sb {
+			return sa < sb
+		}
+	}
+	return len(a) < len(b)
+}
+
+// rank orders factors: constants such as π first, then variables, then
+// everything else.
+func rank(expr ast.Expression) int {
+	switch node := expr.(type) {
+	case *ast.FloatLiteral:
+		return 0
+	case *ast.Identifier:
+		if _, ok := constants.Lookup(node.Value); ok {
+			return 0
+		}
+		return 1
+	}
+	return 2
+}
+
+func sortFactors(factors []factor) {
+	sort.SliceStable(factors, func(i, j int) bool {
+		ri, rj := rank(factors[i].base), rank(factors[j].base)
+		if ri != rj {
+			return ri < rj
+		}
+		return factors[i].base.String() < factors[j].base.String()
+	})
+}
+
+func monomial(factors []factor) ast.Expression {
+	return build(term{coef: big.NewRat(1, 1), factors: factors})
+}
+
+// build turns a term with a non-negative coefficient into an expression,
+// factors with a negative exponent go to the denominator.
+func build(t term) ast.Expression {
+	sortFactors(t.factors)
+	var numerator, denominator ast.Expression
+	appendFactor := func(to ast.Expression, e ast.Expression) ast.Expression {
+		if to == nil {
+			return e
+		}
+		return mul(to, e)
+	}
+	num, den := t.coef.Num(), t.coef.Denom()
+	if t.decimal && terminates(den) {
+		if !t.coef.IsInt() {
+			f, _ := t.coef.Float64()
+			numerator = number(f)
+		} else if num.Cmp(big.NewInt(1)) != 0 || len(t.factors) == 0 {
+			numerator = integer(num)
+		}
+		den = big.NewInt(1)
+	} else if num.Cmp(big.NewInt(1)) != 0 || len(t.factors) == 0 {
+		numerator = integer(num)
+	}
+	if den.Cmp(big.NewInt(1)) != 0 {
+		denominator = integer(den)
+	}
+	for _, f := range t.factors {
+		e, ok := numberValue(f.exp)
+		switch {
+		case ok && e < 0:
+			denominator = appendFactor(denominator, powerNode(f.base, number(-e)))
+		default:
+			numerator = appendFactor(numerator, powerNode(f.base, f.exp))
+		}
+	}
+	if numerator == nil {
+		numerator = number(1)
+	}
+	if denominator == nil {
+		return numerator
+	}
+	return div(numerator, denominator)
+}
+
+func powerNode(base, exp ast.Expression) ast.Expression {
+	if isNumber(exp, 1) {
+		return base
+	}
+	return pow(base, exp)
+}
+
+// terminates reports whether 1/den has a finite decimal expansion.
+func terminates(den *big.Int) bool {
+	d := new(big.Int).Set(den)
+	for _, p := range []int64{2, 5} {
+		m := new(big.Int)
+		for {
+			q, r := new(big.Int).QuoRem(d, big.NewInt(p), m)
+			if r.Sign() != 0 {
+				break
+			}
+			d = q
+		}
+	}
+	return d.Cmp(big.NewInt(1)) == 0
+}
+
+func integer(n *big.Int) ast.Expression {
+	if n.IsInt64() {
+		lit := n.String()
+		return &ast.IntegerLiteral{Token: token.Token{Type: token.INT, Literal: lit}, Value: n.Int64()}
+	}
+	f, _ := new(big.Float).SetInt(n).Float64()
+	return number(f)
+}
+
+func negate(expr ast.Expression) ast.Expression {
+	if v, ok := numberValue(expr); ok {
+		return number(-v)
+	}
+	if p, ok := expr.(*ast.PrefixExpression); ok && p.Operator == "-" {
+		return p.Right
+	}
+	return neg(expr)
+}
+
+func equal(a, b ast.Expression) bool { return a.String() == b.String() }
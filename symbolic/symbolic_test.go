@@ -0,0 +1,175 @@
+package symbolic
+
+import (
+	"testing"
+
+	"github.com/hellracer2007/webCalc/calculator/ast"
+	"github.com/hellracer2007/webCalc/calculator/lexer"
+	"github.com/hellracer2007/webCalc/calculator/parser"
+)
+
+func parse(t *testing.T, input string) ast.Expression {
+	program := parser.New(lexer.New(input)).ParseProgram()
+	if len(program.Statements) != 1 {
+		t.Fatalf("%s: expected one statement, got %d", input, len(program.Statements))
+	}
+	return program.Statements[0].(*ast.ExpressionStatement).Expression
+}
+
+func TestDerivative(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x^2", "(2 * x)"},
+		{"3*x^3 - 2*x + 7", "((9 * (x ^ 2)) - 2)"},
+		{"x*y", "y"},
+		{"-x", "-1"},
+		{"1/x", "(-(1 / (x ^ 2)))"},
+		{"sin(x)", "((π * cos(x)) / 180)"},
+		{"ln(x)/x", "(((-ln(x)) + 1) / (x ^ 2))"},
+		{"x^x", "((x ^ x) * (ln(x) + 1))"},
+		{"2^x", "((2 ^ x) * ln(2))"},
+		{"e^(2*x)", "(2 * (e ^ (2 * x)))"},
+		{"√x", "(1 / (2 * √(x)))"},
+		{"tanh(x)", "(1 / (cosh(x) ^ 2))"},
+		{"exp(2*x)", "(2 * exp(2 * x))"},
+		{"erf(x)", "((2 * exp(-(x ^ 2))) / √(π))"},
+		{"log1p(x)", "(1 / (x + 1))"},
+		{"[x, x^2]", "[1, (2 * x)]"},
+		{"5", "0"},
+	}
+	for _, tt := range tests {
+		d, err := Derivative(parse(t, tt.input), "x")
+		if err != nil {
+			t.Errorf("%s: %s", tt.input, err)
+			continue
+		}
+		if d.String() != tt.expected {
+			t.Errorf("d/dx %s: expected %s got %s", tt.input, tt.expected, d.String())
+		}
+	}
+
+	for _, input := range []string{"x!", "dot([x, 1], [1, 1])"} {
+		if _, err := Derivative(parse(t, input), "x"); err == nil {
+			t.Errorf("d/dx %s: expected an error", input)
+		}
+	}
+}
+
+func TestSimplify(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x + 0", "x"},
+		{"0 - x", "(-x)"},
+		{"1 * x * 1", "x"},
+		{"x * 0", "0"},
+		{"x ^ 1", "x"},
+		{"x ^ 0", "1"},
+		{"x - x", "0"},
+		{"x + x", "(2 * x)"},
+		{"x * x", "(x ^ 2)"},
+		{"x * x^2 / x", "(x ^ 2)"},
+		{"(x^2)^3", "(x ^ 6)"},
+		{"(x^2)^0.5", "((x ^ 2) ^ 0.5)"},
+		{"(2*x)^2", "(4 * (x ^ 2))"},
+		{"2 * (3 * x)", "(6 * x)"},
+		{"x * 4", "(4 * x)"},
+		{"y*x*2", "((2 * x) * y)"},
+		{"x*y - y*x", "0"},
+		{"3*x^2 - x^2 + x - 1 + 2", "(((2 * (x ^ 2)) + x) + 1)"},
+		{"x^a * x^b", "(x ^ (a + b))"},
+		{"π*x*2", "((2 * π) * x)"},
+		{"2 + 3 * 4", "14"},
+		{"1 / 3", "(1 / 3)"},
+		{"1/3 + 1/6", "(1 / 2)"},
+		{"0.1*x + 0.2*x", "(0.3 * x)"},
+		{"x^-2", "(1 / (x ^ 2))"},
+		{"(x+1)*(x+1)", "((x + 1) ^ 2)"},
+		{"6 / 3", "2"},
+		{"2E3 * x", "(2000 * x)"},
+		{"--x", "x"},
+		{"x - (-y)", "(x + y)"},
+		{"(y / x) * x", "y"},
+		{"x / 0", "(x / 0)"},
+		{"ln(e)", "1"},
+		{"[x + x, 2 * 3]", "[(2 * x), 6]"},
+	}
+	for _, tt := range tests {
+		if s := Simplify(parse(t, tt.input)).String(); s != tt.expected {
+			t.Errorf("%s: expected %s got %s", tt.input, tt.expected, s)
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(x+1)^2", "(((x ^ 2) + (2 * x)) + 1)"},
+		{"(x+y)^3", "((((x ^ 3) + ((3 * (x ^ 2)) * y)) + ((3 * x) * (y ^ 2))) + (y ^ 3))"},
+		{"(x-1)*(x+1)*(x^2+1)", "((x ^ 4) - 1)"},
+		{"x*(x+1)/2", "(((x ^ 2) / 2) + (x / 2))"},
+		{"(x+1)/(x+2)", "((x / (x + 2)) + (1 / (x + 2)))"},
+	}
+	for _, tt := range tests {
+		e, err := Expand(parse(t, tt.input))
+		if err != nil {
+			t.Errorf("%s: %s", tt.input, err)
+			continue
+		}
+		if s := e.String(); s != tt.expected {
+			t.Errorf("%s: expected %s got %s", tt.input, tt.expected, s)
+		}
+	}
+
+	if _, err := Expand(parse(t, "(x+1)^100")); err == nil {
+		t.Errorf("(x+1)^100: expected an error")
+	}
+	if e, err := Expand(parse(t, "x^100")); err != nil || e.String() != "(x ^ 100)" {
+		t.Errorf("x^100: expected (x ^ 100) got %v, %v", e, err)
+	}
+}
+
+func polynomial(t *testing.T, input string) *Polynomial {
+	p, err := NewPolynomial(parse(t, input), "x")
+	if err != nil {
+		t.Fatalf("%s: %s", input, err)
+	}
+	return p
+}
+
+func TestPolynomial(t *testing.T) {
+	q, r, err := polynomial(t, "x^3 + 2*x").Div(polynomial(t, "x^2 + 1"))
+	if err != nil || q.String() != "x" || r.String() != "x" {
+		t.Errorf("(x^3 + 2x) / (x^2 + 1): got %v, %v, %v", q, r, err)
+	}
+	if g := GCD(polynomial(t, "x^2 - 1"), polynomial(t, "2*x^2 + 4*x + 2")); g.String() != "(x + 1)" {
+		t.Errorf("gcd: expected (x + 1) got %s", g)
+	}
+	if _, err := NewPolynomial(parse(t, "x^2 + sin(x)"), "x"); err == nil {
+		t.Errorf("x^2 + sin(x): expected an error")
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x^2 - 1", "((x + 1) * (x - 1))"},
+		{"2*x^2 - 2", "(2 * ((x + 1) * (x - 1)))"},
+		{"x^3 - 2*x^2 + x", "(((x - 1) ^ 2) * x)"},
+		{"6*x^2 + 5*x + 1", "(((2 * x) + 1) * ((3 * x) + 1))"},
+		{"x^4 + 4", "((((x ^ 2) + (2 * x)) + 2) * (((x ^ 2) - (2 * x)) + 2))"},
+		{"x^2/2 - 1/2", "((1 / 2) * ((x + 1) * (x - 1)))"},
+		{"-x^2 + 1", "(-((x + 1) * (x - 1)))"},
+		{"x^2 - 2", "((x ^ 2) - 2)"},
+	}
+	for _, tt := range tests {
+		if s := polynomial(t, tt.input).FactorExpression().String(); s != tt.expected {
+			t.Errorf("factor %s: expected %s got %s", tt.input, tt.expected, s)
+		}
+	}
+}
@@ -13,18 +13,31 @@ const (
 	EULER		= "EULER"
 	INT			= "INT"
 	FLOAT		= "FLOAT"
+	IDENT		= "IDENT"
+	UNIT		= "UNIT"
+	DATE		= "DATE"
+	DURATION	= "DURATION"
 	
 	PLUS		= "+"
+	PLUSMINUS	= "±"
 	MINUS		= "-"
 	AST			= "*"
 	DIV			= "/"
 	LPAREN		= "("
 	RPAREN		= ")"
+	LBRACKET	= "["
+	RBRACKET	= "]"
+	LBRACE		= "{"
+	RBRACE		= "}"
+	COMMA		= ","
+	EQ			= "="
 	PROC		= "PROCEDURE"
 	FACTORIAL	= "!"
 	SINE		= "sin"
 	EXP			= "EXP"
 	ELEVATE		= "ELEVATE"
+	TO			= "TO"
+	CHOOSE		= "CHOOSE"
 )
 
 var Keywords = map[string]TokenType{
@@ -37,4 +50,26 @@ var Keywords = map[string]TokenType{
 	"arcsin":	PROC,
 	"arccos":	PROC,
 	"arctan":	PROC,
+	"sinh":	PROC,
+	"cosh":	PROC,
+	"tanh":	PROC,
+	"arcsinh":	PROC,
+	"arccosh":	PROC,
+	"arctanh":	PROC,
+	"sec":	PROC,
+	"csc":	PROC,
+	"cot":	PROC,
+	"arcsec":	PROC,
+	"arccsc":	PROC,
+	"arccot":	PROC,
+	"exp":	PROC,
+	"log2":	PROC,
+	"cbrt":	PROC,
+	"expm1":	PROC,
+	"log1p":	PROC,
+	"erf":	PROC,
+	"erfc":	PROC,
+	"to":	TO,
+	"in":	TO,
+	"choose":	CHOOSE,
 }
@@ -0,0 +1,344 @@
+package units
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+//go:embed units.txt
+var database string
+
+type prefix struct {
+	Symbol string
+	Factor *big.Rat
+}
+
+func decimalPrefix(symbol string, exp int) prefix {
+	f := ratPow(big.NewRat(10, 1), exp)
+	return prefix{Symbol: symbol, Factor: f}
+}
+
+func binaryPrefix(symbol string, exp int) prefix {
+	return prefix{Symbol: symbol, Factor: new(big.Rat).SetInt(new(big.Int).Lsh(big.NewInt(1), uint(exp)))}
+}
+
+var prefixes = []prefix{
+	decimalPrefix("Y", 24), decimalPrefix("Z", 21), decimalPrefix("E", 18),
+	decimalPrefix("P", 15), decimalPrefix("T", 12), decimalPrefix("G", 9),
+	decimalPrefix("M", 6), decimalPrefix("k", 3), decimalPrefix("h", 2),
+	decimalPrefix("da", 1), decimalPrefix("d", -1), decimalPrefix("c", -2),
+	decimalPrefix("m", -3), decimalPrefix("µ", -6), decimalPrefix("μ", -6),
+	decimalPrefix("u", -6), decimalPrefix("n", -9), decimalPrefix("p", -12),
+	decimalPrefix("f", -15), decimalPrefix("a", -18), decimalPrefix("z", -21),
+	decimalPrefix("y", -24),
+	binaryPrefix("Ki", 10), binaryPrefix("Mi", 20), binaryPrefix("Gi", 30),
+	binaryPrefix("Ti", 40),
+}
+
+type Registry struct {
+	units map[string]*Unit
+}
+
+func NewRegistry() *Registry {
+	return &Registry{units: map[string]*Unit{}}
+}
+
+// Default is the registry used by the lexer and evaluator, it is filled
+// from the embedded unit database and then from the files listed in the
+// CALC_UNITS environment variable, e.g. a file holding
+//
+//	define rack = 42 U
+//
+// makes 3 rack, 2 krack or 1 rack to m available.
+var Default = NewRegistry()
+
+func init() {
+	if err := Default.Load(strings.NewReader(database)); err != nil {
+		panic(err)
+	}
+	for _, path := range filepath.SplitList(os.Getenv("CALC_UNITS")) {
+		if err := Default.LoadFile(path); err != nil {
+			fmt.Fprintln(os.Stderr, "units:", err)
+		}
+	}
+}
+
+func LoadFile(path string) error { return Default.LoadFile(path) }
+
+func Lookup(name string) (*Unit, bool) { return Default.Lookup(name) }
+
+func Parse(expr string) (*Unit, error) { return Default.Parse(expr) }
+
+// IsUnit reports whether name is a unit, possibly with a prefix.
+func IsUnit(name string) bool {
+	_, ok := Default.Lookup(name)
+	return ok
+}
+
+// Lookup resolves a unit name. Exact names win over prefixed ones, so
+// min is a minute and not a milli-inch.
+func (r *Registry) Lookup(name string) (*Unit, bool) {
+	if u, ok := r.units[name]; ok {
+		return u, true
+	}
+	for _, p := range prefixes {
+		if !strings.HasPrefix(name, p.Symbol) {
+			continue
+		}
+		base, ok := r.units[name[len(p.Symbol):]]
+		if !ok {
+			continue
+		}
+		u := &Unit{
+			Factor: new(big.Rat).Mul(p.Factor, base.Factor),
+			Dim:    base.Dim,
+			Terms:  []Term{{Name: name, Power: 1}},
+		}
+		return u, true
+	}
+	return nil, false
+}
+
+// LoadFile reads project specific definitions from path, see Load.
+func (r *Registry) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := r.Load(f); err != nil {
+		return fmt.Errorf("%s: %s", path, err)
+	}
+	return nil
+}
+
+// Load reads unit definitions, one per line:
+//
+//	base m length
+//	define km_h = km/h
+//	define degC = K offset 273.15
+//
+// Everything after a # is a comment.
+func (r *Registry) Load(src io.Reader) error {
+	scanner := bufio.NewScanner(src)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if i := strings.Index(text, "#"); i >= 0 {
+			text = text[:i]
+		}
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+		if err := r.Exec(text); err != nil {
+			return fmt.Errorf("line %d: %s", line, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// Exec runs a single base or define statement.
+func (r *Registry) Exec(stmt string) error {
+	fields := strings.Fields(stmt)
+	switch {
+	case len(fields) == 3 && fields[0] == "base":
+		return r.defineBase(fields[1], fields[2])
+	case len(fields) >= 4 && fields[0] == "define" && fields[2] == "=":
+		return r.Define(fields[1], strings.Join(fields[3:], " "))
+	}
+	return fmt.Errorf("cannot understand %q", stmt)
+}
+
+func (r *Registry) defineBase(name, quantity string) error {
+	if _, ok := r.units[name]; ok {
+		return fmt.Errorf("unit %q is already defined", name)
+	}
+	for i, q := range baseQuantities {
+		if q == quantity {
+			u := &Unit{Factor: one, Terms: []Term{{Name: name, Power: 1}}}
+			u.Dim[i] = 1
+			r.units[name] = u
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown base quantity %q", quantity)
+}
+
+// Define adds name as a unit equal to the expression def, which may end
+// in an offset clause for affine units. Factors are kept as rationals so
+// conversions between exactly defined units stay exact. Prefixed forms
+// of the new unit need no definition of their own.
+func (r *Registry) Define(name, def string) error {
+	if !isName(name) {
+		return fmt.Errorf("invalid unit name %q", name)
+	}
+	if _, ok := r.units[name]; ok {
+		return fmt.Errorf("unit %q is already defined", name)
+	}
+	var offset *big.Rat
+	if i := strings.Index(def, " offset "); i >= 0 {
+		o, ok := new(big.Rat).SetString(strings.TrimSpace(def[i+len(" offset "):]))
+		if !ok {
+			return fmt.Errorf("invalid offset in %q", def)
+		}
+		offset = o
+		def = def[:i]
+	}
+	u, err := r.Parse(def)
+	if err != nil {
+		return err
+	}
+	r.units[name] = &Unit{
+		Factor: u.Factor,
+		Offset: offset,
+		Dim:    u.Dim,
+		Terms:  []Term{{Name: name, Power: 1}},
+	}
+	return nil
+}
+
+// Parse reads a unit expression such as "kg*m/s^2" or "1/1000 kg".
+// Every / divides by the next factor only, so J/mol/K is J mol^-1 K^-1.
+func (r *Registry) Parse(expr string) (*Unit, error) {
+	s := &scanner{input: expr}
+	result := One
+	divide := false
+	for {
+		s.skipSpace()
+		if s.done() {
+			break
+		}
+		var factor *Unit
+		switch ch := s.peek(); {
+		case ch == '*' || ch == '·':
+			s.next()
+			continue
+		case ch == '/':
+			s.next()
+			divide = true
+			continue
+		case ch >= '0' && ch <= '9' || ch == '.':
+			num, ok := new(big.Rat).SetString(s.number())
+			if !ok {
+				return nil, fmt.Errorf("invalid number in unit %q", expr)
+			}
+			factor = &Unit{Factor: num}
+		case isLetter(ch):
+			name := s.name()
+			u, ok := r.Lookup(name)
+			if !ok {
+				return nil, fmt.Errorf("unknown unit %q", name)
+			}
+			factor = u
+			if s.peek() == '^' {
+				s.next()
+				n, ok := s.integer()
+				if !ok {
+					return nil, fmt.Errorf("invalid exponent in unit %q", expr)
+				}
+				factor = factor.Pow(n)
+			}
+		default:
+			return nil, fmt.Errorf("unexpected %q in unit %q", ch, expr)
+		}
+		if divide {
+			result = result.Div(factor)
+		} else {
+			result = result.Mul(factor)
+		}
+		divide = false
+	}
+	if divide {
+		return nil, fmt.Errorf("unit %q ends in /", expr)
+	}
+	return result, nil
+}
+
+func isLetter(r rune) bool {
+	return unicode.IsLetter(r) || r == '°' || r == '_'
+}
+
+func isName(name string) bool {
+	for i, r := range name {
+		if !isLetter(r) && !(i > 0 && unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return name != ""
+}
+
+type scanner struct {
+	input string
+	pos   int
+}
+
+func (s *scanner) done() bool { return s.pos >= len(s.input) }
+
+func (s *scanner) peek() rune {
+	r, _ := utf8.DecodeRuneInString(s.input[s.pos:])
+	return r
+}
+
+func (s *scanner) next() rune {
+	r, size := utf8.DecodeRuneInString(s.input[s.pos:])
+	s.pos += size
+	return r
+}
+
+func (s *scanner) skipSpace() {
+	for !s.done() && unicode.IsSpace(s.peek()) {
+		s.next()
+	}
+}
+
+func (s *scanner) name() string {
+	start := s.pos
+	for !s.done() && (isLetter(s.peek()) || unicode.IsDigit(s.peek())) {
+		s.next()
+	}
+	return s.input[start:s.pos]
+}
+
+func (s *scanner) number() string {
+	start := s.pos
+	for !s.done() {
+		ch := s.peek()
+		if ch >= '0' && ch <= '9' || ch == '.' {
+			s.next()
+		} else if ch == 'e' && s.pos+1 < len(s.input) && strings.ContainsRune("0123456789-+", rune(s.input[s.pos+1])) {
+			s.next()
+			s.next()
+		} else {
+			break
+		}
+	}
+	return s.input[start:s.pos]
+}
+
+func (s *scanner) integer() (int, bool) {
+	sign := 1
+	if s.peek() == '-' {
+		s.next()
+		sign = -1
+	}
+	start := s.pos
+	for !s.done() && s.peek() >= '0' && s.peek() <= '9' {
+		s.next()
+	}
+	if start == s.pos {
+		return 0, false
+	}
+	n := 0
+	fmt.Sscanf(s.input[start:s.pos], "%d", &n)
+	return sign * n, true
+}
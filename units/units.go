@@ -0,0 +1,182 @@
+package units
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Dimension holds the exponent of every base quantity.
+type Dimension [8]int
+
+var baseQuantities = [8]string{
+	"length", "mass", "time", "current", "temperature", "amount", "luminosity", "information",
+}
+
+func (d Dimension) String() string {
+	var parts []string
+	for i, p := range d {
+		if p != 0 {
+			parts = append(parts, fmt.Sprintf("%s^%d", baseQuantities[i], p))
+		}
+	}
+	if len(parts) == 0 {
+		return "dimensionless"
+	}
+	return strings.Join(parts, "*")
+}
+
+// Term is one named unit raised to a power, as written by the user.
+type Term struct {
+	Name  string
+	Power int
+}
+
+// Unit is a product of terms together with its size in SI base units.
+type Unit struct {
+	Factor *big.Rat
+	// Offset is only set for affine units such as degC, it is added
+	// after scaling when converting to SI.
+	Offset *big.Rat
+	Dim    Dimension
+	Terms  []Term
+}
+
+var one = big.NewRat(1, 1)
+
+// One is the dimensionless unit.
+var One = &Unit{Factor: one}
+
+func (u *Unit) Mul(v *Unit) *Unit {
+	// scaling by a plain number keeps the offset of degC and friends
+	if u.isOne() {
+		return v
+	}
+	if v.isOne() {
+		return u
+	}
+	result := &Unit{Factor: new(big.Rat).Mul(u.Factor, v.Factor)}
+	for i := range result.Dim {
+		result.Dim[i] = u.Dim[i] + v.Dim[i]
+	}
+	result.Terms = mergeTerms(u.Terms, v.Terms, 1)
+	return result
+}
+
+func (u *Unit) Div(v *Unit) *Unit {
+	result := &Unit{Factor: new(big.Rat).Quo(u.Factor, v.Factor)}
+	for i := range result.Dim {
+		result.Dim[i] = u.Dim[i] - v.Dim[i]
+	}
+	result.Terms = mergeTerms(u.Terms, v.Terms, -1)
+	return result
+}
+
+func (u *Unit) Pow(n int) *Unit {
+	result := &Unit{Factor: ratPow(u.Factor, n)}
+	for i := range result.Dim {
+		result.Dim[i] = u.Dim[i] * n
+	}
+	for _, t := range u.Terms {
+		if t.Power*n != 0 {
+			result.Terms = append(result.Terms, Term{Name: t.Name, Power: t.Power * n})
+		}
+	}
+	return result
+}
+
+func (u *Unit) isOne() bool {
+	return len(u.Terms) == 0 && u.Factor.Cmp(one) == 0
+}
+
+func (u *Unit) Dimensionless() bool {
+	return u.Dim == Dimension{}
+}
+
+// Compatible reports whether quantities in u and v can be converted
+// into each other.
+func (u *Unit) Compatible(v *Unit) bool {
+	return u.Dim == v.Dim
+}
+
+// Affine reports whether converting to or from u needs an offset.
+func (u *Unit) Affine() bool {
+	return u.Offset != nil && u.Offset.Sign() != 0
+}
+
+func (u *Unit) String() string {
+	var num, den []string
+	for _, t := range u.Terms {
+		switch {
+		case t.Power == 1:
+			num = append(num, t.Name)
+		case t.Power > 1:
+			num = append(num, fmt.Sprintf("%s^%d", t.Name, t.Power))
+		case t.Power == -1:
+			den = append(den, t.Name)
+		case t.Power < -1:
+			den = append(den, fmt.Sprintf("%s^%d", t.Name, -t.Power))
+		}
+	}
+	out := strings.Join(num, "*")
+	if out == "" {
+		out = "1"
+	}
+	for _, d := range den {
+		out += "/" + d
+	}
+	return out
+}
+
+// Convert changes value from unit from into unit to. Products of units
+// lose their offset, so degC/s converts as a temperature difference.
+func Convert(value *big.Rat, from, to *Unit) (*big.Rat, error) {
+	if !from.Compatible(to) {
+		return nil, fmt.Errorf("cannot convert %s to %s: %s is not %s", from, to, from.Dim, to.Dim)
+	}
+	si := new(big.Rat).Mul(value, from.Factor)
+	if from.Affine() {
+		si.Add(si, from.Offset)
+	}
+	if to.Affine() {
+		si.Sub(si, to.Offset)
+	}
+	return si.Quo(si, to.Factor), nil
+}
+
+func mergeTerms(a, b []Term, sign int) []Term {
+	result := append([]Term{}, a...)
+	for _, t := range b {
+		found := false
+		for i := range result {
+			if result[i].Name == t.Name {
+				result[i].Power += sign * t.Power
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, Term{Name: t.Name, Power: sign * t.Power})
+		}
+	}
+	terms := result[:0]
+	for _, t := range result {
+		if t.Power != 0 {
+			terms = append(terms, t)
+		}
+	}
+	return terms
+}
+
+func ratPow(r *big.Rat, n int) *big.Rat {
+	result := big.NewRat(1, 1)
+	base := r
+	if n < 0 {
+		base = new(big.Rat).Inv(r)
+		n = -n
+	}
+	for i := 0; i < n; i++ {
+		result.Mul(result, base)
+	}
+	return result
+}
@@ -0,0 +1,100 @@
+package units
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		value    string
+		from, to string
+		expected string
+	}{
+		{"60", "km/h", "m/s", "50/3"},
+		{"1", "mi", "km", "201168/125000"},
+		{"100", "degC", "degF", "212"},
+		{"1", "kWh", "J", "3600000"},
+		{"2", "KiB", "B", "2048"},
+		{"1", "psi", "Pa", "8896443230521/1290320000"},
+	}
+	for _, tt := range tests {
+		from, err := Parse(tt.from)
+		if err != nil {
+			t.Fatalf("Parse(%q): %s", tt.from, err)
+		}
+		to, err := Parse(tt.to)
+		if err != nil {
+			t.Fatalf("Parse(%q): %s", tt.to, err)
+		}
+		value, _ := new(big.Rat).SetString(tt.value)
+		got, err := Convert(value, from, to)
+		if err != nil {
+			t.Fatalf("Convert(%s %s to %s): %s", tt.value, tt.from, tt.to, err)
+		}
+		expected, _ := new(big.Rat).SetString(tt.expected)
+		if got.Cmp(expected) != 0 {
+			t.Errorf("%s %s to %s: expected %s got %s", tt.value, tt.from, tt.to, expected.RatString(), got.RatString())
+		}
+	}
+}
+
+func TestIncompatible(t *testing.T) {
+	m, _ := Parse("m")
+	s, _ := Parse("s")
+	if _, err := Convert(big.NewRat(1, 1), m, s); err == nil {
+		t.Fatalf("expected an error converting m to s")
+	}
+}
+
+func TestString(t *testing.T) {
+	u, err := Parse("kg*m^2/s^2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.String() != "kg*m^2/s^2" {
+		t.Errorf("expected kg*m^2/s^2 got %s", u.String())
+	}
+	if u.Dim != (Dimension{2, 1, -2}) {
+		t.Errorf("wrong dimension %s", u.Dim)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	r := NewRegistry()
+	defs := `
+base m length
+define inch = 0.0254 m
+define U = 1.75 inch   # rack unit
+define rack = 42 U
+`
+	if err := r.Load(strings.NewReader(defs)); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		from, to string
+		expected string
+	}{
+		{"rack", "m", "18669/10000"},
+		{"krack", "rack", "1000"},
+		{"µrack", "m", "18669/10000000000"},
+	} {
+		from, err := r.Parse(tt.from)
+		if err != nil {
+			t.Fatalf("Parse(%q): %s", tt.from, err)
+		}
+		to, _ := r.Parse(tt.to)
+		got, err := Convert(big.NewRat(1, 1), from, to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.RatString() != tt.expected {
+			t.Errorf("1 %s to %s: expected %s got %s", tt.from, tt.to, tt.expected, got.RatString())
+		}
+	}
+
+	if err := r.Exec("define rack = 2 m"); err == nil {
+		t.Errorf("expected an error redefining rack")
+	}
+}